@@ -0,0 +1,591 @@
+// Package api serves a REST API to manage the monitors of a running
+// scheduler: create, update, delete, pause and resume them, check them on
+// demand and list their recent results.
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+var (
+	_ http.Handler = (*Server)(nil)
+
+	defaultLimit = 100
+	maxBodySize  = int64(1 << 20)
+)
+
+type Config struct {
+	// APIKey 是必填的, 以 "Authorization: Bearer <key>" 或 "X-API-Key: <key>" 传入
+	APIKey string `json:"api_key"`
+	// File 保存通过 API 修改后的全部监控项, 为空时修改只保存在内存中, 见 LoadDefinitions
+	File string `json:"file"`
+}
+
+// States gives the current state of monitors, it is implemented by *status_neko.Tracker.
+type States interface {
+	State(name string) (status_neko.MonitorState, bool)
+}
+
+type option struct {
+	onRemove     func(name string)
+	dependencies *status_neko.Dependencies
+	wrap         func(status_neko.Definition, status_neko.Monitor) (status_neko.Monitor, error)
+}
+
+// SetOnRemove is called after a monitor was deleted, e.g. to forget its
+// state and metrics.
+func SetOnRemove(fn func(name string)) status_neko.Option[*option] {
+	return func(o *option) {
+		o.onRemove = fn
+	}
+}
+
+// SetDependencies keeps the dependency graph in sync with the monitors,
+// definitions with unknown parents or cycles are rejected.
+func SetDependencies(d *status_neko.Dependencies) status_neko.Option[*option] {
+	return func(o *option) {
+		o.dependencies = d
+	}
+}
+
+// SetWrap decorates the monitors created through the API, e.g. with
+// middleware.Wrap, so that they behave like the ones of the configuration file.
+func SetWrap(fn func(status_neko.Definition, status_neko.Monitor) (status_neko.Monitor, error)) status_neko.Option[*option] {
+	return func(o *option) {
+		o.wrap = fn
+	}
+}
+
+// Monitor is a monitor as returned by the API.
+type Monitor struct {
+	Name   string                    `json:"name"`
+	Type   string                    `json:"type"`
+	Paused bool                      `json:"paused"`
+	State  *status_neko.MonitorState `json:"state,omitempty"`
+	// Config is the definition the monitor was created with.
+	Config json.RawMessage `json:"config"`
+}
+
+// Check is the outcome of an on-demand check.
+type Check struct {
+	Name     string               `json:"name"`
+	Result   *status_neko.Result  `json:"result"`
+	Error    string               `json:"error,omitempty"`
+	Start    time.Time            `json:"start"`
+	Duration status_neko.Duration `json:"duration"`
+}
+
+// Error is the body of every failed request, Fields point to the invalid
+// fields of the request. Dependents lists the monitors depending on a monitor
+// that cannot be deleted.
+type Error struct {
+	Error      string                  `json:"error"`
+	Fields     status_neko.FieldErrors `json:"fields,omitempty"`
+	Dependents []string                `json:"dependents,omitempty"`
+}
+
+// Server serves the API:
+//
+//	GET    /api/monitors                 list the monitors
+//	POST   /api/monitors                 create a monitor from a definition
+//	GET    /api/monitors/{name}          get a monitor
+//	PUT    /api/monitors/{name}          replace the definition of a monitor
+//	DELETE /api/monitors/{name}          delete a monitor, unless others depend on it
+//	POST   /api/monitors/{name}/pause    stop checking a monitor
+//	POST   /api/monitors/{name}/resume   check a paused monitor again
+//	POST   /api/monitors/{name}/check    check a monitor now and return the result
+//	GET    /api/monitors/{name}/results  recent results, ?limit=100&from=&to= (RFC 3339)
+type Server struct {
+	config    Config
+	option    *option
+	scheduler *status_neko.Scheduler
+	store     status_neko.Store
+	states    States
+	mux       *http.ServeMux
+
+	// mu serializes changes so that the scheduler, definitions and the
+	// file stay in sync.
+	mu          sync.Mutex
+	definitions map[string]status_neko.Definition
+}
+
+// NewServer manages the monitors of scheduler, definitions are the monitors
+// already added to it. store may be nil, results are then not available.
+func NewServer(config Config, scheduler *status_neko.Scheduler, store status_neko.Store, states States, definitions []status_neko.Definition, opts ...status_neko.Option[*option]) (*Server, error) {
+	if config.APIKey == "" {
+		return nil, errors.New("api key is required")
+	}
+	o := &option{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	s := &Server{
+		config:      config,
+		option:      o,
+		scheduler:   scheduler,
+		store:       store,
+		states:      states,
+		mux:         http.NewServeMux(),
+		definitions: make(map[string]status_neko.Definition, len(definitions)),
+	}
+	for _, d := range definitions {
+		s.definitions[d.Name] = d
+	}
+
+	s.mux.HandleFunc("GET /api/monitors", s.list)
+	s.mux.HandleFunc("POST /api/monitors", s.create)
+	s.mux.HandleFunc("GET /api/monitors/{name}", s.get)
+	s.mux.HandleFunc("PUT /api/monitors/{name}", s.update)
+	s.mux.HandleFunc("DELETE /api/monitors/{name}", s.delete)
+	s.mux.HandleFunc("POST /api/monitors/{name}/pause", s.pause)
+	s.mux.HandleFunc("POST /api/monitors/{name}/resume", s.resume)
+	s.mux.HandleFunc("POST /api/monitors/{name}/check", s.check)
+	s.mux.HandleFunc("GET /api/monitors/{name}/results", s.results)
+	return s, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("X-API-Key")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		key = bearer
+	}
+	if subtle.ConstantTimeCompare([]byte(key), []byte(s.config.APIKey)) != 1 {
+		writeError(w, http.StatusUnauthorized, errors.New("invalid api key"))
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// LoadDefinitions reads the monitors saved by a Server, ok is false when the
+// file does not exist yet.
+func LoadDefinitions(path string) (definitions []status_neko.Definition, ok bool, err error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if err := json.Unmarshal(b, &definitions); err != nil {
+		return nil, false, fmt.Errorf("%s: %w", path, err)
+	}
+	return definitions, true, nil
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	monitors := make([]Monitor, 0, len(s.definitions))
+	for _, d := range s.definitions {
+		monitors = append(monitors, s.monitor(d))
+	}
+	s.mu.Unlock()
+
+	sort.Slice(monitors, func(i, j int) bool {
+		return monitors[i].Name < monitors[j].Name
+	})
+	writeJSON(w, http.StatusOK, monitors)
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request) {
+	d, m, ok := s.decode(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.definitions[d.Name]; ok {
+		writeError(w, http.StatusConflict, fmt.Errorf("monitor %s already exists", d.Name))
+		return
+	}
+	if !s.depend(w, d) {
+		return
+	}
+	if err := s.scheduler.Add(d.Name, m, d.Schedule()...); err != nil {
+		if s.option.dependencies != nil {
+			s.option.dependencies.Remove(d.Name)
+		}
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	s.definitions[d.Name] = d
+	if !s.save(w) {
+		return
+	}
+	writeJSON(w, http.StatusCreated, s.monitor(d))
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.definition(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.monitor(d))
+}
+
+func (s *Server) update(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	d, m, ok := s.decode(w, r)
+	if !ok {
+		return
+	}
+	if d.Name != name {
+		writeFields(w, status_neko.FieldErrors{{Field: "name", Message: "must match the monitor being updated"}})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.definition(w, r)
+	if !ok {
+		return
+	}
+	if !s.depend(w, d) {
+		return
+	}
+	// 新的监控项已经构建并校验, 替换失败时旧的监控项继续运行
+	if err := s.scheduler.Replace(name, m, d.Schedule()...); err != nil {
+		if s.option.dependencies != nil {
+			_ = s.option.dependencies.Set(name, old.DependsOn)
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.definitions[name] = d
+	if !s.save(w) {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.monitor(d))
+}
+
+func (s *Server) delete(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.definition(w, r)
+	if !ok {
+		return
+	}
+	// 被依赖的监控项不能删除, 否则保存的配置在下次启动时无法加载
+	if dependents := s.dependents(d.Name); len(dependents) > 0 {
+		writeJSON(w, http.StatusConflict, Error{
+			Error:      fmt.Sprintf("monitor %s is a dependency of %s", d.Name, strings.Join(dependents, ", ")),
+			Dependents: dependents,
+		})
+		return
+	}
+	s.scheduler.Remove(d.Name)
+	delete(s.definitions, d.Name)
+	if s.option.dependencies != nil {
+		s.option.dependencies.Remove(d.Name)
+	}
+	if !s.save(w) {
+		return
+	}
+	if s.option.onRemove != nil {
+		s.option.onRemove(d.Name)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) pause(w http.ResponseWriter, r *http.Request) {
+	s.setPaused(w, r, true)
+}
+
+func (s *Server) resume(w http.ResponseWriter, r *http.Request) {
+	s.setPaused(w, r, false)
+}
+
+func (s *Server) setPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.definition(w, r)
+	if !ok {
+		return
+	}
+
+	var err error
+	if paused {
+		err = s.scheduler.Pause(d.Name)
+	} else {
+		err = s.scheduler.Resume(d.Name)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	d.Paused = paused
+	d.Config, err = setField(d.Config, "paused", paused)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.definitions[d.Name] = d
+	if !s.save(w) {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.monitor(d))
+}
+
+func (s *Server) check(w http.ResponseWriter, r *http.Request) {
+	report, err := s.scheduler.Check(r.Context(), r.PathValue("name"))
+	if errors.Is(err, status_neko.ErrMonitorNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	c := Check{
+		Name:     report.Name,
+		Result:   report.Result,
+		Start:    report.Start,
+		Duration: status_neko.Duration(report.Duration),
+	}
+	if report.Err != nil {
+		c.Error = report.Err.Error()
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+func (s *Server) results(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	d, ok := s.definition(w, r)
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	if s.store == nil {
+		writeError(w, http.StatusNotImplemented, errors.New("no store configured"))
+		return
+	}
+
+	q, fields := query(r)
+	if len(fields) > 0 {
+		writeFields(w, fields)
+		return
+	}
+	q.Monitor = d.Name
+
+	records, err := s.store.Query(r.Context(), q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if records == nil {
+		records = []status_neko.Record{}
+	}
+	writeJSON(w, http.StatusOK, records)
+}
+
+// depend checks that the parents of d exist and sets them in the dependency
+// graph, the caller must hold s.mu. It reports the error itself and returns
+// false on failure.
+func (s *Server) depend(w http.ResponseWriter, d status_neko.Definition) bool {
+	for _, parent := range d.DependsOn {
+		if _, ok := s.definitions[parent]; !ok {
+			writeFields(w, status_neko.FieldErrors{{Field: "depends_on", Message: fmt.Sprintf("unknown monitor %s", parent)}})
+			return false
+		}
+	}
+	if s.option.dependencies == nil {
+		return true
+	}
+	if err := s.option.dependencies.Set(d.Name, d.DependsOn); err != nil {
+		writeFields(w, status_neko.FieldErrors{{Field: "depends_on", Message: err.Error()}})
+		return false
+	}
+	return true
+}
+
+// dependents returns the monitors depending on name, the caller must hold s.mu.
+func (s *Server) dependents(name string) []string {
+	var dependents []string
+	for _, d := range s.definitions {
+		for _, parent := range d.DependsOn {
+			if parent == name {
+				dependents = append(dependents, d.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(dependents)
+	return dependents
+}
+
+// definition finds the monitor named in the path, the caller must hold s.mu.
+func (s *Server) definition(w http.ResponseWriter, r *http.Request) (status_neko.Definition, bool) {
+	name := r.PathValue("name")
+	d, ok := s.definitions[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("monitor %s not found", name))
+	}
+	return d, ok
+}
+
+func (s *Server) monitor(d status_neko.Definition) Monitor {
+	m := Monitor{
+		Name:   d.Name,
+		Type:   d.Type,
+		Paused: d.Paused,
+		Config: d.Config,
+	}
+	if paused, err := s.scheduler.Paused(d.Name); err == nil {
+		m.Paused = paused
+	}
+	if state, ok := s.states.State(d.Name); ok {
+		m.State = &state
+	}
+	return m
+}
+
+// save writes all definitions to the file, the caller must hold s.mu.
+// It reports the error itself and returns false on failure.
+func (s *Server) save(w http.ResponseWriter) bool {
+	if s.config.File == "" {
+		return true
+	}
+
+	definitions := make([]json.RawMessage, 0, len(s.definitions))
+	names := make([]string, 0, len(s.definitions))
+	for name := range s.definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		definitions = append(definitions, s.definitions[name].Config)
+	}
+
+	err := func() error {
+		b, err := json.MarshalIndent(definitions, "", "  ")
+		if err != nil {
+			return err
+		}
+		tmp := s.config.File + ".tmp"
+		if err := os.WriteFile(tmp, b, 0o600); err != nil {
+			return err
+		}
+		return os.Rename(tmp, s.config.File)
+	}()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("save monitors: %w", err))
+		return false
+	}
+	return true
+}
+
+// decode reads, validates and builds the definition in the request body.
+func (s *Server) decode(w http.ResponseWriter, r *http.Request) (status_neko.Definition, status_neko.Monitor, bool) {
+	var d status_neko.Definition
+	b, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err == nil {
+		err = json.Unmarshal(b, &d)
+	}
+	if err == nil {
+		err = d.Validate()
+	}
+	if err != nil {
+		writeInvalid(w, err)
+		return d, nil, false
+	}
+
+	m, err := d.Build()
+	if err == nil && s.option.wrap != nil {
+		m, err = s.option.wrap(d, m)
+	}
+	if err != nil {
+		writeInvalid(w, err)
+		return d, nil, false
+	}
+	return d, m, true
+}
+
+// query reads the limit, from and to parameters of a results request.
+func query(r *http.Request) (status_neko.Query, status_neko.FieldErrors) {
+	q := status_neko.Query{Limit: defaultLimit}
+	var fields status_neko.FieldErrors
+
+	values := r.URL.Query()
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			fields = append(fields, &status_neko.FieldError{Field: "limit", Message: "must be a non-negative integer"})
+		}
+		q.Limit = limit
+	}
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"from", &q.From}, {"to", &q.To}} {
+		v := values.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			fields = append(fields, &status_neko.FieldError{Field: p.name, Message: "must be an RFC 3339 time"})
+		}
+		*p.t = t
+	}
+	return q, fields
+}
+
+// setField sets a top level field of a JSON object.
+func setField(raw json.RawMessage, name string, value interface{}) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	fields[name] = b
+	return json.Marshal(fields)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, Error{Error: err.Error()})
+}
+
+// writeInvalid reports a bad request, pointing to the invalid fields when known.
+func writeInvalid(w http.ResponseWriter, err error) {
+	if fields := status_neko.Fields(err); len(fields) > 0 {
+		writeFields(w, fields)
+		return
+	}
+	writeError(w, http.StatusBadRequest, err)
+}
+
+func writeFields(w http.ResponseWriter, fields status_neko.FieldErrors) {
+	writeJSON(w, http.StatusBadRequest, Error{Error: fields.Error(), Fields: fields})
+}
@@ -0,0 +1,367 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/songzhibin97/status-neko/provide/push"
+	_ "github.com/songzhibin97/status-neko/provide/tcp"
+	"github.com/songzhibin97/status-neko/store/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type client struct {
+	t      *testing.T
+	server http.Handler
+	key    string
+}
+
+func (c client) do(method, path, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if c.key != "" {
+		r.Header.Set("Authorization", "Bearer "+c.key)
+	}
+	w := httptest.NewRecorder()
+	c.server.ServeHTTP(w, r)
+	return w
+}
+
+func (c client) decode(w *httptest.ResponseRecorder, code int, v interface{}) {
+	require.Equal(c.t, code, w.Code, w.Body.String())
+	require.NoError(c.t, json.Unmarshal(w.Body.Bytes(), v))
+}
+
+// listen returns the port of a TCP listener accepting connections.
+func listen(t *testing.T) int {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func newServer(t *testing.T, config Config, opts ...status_neko.Option[*option]) (client, *status_neko.Scheduler, status_neko.Store) {
+	if config.APIKey == "" {
+		config.APIKey = "secret"
+	}
+	scheduler := status_neko.NewScheduler()
+	store := memory.NewMemory(memory.Config{})
+	tracker := status_neko.NewTracker()
+	s, err := NewServer(config, scheduler, store, tracker, nil, opts...)
+	require.NoError(t, err)
+	return client{t: t, server: s, key: config.APIKey}, scheduler, store
+}
+
+func TestNewServer_Error(t *testing.T) {
+	_, err := NewServer(Config{}, status_neko.NewScheduler(), nil, status_neko.NewTracker(), nil)
+	assert.Error(t, err)
+}
+
+func TestServer_Auth(t *testing.T) {
+	c, _, _ := newServer(t, Config{})
+	c.key = ""
+	assert.Equal(t, http.StatusUnauthorized, c.do(http.MethodGet, "/api/monitors", "").Code)
+	c.key = "wrong"
+	assert.Equal(t, http.StatusUnauthorized, c.do(http.MethodGet, "/api/monitors", "").Code)
+
+	c.key = ""
+	r := httptest.NewRequest(http.MethodGet, "/api/monitors", nil)
+	r.Header.Set("X-API-Key", "secret")
+	w := httptest.NewRecorder()
+	c.server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestServer_CRUD(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "monitors.json")
+	var removed []string
+	c, scheduler, _ := newServer(t, Config{File: path}, SetOnRemove(func(name string) {
+		removed = append(removed, name)
+	}))
+	port := listen(t)
+
+	var m Monitor
+	body := fmt.Sprintf(`{"name": "local", "type": "tcp", "interval": "1h", "host": "127.0.0.1", "port": %d}`, port)
+	c.decode(c.do(http.MethodPost, "/api/monitors", body), http.StatusCreated, &m)
+	assert.Equal(t, "local", m.Name)
+	assert.Equal(t, "tcp", m.Type)
+	assert.False(t, m.Paused)
+	assert.Equal(t, []string{"local"}, scheduler.Names())
+
+	assert.Equal(t, http.StatusConflict, c.do(http.MethodPost, "/api/monitors", body).Code)
+
+	var list []Monitor
+	c.decode(c.do(http.MethodGet, "/api/monitors", ""), http.StatusOK, &list)
+	require.Len(t, list, 1)
+	assert.JSONEq(t, body, string(list[0].Config))
+
+	updated := fmt.Sprintf(`{"name": "local", "type": "tcp", "interval": "2h", "host": "localhost", "port": %d}`, port)
+	c.decode(c.do(http.MethodPut, "/api/monitors/local", updated), http.StatusOK, &m)
+	assert.JSONEq(t, updated, string(m.Config))
+	assert.Equal(t, http.StatusNotFound, c.do(http.MethodPut, "/api/monitors/missing",
+		strings.Replace(updated, `"local"`, `"missing"`, 1)).Code)
+
+	c.decode(c.do(http.MethodPost, "/api/monitors/local/pause", ""), http.StatusOK, &m)
+	assert.True(t, m.Paused)
+	paused, err := scheduler.Paused("local")
+	require.NoError(t, err)
+	assert.True(t, paused)
+
+	// 暂停状态被保存
+	definitions, ok, err := LoadDefinitions(path)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, definitions, 1)
+	assert.True(t, definitions[0].Paused)
+	assert.Equal(t, "localhost", func() string {
+		var v struct{ Host string }
+		require.NoError(t, json.Unmarshal(definitions[0].Config, &v))
+		return v.Host
+	}())
+
+	c.decode(c.do(http.MethodPost, "/api/monitors/local/resume", ""), http.StatusOK, &m)
+	assert.False(t, m.Paused)
+
+	c.decode(c.do(http.MethodGet, "/api/monitors/local", ""), http.StatusOK, &m)
+	assert.Equal(t, "local", m.Name)
+
+	assert.Equal(t, http.StatusNoContent, c.do(http.MethodDelete, "/api/monitors/local", "").Code)
+	assert.Equal(t, http.StatusNotFound, c.do(http.MethodDelete, "/api/monitors/local", "").Code)
+	assert.Equal(t, http.StatusNotFound, c.do(http.MethodGet, "/api/monitors/local", "").Code)
+	assert.Empty(t, scheduler.Names())
+	assert.Equal(t, []string{"local"}, removed)
+
+	definitions, ok, err = LoadDefinitions(path)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Empty(t, definitions)
+
+	_, ok, err = LoadDefinitions(filepath.Join(t.TempDir(), "missing.json"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestServer_Validation(t *testing.T) {
+	c, _, _ := newServer(t, Config{})
+
+	tests := []struct {
+		name   string
+		body   string
+		fields []string
+	}{
+		{"missing name and type", `{}`, []string{"name", "type"}},
+		{"unknown type", `{"name": "a", "type": "nope"}`, []string{"type"}},
+		{"bad interval", `{"name": "a", "type": "tcp", "interval": "often"}`, []string{"interval"}},
+		{"negative timeout", `{"name": "a", "type": "tcp", "timeout": -1, "host": "h", "port": 1}`, []string{"timeout"}},
+		{"provider fields", `{"name": "a", "type": "tcp", "port": 70000}`, []string{"host", "port"}},
+		{"provider type", `{"name": "a", "type": "tcp", "host": "h", "port": "80"}`, []string{"port"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e Error
+			c.decode(c.do(http.MethodPost, "/api/monitors", tt.body), http.StatusBadRequest, &e)
+			fields := make([]string, 0, len(e.Fields))
+			for _, f := range e.Fields {
+				fields = append(fields, f.Field)
+			}
+			assert.Equal(t, tt.fields, fields, e.Error)
+		})
+	}
+
+	var e Error
+	c.decode(c.do(http.MethodPost, "/api/monitors", `{`), http.StatusBadRequest, &e)
+	assert.NotEmpty(t, e.Error)
+	assert.Empty(t, e.Fields)
+
+	c.decode(c.do(http.MethodPost, "/api/monitors", `{"name": "a", "type": "tcp", "host": "h", "port": 1}`), http.StatusCreated, &Monitor{})
+	c.decode(c.do(http.MethodPut, "/api/monitors/a", `{"name": "b", "type": "tcp", "host": "h", "port": 1}`), http.StatusBadRequest, &e)
+	require.Len(t, e.Fields, 1)
+	assert.Equal(t, "name", e.Fields[0].Field)
+}
+
+func TestServer_Dependencies(t *testing.T) {
+	deps, err := status_neko.NewDependencies()
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "monitors.json")
+	c, _, _ := newServer(t, Config{File: path}, SetDependencies(deps))
+
+	monitor := func(name string, parents ...string) string {
+		dependsOn, _ := json.Marshal(parents)
+		return fmt.Sprintf(`{"name": %q, "type": "tcp", "host": "h", "port": 1, "depends_on": %s}`, name, dependsOn)
+	}
+
+	var e Error
+	c.decode(c.do(http.MethodPost, "/api/monitors", monitor("tcp", "switch")), http.StatusBadRequest, &e)
+	require.Len(t, e.Fields, 1)
+	assert.Equal(t, "depends_on", e.Fields[0].Field)
+	assert.Equal(t, "unknown monitor switch", e.Fields[0].Message)
+
+	c.decode(c.do(http.MethodPost, "/api/monitors", monitor("switch")), http.StatusCreated, &Monitor{})
+	c.decode(c.do(http.MethodPost, "/api/monitors", monitor("tcp", "switch")), http.StatusCreated, &Monitor{})
+	assert.Equal(t, []string{"switch"}, deps.Parents("tcp"))
+
+	c.decode(c.do(http.MethodPut, "/api/monitors/switch", monitor("switch", "tcp")), http.StatusBadRequest, &e)
+	require.Len(t, e.Fields, 1)
+	assert.Equal(t, "depends_on", e.Fields[0].Field)
+	assert.Equal(t, "dependency cycle: switch -> tcp -> switch", e.Fields[0].Message)
+
+	// 被依赖的监控项不能删除
+	c.decode(c.do(http.MethodPost, "/api/monitors", monitor("http", "switch")), http.StatusCreated, &Monitor{})
+	e = Error{}
+	c.decode(c.do(http.MethodDelete, "/api/monitors/switch", ""), http.StatusConflict, &e)
+	assert.Equal(t, []string{"http", "tcp"}, e.Dependents)
+	assert.Equal(t, "monitor switch is a dependency of http, tcp", e.Error)
+	reload := func() {
+		definitions, ok, err := LoadDefinitions(path)
+		require.NoError(t, err)
+		require.True(t, ok)
+		_, err = status_neko.NewDependencies(definitions...)
+		require.NoError(t, err)
+	}
+	reload()
+
+	assert.Equal(t, http.StatusNoContent, c.do(http.MethodDelete, "/api/monitors/tcp", "").Code)
+	assert.Empty(t, deps.Parents("tcp"))
+	assert.Equal(t, http.StatusNoContent, c.do(http.MethodDelete, "/api/monitors/http", "").Code)
+	assert.Equal(t, http.StatusNoContent, c.do(http.MethodDelete, "/api/monitors/switch", "").Code)
+	reload()
+}
+
+func TestServer_UpdateFailed(t *testing.T) {
+	deps, err := status_neko.NewDependencies()
+	require.NoError(t, err)
+	c, scheduler, _ := newServer(t, Config{}, SetDependencies(deps))
+
+	monitor := func(name string, parents ...string) string {
+		dependsOn, _ := json.Marshal(parents)
+		return fmt.Sprintf(`{"name": %q, "type": "tcp", "host": "h", "port": 1, "depends_on": %s}`, name, dependsOn)
+	}
+	c.decode(c.do(http.MethodPost, "/api/monitors", monitor("switch")), http.StatusCreated, &Monitor{})
+	c.decode(c.do(http.MethodPost, "/api/monitors", monitor("tcp")), http.StatusCreated, &Monitor{})
+
+	// 调度器中的监控项无法替换时, 定义和依赖关系保持不变
+	scheduler.Remove("tcp")
+	var e Error
+	c.decode(c.do(http.MethodPut, "/api/monitors/tcp", monitor("tcp", "switch")), http.StatusInternalServerError, &e)
+	assert.Empty(t, deps.Parents("tcp"))
+	var got Monitor
+	c.decode(c.do(http.MethodGet, "/api/monitors/tcp", ""), http.StatusOK, &got)
+	assert.NotContains(t, string(got.Config), "switch")
+}
+
+func TestServer_PushToken(t *testing.T) {
+	c, _, _ := newServer(t, Config{})
+	monitor := func(name, token string, parents ...string) string {
+		dependsOn, _ := json.Marshal(parents)
+		return fmt.Sprintf(`{"name": %q, "type": "push", "token": %q, "depends_on": %s}`, name, token, dependsOn)
+	}
+	pushes := func(token string) error {
+		return push.DefaultHub.Push(token, push.Ping{Signal: push.SignalSuccess})
+	}
+
+	c.decode(c.do(http.MethodPost, "/api/monitors", monitor("backup", "api-nightly")), http.StatusCreated, &Monitor{})
+	require.NoError(t, pushes("api-nightly"))
+
+	// 被拒绝的请求不影响正在运行的监控项, 也不注册新的 token
+	var e Error
+	c.decode(c.do(http.MethodPost, "/api/monitors", monitor("backup", "api-daily")), http.StatusConflict, &e)
+	c.decode(c.do(http.MethodPut, "/api/monitors/backup", monitor("backup", "api-daily", "missing")), http.StatusBadRequest, &e)
+	c.decode(c.do(http.MethodPost, "/api/monitors", monitor("other", "api-nightly")), http.StatusBadRequest, &e)
+	assert.Equal(t, "token", e.Fields[0].Field)
+	assert.NoError(t, pushes("api-nightly"))
+	assert.ErrorIs(t, pushes("api-daily"), push.ErrUnknownToken)
+
+	c.decode(c.do(http.MethodPut, "/api/monitors/backup", monitor("backup", "api-daily")), http.StatusOK, &Monitor{})
+	assert.ErrorIs(t, pushes("api-nightly"), push.ErrUnknownToken)
+	assert.NoError(t, pushes("api-daily"))
+
+	assert.Equal(t, http.StatusNoContent, c.do(http.MethodDelete, "/api/monitors/backup", "").Code)
+	assert.ErrorIs(t, pushes("api-daily"), push.ErrUnknownToken)
+}
+
+func TestServer_Wrap(t *testing.T) {
+	var wrapped []string
+	c, _, _ := newServer(t, Config{}, SetWrap(func(d status_neko.Definition, m status_neko.Monitor) (status_neko.Monitor, error) {
+		if d.Name == "invalid" {
+			return nil, status_neko.FieldErrors{{Field: "retries", Message: "must not be negative"}}
+		}
+		wrapped = append(wrapped, d.Name)
+		return m, nil
+	}))
+
+	c.decode(c.do(http.MethodPost, "/api/monitors", `{"name": "tcp", "type": "tcp", "host": "h", "port": 1}`), http.StatusCreated, &Monitor{})
+	c.decode(c.do(http.MethodPut, "/api/monitors/tcp", `{"name": "tcp", "type": "tcp", "host": "h", "port": 2}`), http.StatusOK, &Monitor{})
+	assert.Equal(t, []string{"tcp", "tcp"}, wrapped)
+
+	var e Error
+	c.decode(c.do(http.MethodPost, "/api/monitors", `{"name": "invalid", "type": "tcp", "host": "h", "port": 1}`), http.StatusBadRequest, &e)
+	require.Len(t, e.Fields, 1)
+	assert.Equal(t, "retries", e.Fields[0].Field)
+}
+
+func TestServer_CheckAndResults(t *testing.T) {
+	c, scheduler, store := newServer(t, Config{})
+	port := listen(t)
+
+	body := fmt.Sprintf(`{"name": "local", "type": "tcp", "interval": "1h", "paused": true, "host": "127.0.0.1", "port": %d}`, port)
+	c.decode(c.do(http.MethodPost, "/api/monitors", body), http.StatusCreated, &Monitor{})
+
+	// other 的第一次检测说明调度已经在运行
+	other := strings.Replace(body, `"local"`, `"other"`, 1)
+	other = strings.Replace(other, `"paused": true`, `"paused": false`, 1)
+	c.decode(c.do(http.MethodPost, "/api/monitors", other), http.StatusCreated, &Monitor{})
+
+	reports, _ := scheduler.Subscribe(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go scheduler.Run(ctx)
+	running := make(chan struct{})
+	go func() {
+		for r := range reports {
+			_ = store.Append(context.Background(), status_neko.NewRecord(r))
+			if r.Name == "other" {
+				close(running)
+			}
+		}
+	}()
+	<-running
+
+	var check Check
+	c.decode(c.do(http.MethodPost, "/api/monitors/local/check", ""), http.StatusOK, &check)
+	assert.Equal(t, "local", check.Name)
+	assert.Equal(t, status_neko.StatusUp, check.Result.Status)
+	assert.Empty(t, check.Error)
+
+	assert.Equal(t, http.StatusNotFound, c.do(http.MethodPost, "/api/monitors/missing/check", "").Code)
+
+	require.Eventually(t, func() bool {
+		var records []status_neko.Record
+		c.decode(c.do(http.MethodGet, "/api/monitors/local/results?limit=10", ""), http.StatusOK, &records)
+		return len(records) == 1 && records[0].Status == status_neko.StatusUp
+	}, time.Second, 10*time.Millisecond)
+
+	var e Error
+	c.decode(c.do(http.MethodGet, "/api/monitors/local/results?limit=x&from=yesterday", ""), http.StatusBadRequest, &e)
+	require.Len(t, e.Fields, 2)
+	assert.Equal(t, "limit", e.Fields[0].Field)
+	assert.Equal(t, "from", e.Fields[1].Field)
+	assert.Equal(t, http.StatusNotFound, c.do(http.MethodGet, "/api/monitors/missing/results", "").Code)
+}
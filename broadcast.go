@@ -0,0 +1,77 @@
+package status_neko
+
+import (
+	"context"
+	"sync"
+)
+
+type subscriber[T any] struct {
+	ch   chan T
+	done chan struct{}
+	once sync.Once
+}
+
+// broadcaster fans values out to subscribers, its zero value is ready to use.
+type broadcaster[T any] struct {
+	mu   sync.RWMutex
+	subs map[*subscriber[T]]struct{}
+}
+
+func (b *broadcaster[T]) subscribe(buffer int) (<-chan T, func()) {
+	sub := &subscriber[T]{
+		ch:   make(chan T, buffer),
+		done: make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	if b.subs == nil {
+		b.subs = make(map[*subscriber[T]]struct{})
+	}
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub.ch, func() {
+		b.unsubscribe(sub)
+	}
+}
+
+func (b *broadcaster[T]) unsubscribe(sub *subscriber[T]) {
+	sub.once.Do(func() {
+		// done is closed before taking the lock so a publisher blocked on
+		// this subscriber lets go of its read lock.
+		close(sub.done)
+
+		b.mu.Lock()
+		delete(b.subs, sub)
+		close(sub.ch)
+		b.mu.Unlock()
+	})
+}
+
+// publish blocks until every subscriber took v, unsubscribed or ctx is done.
+func (b *broadcaster[T]) publish(ctx context.Context, v T) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subs {
+		select {
+		case sub.ch <- v:
+		case <-sub.done:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (b *broadcaster[T]) closeAll() {
+	b.mu.RLock()
+	subs := make([]*subscriber[T], 0, len(b.subs))
+	for sub := range b.subs {
+		subs = append(subs, sub)
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		b.unsubscribe(sub)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/songzhibin97/status-neko/api"
+	"github.com/songzhibin97/status-neko/probe"
+	"github.com/songzhibin97/status-neko/statuspage"
+)
+
+// fileConfig is the layout of the monitor definitions file.
+type fileConfig struct {
+	// Listen 为 HTTP 服务地址, 如 ":8080", 提供 /metrics, /probe, 状态页和 API; 为空时不启动
+	Listen    string                   `json:"listen"`
+	Workers   int                      `json:"workers"`
+	State     stateConfig              `json:"state"`
+	Monitors  []status_neko.Definition `json:"monitors"`
+	Notifiers []json.RawMessage        `json:"notifiers"`
+	// Store 为空时使用内存存储
+	Store json.RawMessage `json:"store"`
+	// Modules 是 /probe 可用的模块, 与 blackbox_exporter 的 modules 对应
+	Modules map[string]probe.Module `json:"modules"`
+	// StatusPage 配置公开的状态页, 没有页面时不提供
+	StatusPage statuspage.Config `json:"status_page"`
+	// API 配置管理监控项的 REST API, 没有 api_key 时不提供
+	API api.Config `json:"api"`
+	// Maintenance 是计划维护时间, 期间照常检测和记录, 但不发送通知, 也不计入可用性
+	Maintenance []status_neko.MaintenanceWindow `json:"maintenance"`
+}
+
+// stateConfig configures the status_neko.Tracker.
+type stateConfig struct {
+	FailureThreshold  int                  `json:"failure_threshold"`
+	RecoveryThreshold int                  `json:"recovery_threshold"`
+	FlapWindow        status_neko.Duration `json:"flap_window"`
+	FlapThreshold     int                  `json:"flap_threshold"`
+}
+
+// loadConfig reads a YAML or JSON file, chosen by its extension.
+func loadConfig(path string) (*fileConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		b, err = yamlToJSON(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	var c fileConfig
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// 通过 API 修改过的监控项取代配置文件中的
+	if c.API.File != "" {
+		definitions, ok, err := api.LoadDefinitions(c.API.File)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			c.Monitors = definitions
+		}
+	}
+
+	names := make(map[string]struct{}, len(c.Monitors))
+	for i, d := range c.Monitors {
+		if d.Name == "" {
+			return nil, fmt.Errorf("%s: monitors[%d] has no name", path, i)
+		}
+		if _, ok := names[d.Name]; ok {
+			return nil, fmt.Errorf("%s: duplicate monitor name %s", path, d.Name)
+		}
+		names[d.Name] = struct{}{}
+	}
+	if _, err := status_neko.NewDependencies(c.Monitors...); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if _, err := status_neko.NewMaintenance(c.Maintenance...); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// yamlToJSON converts YAML to JSON so that both formats share the json
+// tags of the provider configs.
+func yamlToJSON(b []byte) ([]byte, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
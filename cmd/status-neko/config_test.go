@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/songzhibin97/status-neko/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	yamlPath := writeFile(t, "monitors.yaml", `
+workers: 4
+monitors:
+  - name: baidu
+    type: http
+    interval: 30s
+    timeout: 10s
+    url: http://baidu.com
+    method: GET
+    headers:
+      X-Test: "1"
+  - name: dns
+    type: dns
+    host: example.com
+    resource_type: A
+`)
+	jsonPath := writeFile(t, "monitors.json", `{
+	"workers": 4,
+	"monitors": [
+		{"name": "baidu", "type": "http", "interval": "30s", "timeout": "10s", "url": "http://baidu.com", "method": "GET", "headers": {"X-Test": "1"}},
+		{"name": "dns", "type": "dns", "host": "example.com", "resource_type": "A"}
+	]
+}`)
+
+	for _, path := range []string{yamlPath, jsonPath} {
+		c, monitors, err := loadMonitors(path, nil)
+		require.NoError(t, err, path)
+		assert.Equal(t, 4, c.Workers)
+		require.Len(t, monitors, 2)
+
+		assert.Equal(t, "baidu", monitors[0].definition.Name)
+		assert.Equal(t, 30*time.Second, time.Duration(monitors[0].definition.Interval))
+		assert.Equal(t, "http", monitors[0].monitor.Name())
+		assert.Equal(t, "dns", monitors[1].monitor.Name())
+	}
+}
+
+func TestLoadConfig_APIFile(t *testing.T) {
+	dir := t.TempDir()
+	apiFile := filepath.Join(dir, "api.json")
+	path := writeFile(t, "monitors.yaml", `
+api:
+  api_key: secret
+  file: `+apiFile+`
+monitors:
+  - name: baidu
+    type: http
+    url: http://baidu.com
+`)
+
+	// 文件不存在时使用配置文件中的监控项
+	_, monitors, err := loadMonitors(path, nil)
+	require.NoError(t, err)
+	require.Len(t, monitors, 1)
+	assert.Equal(t, "baidu", monitors[0].definition.Name)
+
+	require.NoError(t, os.WriteFile(apiFile, []byte(`[{"name": "dns", "type": "dns", "host": "example.com", "paused": true}]`), 0o600))
+	_, monitors, err = loadMonitors(path, nil)
+	require.NoError(t, err)
+	require.Len(t, monitors, 1)
+	assert.Equal(t, "dns", monitors[0].definition.Name)
+	assert.True(t, monitors[0].definition.Paused)
+}
+
+func TestLoadConfig_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"no name", "monitors:\n  - type: tcp\n", "monitors[0] has no name"},
+		{"duplicate", "monitors:\n  - {name: a, type: tcp}\n  - {name: a, type: tcp}\n", "duplicate monitor name a"},
+		{"unknown type", "monitors:\n  - {name: a, type: nope}\n", `monitor a: unknown monitor type "nope"`},
+		{"invalid yaml", "monitors: [", "yaml"},
+		{"dependency cycle", "monitors:\n  - {name: a, type: tcp, host: h, port: 1, depends_on: [b]}\n  - {name: b, type: tcp, host: h, port: 1, depends_on: [a]}\n", "dependency cycle: b -> a -> b"},
+		{"unknown dependency", "monitors:\n  - {name: a, type: tcp, host: h, port: 1, depends_on: [b]}\n", "depends on unknown monitor b"},
+		{"invalid maintenance", "maintenance:\n  - {monitors: [a], cron: '0 2 * *', duration: 1h}\n", "maintenance[0].cron"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := loadMonitors(writeFile(t, "monitors.yml", tt.content), nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestCheckCommand(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+
+	up := writeFile(t, "up.yaml", "monitors:\n  - {name: local, type: tcp, host: 127.0.0.1, port: "+port+"}\n")
+	ok, err := checkCommand(context.Background(), []string{"-config", up})
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	listener.Close()
+	down := writeFile(t, "down.yaml", "monitors:\n  - {name: local, type: tcp, host: 127.0.0.1, port: "+port+"}\n")
+	ok, err = checkCommand(context.Background(), []string{"-config", down, "-timeout", "1s"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestRecordReports(t *testing.T) {
+	store, err := status_neko.BuildStore([]byte(`{"type": "memory"}`))
+	require.NoError(t, err)
+
+	start := time.Now()
+	maintenance, err := status_neko.NewMaintenance(status_neko.MaintenanceWindow{
+		Groups: []string{"db"},
+		Start:  start.Add(-time.Minute),
+		End:    start.Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	reports := make(chan status_neko.Report, 3)
+	reports <- status_neko.Report{Name: "a", Result: status_neko.NewResult(status_neko.StatusUp, start, "ok", nil), Start: start}
+	reports <- status_neko.Report{Name: "a", Result: status_neko.Complete(nil, errors.New("boom"), start), Start: start}
+	reports <- status_neko.Report{Name: "b", Tags: map[string]string{"group": "db"}, Result: status_neko.Complete(nil, errors.New("upgrading"), start), Start: start}
+	close(reports)
+
+	recordReports(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), store, maintenance, reports)
+
+	records, err := store.Query(context.Background(), status_neko.Query{Monitor: "a"})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, status_neko.StatusDown, records[1].Status)
+	assert.Equal(t, "boom", records[1].Message)
+	assert.False(t, records[1].Maintenance)
+
+	records, err = store.Query(context.Background(), status_neko.Query{Monitor: "b"})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.True(t, records[0].Maintenance)
+}
+
+func TestReportCommand(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, "monitors.yaml", `
+store:
+  type: file
+  path: `+dir+`
+monitors:
+  - name: api
+    type: tcp
+    host: 127.0.0.1
+    port: 1
+`)
+
+	store, err := status_neko.BuildStore([]byte(`{"type": "file", "path": "` + dir + `"}`))
+	require.NoError(t, err)
+	now := time.Now()
+	require.NoError(t, store.Append(context.Background(),
+		status_neko.Record{Monitor: "api", Status: status_neko.StatusUp, Timestamp: now.Add(-2 * time.Hour)},
+		status_neko.Record{Monitor: "api", Status: status_neko.StatusDown, Timestamp: now.Add(-time.Hour)},
+	))
+	require.NoError(t, store.Close())
+
+	var out bytes.Buffer
+	require.NoError(t, reportCommand(context.Background(), &out, []string{"-config", path, "-window", "1d", "-slo", "99"}))
+
+	var reports []report.Report
+	require.NoError(t, json.Unmarshal(out.Bytes(), &reports))
+	require.Len(t, reports, 1)
+	assert.Equal(t, "api", reports[0].Name)
+	assert.InDelta(t, 50, reports[0].Uptime, 0.1)
+	assert.Len(t, reports[0].Incidents, 1)
+	assert.NotNil(t, reports[0].Budget)
+
+	out.Reset()
+	require.NoError(t, reportCommand(context.Background(), &out, []string{"-config", path, "-group", "all", "-window", "month"}))
+	var group report.Report
+	require.NoError(t, json.Unmarshal(out.Bytes(), &group))
+	assert.Equal(t, "all", group.Name)
+	assert.Len(t, group.Monitors, 1)
+	assert.Nil(t, group.Budget)
+
+	assert.Error(t, reportCommand(context.Background(), &out, []string{"-config", path, "-window", "soon"}))
+}
+
+func TestParseWindow(t *testing.T) {
+	d, err := parseWindow("7d")
+	require.NoError(t, err)
+	assert.Equal(t, 7*24*time.Hour, d)
+
+	d, err = parseWindow("90m")
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, d)
+
+	_, err = parseWindow("xd")
+	assert.Error(t, err)
+}
@@ -0,0 +1,452 @@
+// Command status-neko runs the monitors defined in a YAML or JSON file.
+//
+//	status-neko run -config monitors.yaml    # 按计划持续检测并记录状态变化
+//	status-neko check -config monitors.yaml  # 每个监控项检测一次, 有失败时返回非 0
+//	status-neko report -config monitors.yaml -window month -slo 99.9  # 输出可用性报告
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/songzhibin97/status-neko/api"
+	"github.com/songzhibin97/status-neko/metrics"
+	"github.com/songzhibin97/status-neko/middleware"
+	"github.com/songzhibin97/status-neko/probe"
+	"github.com/songzhibin97/status-neko/report"
+	"github.com/songzhibin97/status-neko/statuspage"
+
+	_ "github.com/songzhibin97/status-neko/notify/dingtalk"
+	_ "github.com/songzhibin97/status-neko/notify/discord"
+	_ "github.com/songzhibin97/status-neko/notify/email"
+	_ "github.com/songzhibin97/status-neko/notify/feishu"
+	_ "github.com/songzhibin97/status-neko/notify/slack"
+	_ "github.com/songzhibin97/status-neko/notify/teams"
+	_ "github.com/songzhibin97/status-neko/notify/telegram"
+	_ "github.com/songzhibin97/status-neko/notify/webhook"
+	_ "github.com/songzhibin97/status-neko/notify/wecom"
+
+	_ "github.com/songzhibin97/status-neko/provide/certificate_expires"
+	_ "github.com/songzhibin97/status-neko/provide/composite"
+	_ "github.com/songzhibin97/status-neko/provide/dns"
+	_ "github.com/songzhibin97/status-neko/provide/grpc"
+	_ "github.com/songzhibin97/status-neko/provide/http"
+	_ "github.com/songzhibin97/status-neko/provide/icmp"
+	_ "github.com/songzhibin97/status-neko/provide/kafka_producer"
+	_ "github.com/songzhibin97/status-neko/provide/mongodb"
+	_ "github.com/songzhibin97/status-neko/provide/mqtt"
+	_ "github.com/songzhibin97/status-neko/provide/mss"
+	_ "github.com/songzhibin97/status-neko/provide/mysql"
+	_ "github.com/songzhibin97/status-neko/provide/pgsql"
+	"github.com/songzhibin97/status-neko/provide/push"
+	_ "github.com/songzhibin97/status-neko/provide/redis"
+	_ "github.com/songzhibin97/status-neko/provide/tcp"
+
+	_ "github.com/songzhibin97/status-neko/store/file"
+	_ "github.com/songzhibin97/status-neko/store/memory"
+)
+
+// compactInterval is how often the retention policy of the store is applied.
+var compactInterval = time.Hour
+
+const usage = `usage: status-neko <command> [flags]
+
+commands:
+  run     run the monitors on schedule and log state changes
+  check   run every monitor once and exit non-zero if any fails
+  report  print the availability of monitors from the stored history
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var err error
+	switch os.Args[1] {
+	case "run":
+		err = runCommand(ctx, logger, os.Args[2:])
+	case "check":
+		var ok bool
+		ok, err = checkCommand(ctx, os.Args[2:])
+		if err == nil && !ok {
+			os.Exit(1)
+		}
+	case "report":
+		err = reportCommand(ctx, os.Stdout, os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		logger.Error("status-neko failed", "err", err)
+		os.Exit(1)
+	}
+}
+
+type monitor struct {
+	definition status_neko.Definition
+	monitor    status_neko.Monitor
+}
+
+// loadMonitors builds the monitors of the configuration file wrapped with
+// their middlewares, the checks are logged to logger unless it is nil.
+func loadMonitors(path string, logger *slog.Logger) (*fileConfig, []monitor, error) {
+	c, err := loadConfig(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	monitors := make([]monitor, 0, len(c.Monitors))
+	for _, d := range c.Monitors {
+		m, err := d.Build()
+		if err == nil {
+			m, err = middleware.Wrap(d, m, logger)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		monitors = append(monitors, monitor{definition: d, monitor: m})
+	}
+	return c, monitors, nil
+}
+
+func runCommand(ctx context.Context, logger *slog.Logger, args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	path := fs.String("config", "monitors.yaml", "monitor definitions file (YAML or JSON)")
+	_ = fs.Parse(args)
+
+	c, monitors, err := loadMonitors(*path, logger)
+	if err != nil {
+		return err
+	}
+
+	scheduler := status_neko.NewScheduler(status_neko.SetWorkers(c.Workers))
+	for _, m := range monitors {
+		if err := scheduler.Add(m.definition.Name, m.monitor, m.definition.Schedule()...); err != nil {
+			return err
+		}
+	}
+
+	maintenance, err := status_neko.NewMaintenance(c.Maintenance...)
+	if err != nil {
+		return err
+	}
+	dependencies, err := status_neko.NewDependencies(c.Monitors...)
+	if err != nil {
+		return err
+	}
+	tracker := status_neko.NewTracker(
+		status_neko.SetMaintenanceWindows(maintenance),
+		status_neko.SetDependencies(dependencies),
+		status_neko.SetFailureThreshold(c.State.FailureThreshold),
+		status_neko.SetRecoveryThreshold(c.State.RecoveryThreshold),
+		status_neko.SetFlapDetection(time.Duration(c.State.FlapWindow), c.State.FlapThreshold),
+	)
+	transitions, _ := tracker.Subscribe(len(monitors))
+	go logTransitions(logger, transitions)
+
+	notifiers := make([]status_neko.Notifier, 0, len(c.Notifiers))
+	for _, raw := range c.Notifiers {
+		n, err := status_neko.BuildNotifier(raw)
+		if err != nil {
+			return err
+		}
+		notifiers = append(notifiers, n)
+	}
+	dispatcher := status_neko.NewDispatcher(notifiers,
+		status_neko.SetErrorHandler(func(n status_neko.Notifier, notification status_neko.Notification, err error) {
+			logger.Error("failed to send notification", "notifier", n.Name(), "monitor", notification.Monitor, "err", err)
+		}),
+	)
+	notifications, _ := tracker.Subscribe(len(monitors))
+	go dispatcher.Run(ctx, notifications)
+
+	reports, _ := scheduler.Subscribe(len(monitors))
+	go tracker.Run(ctx, reports)
+
+	store, err := buildStore(c)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	history, _ := scheduler.Subscribe(len(monitors))
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		recordReports(ctx, logger, store, maintenance, history)
+	}()
+	defer wg.Wait()
+
+	if c.Listen != "" {
+		exporter := metrics.NewExporter()
+		metricReports, _ := scheduler.Subscribe(len(monitors))
+		go exporter.Run(ctx, metricReports)
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", exporter)
+		mux.Handle("/probe", probe.NewHandler(c.Modules))
+		mux.Handle("/api/push/", push.DefaultHub)
+		if len(c.StatusPage.Pages) > 0 {
+			pages, err := statuspage.NewServer(c.StatusPage, store, tracker)
+			if err != nil {
+				return err
+			}
+			mux.Handle("/", pages)
+		}
+		if c.API.APIKey != "" {
+			server, err := api.NewServer(c.API, scheduler, store, tracker, c.Monitors,
+				api.SetDependencies(dependencies),
+				api.SetWrap(func(d status_neko.Definition, m status_neko.Monitor) (status_neko.Monitor, error) {
+					return middleware.Wrap(d, m, logger)
+				}),
+				api.SetOnRemove(func(name string) {
+					tracker.Remove(name)
+					exporter.Remove(name)
+				}),
+			)
+			if err != nil {
+				return err
+			}
+			mux.Handle("/api/monitors", server)
+			mux.Handle("/api/monitors/", server)
+		}
+		if err := serve(ctx, logger, c.Listen, mux); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("status-neko started", "monitors", len(monitors))
+	if err := scheduler.Run(ctx); err != nil && ctx.Err() == nil {
+		return err
+	}
+	logger.Info("status-neko stopped")
+	return nil
+}
+
+// serve starts an HTTP server on addr that is shut down once ctx is done.
+func serve(ctx context.Context, logger *slog.Logger, addr string, handler http.Handler) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			logger.Error("http server failed", "err", err)
+		}
+	}()
+	logger.Info("http server started", "addr", ln.Addr().String())
+	return nil
+}
+
+// buildStore creates the configured store, the memory store by default.
+func buildStore(c *fileConfig) (status_neko.Store, error) {
+	raw := c.Store
+	if len(raw) == 0 {
+		raw = json.RawMessage(`{"type": "memory"}`)
+	}
+	return status_neko.BuildStore(raw)
+}
+
+// recordReports appends every report to the store, marking the checks
+// within maintenance, and applies its retention policy periodically, until
+// the reports channel is closed.
+func recordReports(ctx context.Context, logger *slog.Logger, store status_neko.Store, maintenance *status_neko.Maintenance, reports <-chan status_neko.Report) {
+	ticker := time.NewTicker(compactInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case r, ok := <-reports:
+			if !ok {
+				return
+			}
+			record := status_neko.NewRecord(r)
+			record.Maintenance = maintenance.Active(r.Name, r.Tags, record.Timestamp)
+			// 调度停止后 ctx 已取消, 仍需写入剩余的结果
+			if err := store.Append(context.WithoutCancel(ctx), record); err != nil {
+				logger.Error("failed to store result", "monitor", r.Name, "err", err)
+			}
+		case now := <-ticker.C:
+			if err := store.Compact(ctx, now); err != nil {
+				logger.Error("failed to compact store", "err", err)
+			}
+		}
+	}
+}
+
+func logTransitions(logger *slog.Logger, transitions <-chan status_neko.Transition) {
+	for tr := range transitions {
+		level := slog.LevelInfo
+		if tr.To == status_neko.StateDown {
+			level = slog.LevelWarn
+		}
+
+		attrs := []any{
+			"monitor", tr.Name,
+			"provider", tr.Provider,
+			"from", tr.From,
+			"to", tr.To,
+			"flapping", tr.Flapping,
+		}
+		if tr.Result != nil {
+			attrs = append(attrs, "latency", tr.Result.Latency, "message", tr.Result.Message)
+		}
+		logger.Log(context.Background(), level, "monitor state changed", attrs...)
+	}
+}
+
+func checkCommand(ctx context.Context, args []string) (bool, error) {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	path := fs.String("config", "monitors.yaml", "monitor definitions file (YAML or JSON)")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout of each check unless the monitor sets one")
+	_ = fs.Parse(args)
+
+	_, monitors, err := loadMonitors(*path, nil)
+	if err != nil {
+		return false, err
+	}
+
+	results := make([]*status_neko.Result, len(monitors))
+	var wg sync.WaitGroup
+	for i, m := range monitors {
+		wg.Add(1)
+		go func(i int, m monitor) {
+			defer wg.Done()
+
+			d := *timeout
+			if m.definition.Timeout > 0 {
+				d = time.Duration(m.definition.Timeout)
+			}
+			checkCtx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			start := time.Now()
+			result, err := m.monitor.Check(checkCtx)
+			results[i] = status_neko.Complete(result, err, start)
+		}(i, m)
+	}
+	wg.Wait()
+
+	ok := true
+	for i, m := range monitors {
+		r := results[i]
+		if r.Status == status_neko.StatusDown {
+			ok = false
+		}
+		fmt.Printf("%-8s %-24s %-16s %10s  %s\n", r.Status, m.definition.Name, m.monitor.Name(), r.Latency.Round(time.Millisecond), r.Message)
+	}
+	return ok, nil
+}
+
+func reportCommand(ctx context.Context, out io.Writer, args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	path := fs.String("config", "monitors.yaml", "monitor definitions file (YAML or JSON)")
+	names := fs.String("monitor", "", "comma separated monitors, all monitors of the config by default")
+	group := fs.String("group", "", "report the monitors as one group with this name")
+	window := fs.String("window", "24h", `report window ending now, a duration such as 7d or "month" for the current calendar month`)
+	slo := fs.Float64("slo", 0, "SLO target in percent for the error budget, e.g. 99.9")
+	_ = fs.Parse(args)
+
+	c, err := loadConfig(*path)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var w report.Window
+	if *window == "month" {
+		w = report.Month(now)
+	} else {
+		d, err := parseWindow(*window)
+		if err != nil {
+			return err
+		}
+		w = report.Last(now, d)
+	}
+
+	var monitors []string
+	if *names != "" {
+		monitors = strings.Split(*names, ",")
+	} else {
+		for _, d := range c.Monitors {
+			monitors = append(monitors, d.Name)
+		}
+	}
+
+	store, err := buildStore(c)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	reporter := report.NewReporter(store, report.SetSLO(*slo))
+
+	var result interface{}
+	if *group != "" {
+		result, err = reporter.Group(ctx, *group, monitors, w)
+	} else {
+		reports := make([]*report.Report, 0, len(monitors))
+		for _, m := range monitors {
+			r, err := reporter.Monitor(ctx, m, w)
+			if err != nil {
+				return err
+			}
+			reports = append(reports, r)
+		}
+		result = reports
+	}
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+// parseWindow parses a duration that may use a "d" suffix for days.
+func parseWindow(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid window %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid window %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,68 @@
+package status_neko
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron(t *testing.T) {
+	base := time.Date(2024, 10, 1, 10, 30, 0, 0, time.UTC) // 周二
+
+	tests := []struct {
+		expr string
+		want []time.Time
+	}{
+		{"*/15 * * * *", []time.Time{
+			time.Date(2024, 10, 1, 10, 45, 0, 0, time.UTC),
+			time.Date(2024, 10, 1, 11, 0, 0, 0, time.UTC),
+		}},
+		{"0 2 * * sat,SUN", []time.Time{
+			time.Date(2024, 10, 5, 2, 0, 0, 0, time.UTC),
+			time.Date(2024, 10, 6, 2, 0, 0, 0, time.UTC),
+			time.Date(2024, 10, 12, 2, 0, 0, 0, time.UTC),
+		}},
+		{"30 9-17/4 * * mon-fri", []time.Time{
+			time.Date(2024, 10, 1, 13, 30, 0, 0, time.UTC),
+			time.Date(2024, 10, 1, 17, 30, 0, 0, time.UTC),
+			time.Date(2024, 10, 2, 9, 30, 0, 0, time.UTC),
+		}},
+		// 日和星期都指定时满足其一即可
+		{"0 0 15 * 7", []time.Time{
+			time.Date(2024, 10, 6, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 10, 13, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 10, 15, 0, 0, 0, 0, time.UTC),
+		}},
+		{"0 0 29 feb *", []time.Time{
+			time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		}},
+		{"@monthly", []time.Time{
+			time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := parseCron(tt.expr)
+			require.NoError(t, err)
+			next := base
+			for _, want := range tt.want {
+				next = s.next(next)
+				assert.Equal(t, want, next)
+			}
+		})
+	}
+
+	s, err := parseCron("0 0 30 feb *")
+	require.NoError(t, err)
+	assert.True(t, s.next(base).IsZero())
+}
+
+func TestParseCron_Error(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "* * * * funday"} {
+		_, err := parseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
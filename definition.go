@@ -0,0 +1,118 @@
+package status_neko
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Definition describes a monitor in a configuration file: the provider
+// config understood by Build plus the name and schedule of the monitor.
+//
+//	{"name": "baidu", "type": "http", "interval": "30s", "url": "http://baidu.com"}
+type Definition struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Interval Duration `json:"interval"`
+	Timeout  Duration `json:"timeout"`
+	Jitter   Duration `json:"jitter"`
+	// Tags are user defined labels, e.g. exported as Prometheus labels.
+	Tags map[string]string `json:"tags"`
+	// Paused monitors are added to the scheduler without being checked.
+	Paused bool `json:"paused"`
+	// DependsOn are the names of the monitors this one depends on, see Dependencies.
+	DependsOn []string `json:"depends_on"`
+
+	// Config is the raw JSON object the definition was decoded from.
+	Config json.RawMessage `json:"-"`
+}
+
+func (d *Definition) UnmarshalJSON(b []byte) error {
+	type definition Definition
+	var v definition
+	if err := json.Unmarshal(b, &v); err != nil {
+		return durationField(b, err)
+	}
+	*d = Definition(v)
+	d.Config = append(json.RawMessage(nil), b...)
+	return nil
+}
+
+// Validate checks the fields of the definition itself, the provider config
+// is checked by Build.
+func (d Definition) Validate() error {
+	var fields FieldErrors
+	if d.Name == "" {
+		fields = append(fields, &FieldError{Field: "name", Message: "is required"})
+	}
+	if d.Type == "" {
+		fields = append(fields, &FieldError{Field: "type", Message: "is required"})
+	} else if !providers.has(d.Type) {
+		fields = append(fields, &FieldError{Field: "type", Message: fmt.Sprintf("unknown monitor type %q", d.Type)})
+	}
+	for _, f := range []struct {
+		name  string
+		value Duration
+	}{{"interval", d.Interval}, {"timeout", d.Timeout}, {"jitter", d.Jitter}} {
+		if f.value < 0 {
+			fields = append(fields, &FieldError{Field: f.name, Message: "must not be negative"})
+		}
+	}
+	if len(fields) > 0 {
+		return fields
+	}
+	return nil
+}
+
+// Build creates the Monitor of the definition, see Build.
+func (d Definition) Build() (Monitor, error) {
+	if d.Name == "" {
+		return nil, fmt.Errorf("monitor has no name")
+	}
+	m, err := Build(d.Config)
+	if err != nil {
+		return nil, fmt.Errorf("monitor %s: %w", d.Name, err)
+	}
+	return m, nil
+}
+
+// Schedule returns the scheduler options of the definition.
+func (d Definition) Schedule() []Option[*schedule] {
+	var opts []Option[*schedule]
+	if d.Interval > 0 {
+		opts = append(opts, SetInterval(time.Duration(d.Interval)))
+	}
+	if d.Timeout > 0 {
+		opts = append(opts, SetTimeout(time.Duration(d.Timeout)))
+	}
+	if d.Jitter > 0 {
+		opts = append(opts, SetJitter(time.Duration(d.Jitter)))
+	}
+	if len(d.Tags) > 0 {
+		opts = append(opts, SetTags(d.Tags))
+	}
+	if d.Paused {
+		opts = append(opts, SetPaused(true))
+	}
+	return opts
+}
+
+// durationField turns the error of a Duration field, which encoding/json
+// reports without the field name, into a *FieldError.
+func durationField(b []byte, err error) error {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(b, &fields) != nil {
+		return err
+	}
+	for _, name := range []string{"interval", "timeout", "jitter"} {
+		raw, ok := fields[name]
+		if !ok {
+			continue
+		}
+		var d Duration
+		if derr := d.UnmarshalJSON(raw); derr != nil {
+			return &FieldError{Field: name, Message: derr.Error()}
+		}
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package status_neko
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefinition(t *testing.T) {
+	var d Definition
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"name": "hello",
+		"type": "echo",
+		"interval": "10ms",
+		"timeout": "5ms",
+		"tags": {"env": "prod"},
+		"message": "hello"
+	}`), &d))
+
+	assert.Equal(t, "hello", d.Name)
+	assert.Equal(t, "echo", d.Type)
+	assert.Equal(t, Duration(10*time.Millisecond), d.Interval)
+	assert.Equal(t, map[string]string{"env": "prod"}, d.Tags)
+	assert.Len(t, d.Schedule(), 3)
+
+	m, err := d.Build()
+	require.NoError(t, err)
+	result, err := m.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "hello", result.Message)
+
+	s := NewScheduler()
+	require.NoError(t, s.Add(d.Name, m, d.Schedule()...))
+	assert.Equal(t, []string{"hello"}, s.Names())
+
+	reports, _ := s.Subscribe(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+	r := <-reports
+	assert.Equal(t, map[string]string{"env": "prod"}, r.Tags)
+
+	_, err = Definition{Config: d.Config}.Build()
+	assert.EqualError(t, err, "monitor has no name")
+
+	d.Config = json.RawMessage(`{"type": "nope"}`)
+	_, err = d.Build()
+	assert.EqualError(t, err, `monitor hello: unknown monitor type "nope"`)
+}
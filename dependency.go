@@ -0,0 +1,102 @@
+package status_neko
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrDependencyCycle is returned when monitors would depend on themselves.
+var ErrDependencyCycle = errors.New("dependency cycle")
+
+// Dependencies is the graph of monitors depending on other monitors, e.g.
+// the mysql check of a host depends on its tcp check. While a parent is
+// DOWN or UNREACHABLE the failures of its children are UNREACHABLE instead
+// of DOWN, see SetDependencies.
+type Dependencies struct {
+	mu      sync.RWMutex
+	parents map[string][]string
+}
+
+// NewDependencies builds the graph of the DependsOn of the definitions,
+// rejecting unknown monitors and cycles.
+func NewDependencies(definitions ...Definition) (*Dependencies, error) {
+	names := make(map[string]bool, len(definitions))
+	for _, d := range definitions {
+		names[d.Name] = true
+	}
+
+	deps := &Dependencies{parents: make(map[string][]string)}
+	for _, d := range definitions {
+		for _, parent := range d.DependsOn {
+			if !names[parent] {
+				return nil, fmt.Errorf("monitor %s depends on unknown monitor %s", d.Name, parent)
+			}
+		}
+		if err := deps.Set(d.Name, d.DependsOn); err != nil {
+			return nil, err
+		}
+	}
+	return deps, nil
+}
+
+// Set replaces the parents of a monitor, it fails with ErrDependencyCycle
+// when a parent already depends on the monitor.
+func (d *Dependencies) Set(name string, parents []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, parent := range parents {
+		if path := d.path(parent, name, nil); path != nil {
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(append([]string{name}, path...), " -> "))
+		}
+	}
+	if len(parents) == 0 {
+		delete(d.parents, name)
+		return nil
+	}
+	d.parents[name] = append([]string(nil), parents...)
+	return nil
+}
+
+// Remove forgets the parents of a monitor.
+func (d *Dependencies) Remove(name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.parents, name)
+}
+
+// Parents returns the monitors the named monitor directly depends on.
+func (d *Dependencies) Parents(name string) []string {
+	if d == nil {
+		return nil
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return append([]string(nil), d.parents[name]...)
+}
+
+// path returns the monitors from "from" to "to" following the parents,
+// nil when to is not reachable. The caller must hold d.mu.
+func (d *Dependencies) path(from, to string, seen map[string]bool) []string {
+	if from == to {
+		return []string{to}
+	}
+	if seen == nil {
+		seen = make(map[string]bool)
+	}
+	if seen[from] {
+		return nil
+	}
+	seen[from] = true
+
+	for _, parent := range d.parents[from] {
+		if path := d.path(parent, to, seen); path != nil {
+			return append([]string{from}, path...)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package status_neko
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDependencies(t *testing.T) {
+	deps, err := NewDependencies(
+		Definition{Name: "switch"},
+		Definition{Name: "tcp", DependsOn: []string{"switch"}},
+		Definition{Name: "mysql", DependsOn: []string{"tcp"}},
+		Definition{Name: "http", DependsOn: []string{"tcp", "switch"}},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tcp", "switch"}, deps.Parents("http"))
+	assert.Empty(t, deps.Parents("switch"))
+	assert.Empty(t, (*Dependencies)(nil).Parents("http"))
+
+	err = deps.Set("switch", []string{"mysql"})
+	assert.ErrorIs(t, err, ErrDependencyCycle)
+	assert.EqualError(t, err, "dependency cycle: switch -> mysql -> tcp -> switch")
+	assert.Empty(t, deps.Parents("switch"))
+
+	assert.EqualError(t, deps.Set("tcp", []string{"tcp"}), "dependency cycle: tcp -> tcp")
+
+	deps.Remove("mysql")
+	require.NoError(t, deps.Set("switch", []string{"mysql"}))
+	assert.Equal(t, []string{"mysql"}, deps.Parents("switch"))
+
+	_, err = NewDependencies(Definition{Name: "a", DependsOn: []string{"b"}}, Definition{Name: "b", DependsOn: []string{"a"}})
+	assert.ErrorIs(t, err, ErrDependencyCycle)
+
+	_, err = NewDependencies(Definition{Name: "a", DependsOn: []string{"nope"}})
+	assert.EqualError(t, err, "monitor a depends on unknown monitor nope")
+}
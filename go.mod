@@ -9,6 +9,8 @@ require (
 	github.com/denisenkom/go-mssqldb v0.12.3
 	github.com/eclipse/paho.mqtt.golang v1.5.0
 	github.com/go-ping/ping v1.1.0
+	github.com/go-redis/redis/v8 v8.11.5
+	github.com/go-redis/redismock/v8 v8.11.5
 	github.com/go-resty/resty/v2 v2.15.2
 	github.com/go-sql-driver/mysql v1.8.1
 	github.com/jhump/protoreflect v1.17.0
@@ -16,8 +18,11 @@ require (
 	github.com/miekg/dns v1.1.62
 	github.com/stretchr/testify v1.9.0
 	go.mongodb.org/mongo-driver v1.17.0
+	golang.org/x/crypto v0.27.0
+	golang.org/x/net v0.28.0
 	golang.org/x/oauth2 v0.23.0
 	google.golang.org/grpc v1.67.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -29,8 +34,6 @@ require (
 	github.com/eapache/go-resiliency v1.7.0 // indirect
 	github.com/eapache/go-xerial-snappy v0.0.0-20230731223053-c322873962e3 // indirect
 	github.com/eapache/queue v1.1.0 // indirect
-	github.com/go-redis/redis/v8 v8.11.5 // indirect
-	github.com/go-redis/redismock/v8 v8.11.5 // indirect
 	github.com/golang-sql/civil v0.0.0-20190719163853-cb61b32ac6fe // indirect
 	github.com/golang-sql/sqlexp v0.1.0 // indirect
 	github.com/golang/protobuf v1.5.4 // indirect
@@ -57,14 +60,11 @@ require (
 	github.com/xdg-go/scram v1.1.2 // indirect
 	github.com/xdg-go/stringprep v1.0.4 // indirect
 	github.com/youmark/pkcs8 v0.0.0-20240726163527-a2c0da244d78 // indirect
-	golang.org/x/crypto v0.27.0 // indirect
 	golang.org/x/mod v0.18.0 // indirect
-	golang.org/x/net v0.28.0 // indirect
 	golang.org/x/sync v0.8.0 // indirect
 	golang.org/x/sys v0.25.0 // indirect
 	golang.org/x/text v0.18.0 // indirect
 	golang.org/x/tools v0.22.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240814211410-ddb44dafa142 // indirect
 	google.golang.org/protobuf v1.34.2 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
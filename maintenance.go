@@ -0,0 +1,268 @@
+package status_neko
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaintenanceWindow is planned maintenance of some monitors. It is either
+// one-off (Start and End), recurring on a cron expression (Cron and
+// Duration) or weekly (Weekdays, From and To):
+//
+//	{"name": "db upgrade", "monitors": ["mysql"], "start": "2024-10-01T02:00:00+08:00", "end": "2024-10-01T04:00:00+08:00"}
+//	{"name": "backup", "tags": {"team": "dba"}, "cron": "0 3 * * *", "duration": "30m", "timezone": "Asia/Shanghai"}
+//	{"name": "release", "groups": ["shop"], "weekdays": ["tue", "thu"], "from": "22:00", "to": "01:00"}
+type MaintenanceWindow struct {
+	Name string `json:"name"`
+
+	// 匹配任一条件的监控项处于维护中: 名称在 Monitors 中, 包含全部 Tags,
+	// 或 group 标签在 Groups 中
+	Monitors []string          `json:"monitors"`
+	Tags     map[string]string `json:"tags"`
+	Groups   []string          `json:"groups"`
+
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+
+	Cron     string   `json:"cron"`
+	Duration Duration `json:"duration"`
+
+	// Weekdays 为 mon, tue ... sun, 为空时每天; From 和 To 为 "15:04",
+	// To 不晚于 From 时到第二天结束
+	Weekdays []string `json:"weekdays"`
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+
+	// Timezone 是 Cron 和 weekly 使用的 IANA 时区, 默认本地时区
+	Timezone string `json:"timezone"`
+}
+
+// GroupTag is the monitor tag matched by MaintenanceWindow.Groups.
+const GroupTag = "group"
+
+// Period is the time range [Start, End).
+type Period struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// window is a validated MaintenanceWindow.
+type window struct {
+	MaintenanceWindow
+	location *time.Location
+	cron     *cronSchedule
+	weekdays map[time.Weekday]bool
+	// from and to are offsets into the day for weekly windows.
+	from, to time.Duration
+}
+
+// Maintenance tells which monitors are under planned maintenance. A nil
+// *Maintenance has no windows.
+type Maintenance struct {
+	windows []*window
+}
+
+// NewMaintenance validates the windows, the errors point to the invalid
+// fields, e.g. "maintenance[0].cron".
+func NewMaintenance(windows ...MaintenanceWindow) (*Maintenance, error) {
+	m := &Maintenance{}
+	var fields FieldErrors
+	for i, mw := range windows {
+		w, errs := compile(mw)
+		for _, err := range errs {
+			fields = append(fields, &FieldError{
+				Field:   fmt.Sprintf("maintenance[%d].%s", i, err.Field),
+				Message: err.Message,
+			})
+		}
+		m.windows = append(m.windows, w)
+	}
+	if len(fields) > 0 {
+		return nil, fields
+	}
+	return m, nil
+}
+
+func compile(mw MaintenanceWindow) (*window, FieldErrors) {
+	w := &window{MaintenanceWindow: mw, location: time.Local}
+	var fields FieldErrors
+	invalid := func(field, format string, args ...interface{}) {
+		fields = append(fields, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(mw.Monitors) == 0 && len(mw.Tags) == 0 && len(mw.Groups) == 0 {
+		invalid("monitors", "one of monitors, tags or groups is required")
+	}
+	if mw.Timezone != "" {
+		location, err := time.LoadLocation(mw.Timezone)
+		if err != nil {
+			invalid("timezone", "unknown time zone %q", mw.Timezone)
+		} else {
+			w.location = location
+		}
+	}
+
+	oneOff := !mw.Start.IsZero() || !mw.End.IsZero()
+	recurring := mw.Cron != ""
+	weekly := len(mw.Weekdays) > 0 || mw.From != "" || mw.To != ""
+	switch {
+	case oneOff && !recurring && !weekly:
+		if !mw.End.After(mw.Start) {
+			invalid("end", "must be after start")
+		}
+	case recurring && !oneOff && !weekly:
+		cron, err := parseCron(mw.Cron)
+		if err != nil {
+			invalid("cron", "%v", err)
+		}
+		w.cron = cron
+		if mw.Duration <= 0 {
+			invalid("duration", "must be positive")
+		}
+	case weekly && !oneOff && !recurring:
+		if len(mw.Weekdays) > 0 {
+			w.weekdays = make(map[time.Weekday]bool, len(mw.Weekdays))
+		}
+		for _, day := range mw.Weekdays {
+			d, ok := weekdays[strings.ToLower(day)[:min(3, len(day))]]
+			if !ok {
+				invalid("weekdays", "unknown weekday %q", day)
+				continue
+			}
+			w.weekdays[time.Weekday(d)] = true
+		}
+		var err error
+		if w.from, err = clock(mw.From); err != nil {
+			invalid("from", "%v", err)
+		}
+		if w.to, err = clock(mw.To); err != nil {
+			invalid("to", "%v", err)
+		}
+		if w.to <= w.from {
+			w.to += 24 * time.Hour
+		}
+	default:
+		invalid("cron", "exactly one of start/end, cron or weekdays/from/to is required")
+	}
+	return w, fields
+}
+
+// clock parses "15:04" into an offset into the day.
+func clock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a time like 15:04", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Active reports whether the monitor is under maintenance at t.
+func (m *Maintenance) Active(name string, tags map[string]string, t time.Time) bool {
+	return len(m.Periods(name, tags, t, t.Add(time.Nanosecond))) > 0
+}
+
+// Periods returns the merged maintenance periods of the monitor that
+// overlap [from, to), sorted by start.
+func (m *Maintenance) Periods(name string, tags map[string]string, from, to time.Time) []Period {
+	if m == nil {
+		return nil
+	}
+
+	var periods []Period
+	for _, w := range m.windows {
+		if w.matches(name, tags) {
+			periods = append(periods, w.periods(from, to)...)
+		}
+	}
+	return merge(periods)
+}
+
+func (w *window) matches(name string, tags map[string]string) bool {
+	for _, monitor := range w.Monitors {
+		if monitor == name {
+			return true
+		}
+	}
+	for _, group := range w.Groups {
+		if group != "" && tags[GroupTag] == group {
+			return true
+		}
+	}
+	if len(w.Tags) == 0 {
+		return false
+	}
+	for k, v := range w.Tags {
+		if tags[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// periods returns the occurrences of w overlapping [from, to).
+func (w *window) periods(from, to time.Time) []Period {
+	var periods []Period
+	add := func(p Period) {
+		if p.Start.Before(to) && p.End.After(from) {
+			periods = append(periods, p)
+		}
+	}
+
+	switch {
+	case w.cron != nil:
+		d := time.Duration(w.Duration)
+		// 开始于 from 之前的一次维护可能仍在进行
+		t := from.Add(-d).In(w.location).Add(-time.Nanosecond)
+		for {
+			t = w.cron.next(t)
+			if t.IsZero() || !t.Before(to) {
+				break
+			}
+			add(Period{Start: t, End: t.Add(d)})
+		}
+	case w.to > 0:
+		// 从前一天开始, 跨越午夜的维护可能仍在进行
+		day := from.In(w.location)
+		day = time.Date(day.Year(), day.Month(), day.Day()-1, 0, 0, 0, 0, w.location)
+		for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+			if w.weekdays != nil && !w.weekdays[day.Weekday()] {
+				continue
+			}
+			add(Period{Start: at(day, w.from), End: at(day, w.to)})
+		}
+	default:
+		add(Period{Start: w.Start, End: w.End})
+	}
+	return periods
+}
+
+// at returns the wall clock time offset d into day, the offset may exceed a day.
+func at(day time.Time, d time.Duration) time.Time {
+	days := int(d / (24 * time.Hour))
+	d -= time.Duration(days) * 24 * time.Hour
+	return time.Date(day.Year(), day.Month(), day.Day()+days, int(d/time.Hour), int(d%time.Hour/time.Minute), 0, 0, day.Location())
+}
+
+// merge sorts periods and joins the overlapping ones.
+func merge(periods []Period) []Period {
+	if len(periods) < 2 {
+		return periods
+	}
+	sort.Slice(periods, func(i, j int) bool {
+		return periods[i].Start.Before(periods[j].Start)
+	})
+	merged := periods[:1]
+	for _, p := range periods[1:] {
+		last := &merged[len(merged)-1]
+		if !p.Start.After(last.End) {
+			if p.End.After(last.End) {
+				last.End = p.End
+			}
+			continue
+		}
+		merged = append(merged, p)
+	}
+	return merged
+}
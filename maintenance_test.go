@@ -0,0 +1,111 @@
+package status_neko
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMaintenance_Error(t *testing.T) {
+	var windows []MaintenanceWindow
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"monitors": ["a"], "start": "2024-10-01T02:00:00Z", "end": "2024-10-01T01:00:00Z"},
+		{"monitors": ["a"], "cron": "0 2 * *"},
+		{"tags": {"team": "dba"}, "weekdays": ["someday"], "from": "25:00", "to": "01:00", "timezone": "Mars/Olympus"},
+		{"start": "2024-10-01T02:00:00Z", "end": "2024-10-01T03:00:00Z"},
+		{"monitors": ["a"]},
+		{"monitors": ["a"], "cron": "@daily", "duration": "1h", "from": "02:00", "to": "03:00"}
+	]`), &windows))
+
+	_, err := NewMaintenance(windows...)
+	var fields []string
+	for _, f := range Fields(err) {
+		fields = append(fields, f.Field)
+	}
+	assert.Equal(t, []string{
+		"maintenance[0].end",
+		"maintenance[1].cron",
+		"maintenance[1].duration",
+		"maintenance[2].timezone",
+		"maintenance[2].weekdays",
+		"maintenance[2].from",
+		"maintenance[3].monitors",
+		"maintenance[4].cron",
+		"maintenance[5].cron",
+	}, fields)
+
+	m, err := NewMaintenance()
+	require.NoError(t, err)
+	assert.False(t, m.Active("a", nil, time.Now()))
+	assert.False(t, (*Maintenance)(nil).Active("a", nil, time.Now()))
+}
+
+func TestMaintenance(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+
+	var windows []MaintenanceWindow
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"name": "upgrade", "monitors": ["mysql"], "start": "2024-10-01T02:00:00+08:00", "end": "2024-10-01T04:00:00+08:00"},
+		{"name": "backup", "tags": {"team": "dba", "env": "prod"}, "cron": "0 3 * * *", "duration": "30m", "timezone": "Asia/Shanghai"},
+		{"name": "release", "groups": ["shop"], "weekdays": ["tue", "Thursday"], "from": "22:00", "to": "01:00", "timezone": "Asia/Shanghai"}
+	]`), &windows))
+	m, err := NewMaintenance(windows...)
+	require.NoError(t, err)
+
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 10, day, hour, minute, 0, 0, shanghai)
+	}
+	dba := map[string]string{"team": "dba", "env": "prod"}
+	shop := map[string]string{GroupTag: "shop"}
+
+	tests := []struct {
+		name   string
+		tags   map[string]string
+		t      time.Time
+		active bool
+	}{
+		{"mysql", nil, at(1, 2, 0), true},
+		{"mysql", nil, at(1, 3, 59), true},
+		{"mysql", nil, at(1, 4, 0), false},
+		{"redis", nil, at(1, 2, 30), false},
+		{"mysql", dba, at(2, 3, 15), true},
+		{"mysql", dba, at(2, 3, 30), false},
+		{"mysql", map[string]string{"team": "dba"}, at(2, 3, 15), false},
+		// 2024-10-01 是周二, 22:00 到次日 01:00
+		{"web", shop, at(1, 21, 59), false},
+		{"web", shop, at(1, 22, 0), true},
+		{"web", shop, at(2, 0, 30), true},
+		{"web", shop, at(2, 1, 0), false},
+		{"web", shop, at(2, 22, 30), false},
+		{"web", shop, at(3, 23, 0), true},
+		{"web", map[string]string{GroupTag: "other"}, at(1, 22, 0), false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.active, m.Active(tt.name, tt.tags, tt.t), "%s %v", tt.name, tt.t)
+	}
+
+	// 同时匹配多个窗口时合并重叠的时间段
+	tags := map[string]string{"team": "dba", "env": "prod", GroupTag: "shop"}
+	periods := m.Periods("mysql", tags, at(1, 0, 0), at(3, 0, 0))
+	assert.Equal(t, normalize([]Period{
+		{Start: at(1, 2, 0), End: at(1, 4, 0)},
+		{Start: at(1, 22, 0), End: at(2, 1, 0)},
+		{Start: at(2, 3, 0), End: at(2, 3, 30)},
+	}), normalize(periods))
+
+	// 开始于查询范围之前的维护
+	assert.Equal(t, normalize([]Period{{Start: at(1, 22, 0), End: at(2, 1, 0)}}),
+		normalize(m.Periods("web", shop, at(2, 0, 0), at(2, 2, 0))))
+}
+
+func normalize(periods []Period) []Period {
+	for i := range periods {
+		periods[i].Start = periods[i].Start.UTC()
+		periods[i].End = periods[i].End.UTC()
+	}
+	return periods
+}
@@ -0,0 +1,319 @@
+// Package metrics exports check results in the Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+var (
+	_ http.Handler = (*Exporter)(nil)
+
+	// DefaultBuckets are the latency histogram buckets in seconds.
+	DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+	contentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+type option struct {
+	namespace string
+	buckets   []float64
+}
+
+// SetNamespace sets the prefix of the metric names, "status_neko" by default.
+func SetNamespace(namespace string) status_neko.Option[*option] {
+	return func(o *option) {
+		o.namespace = namespace
+	}
+}
+
+// SetBuckets sets the upper bounds in seconds of the latency histogram.
+func SetBuckets(buckets []float64) status_neko.Option[*option] {
+	return func(o *option) {
+		o.buckets = buckets
+	}
+}
+
+// series is what the exporter keeps for one monitor.
+type series struct {
+	provider string
+	tags     map[string]string
+
+	up                  float64
+	consecutiveFailures int
+	checks              uint64
+	failures            uint64
+	lastCheck           time.Time
+
+	// buckets 是每个上界的累计次数
+	buckets      []uint64
+	latencySum   float64
+	measurements map[string]float64
+}
+
+// Exporter keeps the latest state of every monitor and serves it as
+// Prometheus metrics:
+//
+//	status_neko_up                               1 unless the last check was down
+//	status_neko_check_duration_seconds           latency histogram
+//	status_neko_consecutive_failures             failed checks in a row
+//	status_neko_checks_total                     checks run
+//	status_neko_check_failures_total             checks failed
+//	status_neko_last_check_timestamp_seconds     time of the last check
+//	status_neko_<provider>_<measurement>         values of status_neko.Measurer details
+//
+// Every series is labeled with monitor, provider and the monitor tags.
+type Exporter struct {
+	option *option
+
+	mu       sync.Mutex
+	monitors map[string]*series
+}
+
+func NewExporter(opts ...status_neko.Option[*option]) *Exporter {
+	o := &option{
+		namespace: "status_neko",
+		buckets:   DefaultBuckets,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	buckets := append([]float64(nil), o.buckets...)
+	sort.Float64s(buckets)
+	o.buckets = buckets
+
+	return &Exporter{
+		option:   o,
+		monitors: make(map[string]*series),
+	}
+}
+
+// Run observes reports until the channel is closed or ctx is done.
+func (e *Exporter) Run(ctx context.Context, reports <-chan status_neko.Report) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case r, ok := <-reports:
+			if !ok {
+				return
+			}
+			e.Observe(r)
+		}
+	}
+}
+
+// Observe updates the metrics of the monitor of r.
+func (e *Exporter) Observe(r status_neko.Report) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s, ok := e.monitors[r.Name]
+	if !ok {
+		s = &series{buckets: make([]uint64, len(e.option.buckets))}
+		e.monitors[r.Name] = s
+	}
+	if r.Monitor != nil {
+		s.provider = r.Monitor.Name()
+	}
+	s.tags = r.Tags
+
+	s.checks++
+	s.lastCheck = r.Start
+	if r.Result.Status == status_neko.StatusDown {
+		s.up = 0
+		s.failures++
+		s.consecutiveFailures++
+	} else {
+		s.up = 1
+		s.consecutiveFailures = 0
+	}
+
+	latency := r.Result.Latency.Seconds()
+	s.latencySum += latency
+	for i, bound := range e.option.buckets {
+		if latency <= bound {
+			s.buckets[i]++
+		}
+	}
+
+	// 检测失败时通常没有详情, 保留上一次的值
+	if m, ok := r.Result.Details.(status_neko.Measurer); ok {
+		s.measurements = m.Measurements()
+	}
+}
+
+// Remove drops the metrics of a monitor, e.g. after it was deleted.
+func (e *Exporter) Remove(name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.monitors, name)
+}
+
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", contentType)
+	bw := bufio.NewWriter(w)
+	e.write(bw)
+	_ = bw.Flush()
+}
+
+// family is a metric and its samples in exposition order.
+type family struct {
+	name    string
+	help    string
+	kind    string
+	samples []string
+}
+
+func (f *family) add(suffix, labels string, value float64) {
+	f.samples = append(f.samples, f.name+suffix+labels+" "+FormatValue(value))
+}
+
+func (e *Exporter) write(w *bufio.Writer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ns := e.option.namespace
+	up := &family{name: ns + "_up", kind: "gauge", help: "Whether the last check of the monitor was not down."}
+	duration := &family{name: ns + "_check_duration_seconds", kind: "histogram", help: "Latency of the checks in seconds."}
+	consecutive := &family{name: ns + "_consecutive_failures", kind: "gauge", help: "Number of failed checks in a row."}
+	checks := &family{name: ns + "_checks_total", kind: "counter", help: "Number of checks run."}
+	failures := &family{name: ns + "_check_failures_total", kind: "counter", help: "Number of checks that failed."}
+	lastCheck := &family{name: ns + "_last_check_timestamp_seconds", kind: "gauge", help: "Unix time of the last check."}
+	measurements := make(map[string]*family)
+
+	names := make([]string, 0, len(e.monitors))
+	for name := range e.monitors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		s := e.monitors[name]
+		pairs := labelPairs(name, s.provider, s.tags)
+		labels := formatLabels(pairs)
+
+		up.add("", labels, s.up)
+		consecutive.add("", labels, float64(s.consecutiveFailures))
+		checks.add("", labels, float64(s.checks))
+		failures.add("", labels, float64(s.failures))
+		lastCheck.add("", labels, float64(s.lastCheck.UnixNano())/1e9)
+
+		for i, bound := range e.option.buckets {
+			duration.add("_bucket", formatLabels(append(pairs, [2]string{"le", FormatValue(bound)})), float64(s.buckets[i]))
+		}
+		duration.add("_bucket", formatLabels(append(pairs, [2]string{"le", "+Inf"})), float64(s.checks))
+		duration.add("_sum", labels, s.latencySum)
+		duration.add("_count", labels, float64(s.checks))
+
+		for key, value := range s.measurements {
+			metric := Sanitize(ns + "_" + s.provider + "_" + key)
+			f, ok := measurements[metric]
+			if !ok {
+				f = &family{name: metric, kind: "gauge", help: fmt.Sprintf("%s reported by the %s provider.", key, s.provider)}
+				measurements[metric] = f
+			}
+			f.add("", labels, value)
+		}
+	}
+
+	families := []*family{up, duration, consecutive, checks, failures, lastCheck}
+	extra := make([]string, 0, len(measurements))
+	for metric := range measurements {
+		extra = append(extra, metric)
+	}
+	sort.Strings(extra)
+	for _, metric := range extra {
+		f := measurements[metric]
+		sort.Strings(f.samples)
+		families = append(families, f)
+	}
+
+	for _, f := range families {
+		if len(f.samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+		for _, sample := range f.samples {
+			w.WriteString(sample)
+			w.WriteByte('\n')
+		}
+	}
+}
+
+// labelPairs returns the labels of a monitor, tags are sorted by name and
+// may not override monitor or provider.
+func labelPairs(monitor, provider string, tags map[string]string) [][2]string {
+	pairs := [][2]string{{"monitor", monitor}, {"provider", provider}}
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		name := Sanitize(key)
+		if name == "monitor" || name == "provider" || name == "le" || strings.HasPrefix(name, "__") {
+			name = "tag_" + strings.TrimLeft(name, "_")
+		}
+		pairs = append(pairs, [2]string{name, tags[key]})
+	}
+	return pairs
+}
+
+func formatLabels(pairs [][2]string) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, p := range pairs {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(p[0])
+		b.WriteString(`="`)
+		b.WriteString(escape(p[1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(value string) string {
+	return escaper.Replace(value)
+}
+
+// Sanitize turns s into a valid metric or label name.
+func Sanitize(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		valid := c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9'
+		if !valid {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// FormatValue formats a sample value in the exposition format.
+func FormatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
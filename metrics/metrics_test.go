@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeMonitor string
+
+func (f fakeMonitor) Name() string {
+	return string(f)
+}
+
+func (f fakeMonitor) Check(ctx context.Context) (*status_neko.Result, error) {
+	return nil, nil
+}
+
+type certDetails struct{}
+
+func (certDetails) Measurements() map[string]float64 {
+	return map[string]float64{"days_left": 30.5}
+}
+
+var start = time.Unix(1727769600, 0)
+
+func report(name, provider string, status status_neko.Status, latency time.Duration, tags map[string]string, details interface{}) status_neko.Report {
+	return status_neko.Report{
+		Name:    name,
+		Monitor: fakeMonitor(provider),
+		Tags:    tags,
+		Start:   start,
+		Result:  &status_neko.Result{Status: status, Latency: latency, Details: details},
+	}
+}
+
+func scrape(t *testing.T, e *Exporter) string {
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", resp.Header.Get("Content-Type"))
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestExporter(t *testing.T) {
+	e := NewExporter(SetBuckets([]float64{1, 0.1}))
+
+	tags := map[string]string{"env": "prod", "team-name": `a"b`, "monitor": "x"}
+	e.Observe(report("cert", "certificate_expires", status_neko.StatusUp, 50*time.Millisecond, tags, certDetails{}))
+	e.Observe(report("cert", "certificate_expires", status_neko.StatusDown, 2*time.Second, tags, nil))
+	e.Observe(report("api", "http", status_neko.StatusDegraded, 500*time.Millisecond, nil, nil))
+
+	const labels = `monitor="cert",provider="certificate_expires",env="prod",tag_monitor="x",team_name="a\"b"`
+	assert.Equal(t, `# HELP status_neko_up Whether the last check of the monitor was not down.
+# TYPE status_neko_up gauge
+status_neko_up{monitor="api",provider="http"} 1
+status_neko_up{`+labels+`} 0
+# HELP status_neko_check_duration_seconds Latency of the checks in seconds.
+# TYPE status_neko_check_duration_seconds histogram
+status_neko_check_duration_seconds_bucket{monitor="api",provider="http",le="0.1"} 0
+status_neko_check_duration_seconds_bucket{monitor="api",provider="http",le="1"} 1
+status_neko_check_duration_seconds_bucket{monitor="api",provider="http",le="+Inf"} 1
+status_neko_check_duration_seconds_sum{monitor="api",provider="http"} 0.5
+status_neko_check_duration_seconds_count{monitor="api",provider="http"} 1
+status_neko_check_duration_seconds_bucket{`+labels+`,le="0.1"} 1
+status_neko_check_duration_seconds_bucket{`+labels+`,le="1"} 1
+status_neko_check_duration_seconds_bucket{`+labels+`,le="+Inf"} 2
+status_neko_check_duration_seconds_sum{`+labels+`} 2.05
+status_neko_check_duration_seconds_count{`+labels+`} 2
+# HELP status_neko_consecutive_failures Number of failed checks in a row.
+# TYPE status_neko_consecutive_failures gauge
+status_neko_consecutive_failures{monitor="api",provider="http"} 0
+status_neko_consecutive_failures{`+labels+`} 1
+# HELP status_neko_checks_total Number of checks run.
+# TYPE status_neko_checks_total counter
+status_neko_checks_total{monitor="api",provider="http"} 1
+status_neko_checks_total{`+labels+`} 2
+# HELP status_neko_check_failures_total Number of checks that failed.
+# TYPE status_neko_check_failures_total counter
+status_neko_check_failures_total{monitor="api",provider="http"} 0
+status_neko_check_failures_total{`+labels+`} 1
+# HELP status_neko_last_check_timestamp_seconds Unix time of the last check.
+# TYPE status_neko_last_check_timestamp_seconds gauge
+status_neko_last_check_timestamp_seconds{monitor="api",provider="http"} 1.7277696e+09
+status_neko_last_check_timestamp_seconds{`+labels+`} 1.7277696e+09
+# HELP status_neko_certificate_expires_days_left days_left reported by the certificate_expires provider.
+# TYPE status_neko_certificate_expires_days_left gauge
+status_neko_certificate_expires_days_left{`+labels+`} 30.5
+`, scrape(t, e))
+
+	e.Remove("cert")
+	e.Remove("api")
+	assert.Empty(t, scrape(t, e))
+}
+
+func TestExporter_Run(t *testing.T) {
+	e := NewExporter(SetNamespace("neko"))
+	reports := make(chan status_neko.Report, 1)
+	reports <- report("api", "http", status_neko.StatusUp, time.Millisecond, nil, nil)
+	close(reports)
+	e.Run(context.Background(), reports)
+
+	assert.Contains(t, scrape(t, e), `neko_up{monitor="api",provider="http"} 1`)
+}
+
+func TestSanitize(t *testing.T) {
+	assert.Equal(t, "a_b_c1", Sanitize("a-b.c1"))
+	assert.Equal(t, "_a", Sanitize("9a"))
+	assert.Equal(t, "_x9", Sanitize("1x9"))
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+var (
+	// ErrCircuitOpen is returned instead of checking while the circuit breaker is open.
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+
+	defaultThreshold = 5
+	defaultCooldown  = time.Minute
+)
+
+type breakerOption struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+}
+
+// SetThreshold sets the consecutive failures opening the circuit, default 5.
+func SetThreshold(threshold int) status_neko.Option[*breakerOption] {
+	return func(o *breakerOption) {
+		o.threshold = threshold
+	}
+}
+
+// SetCooldown sets how long the checks are skipped once the circuit is open, default 1m.
+func SetCooldown(cooldown time.Duration) status_neko.Option[*breakerOption] {
+	return func(o *breakerOption) {
+		o.cooldown = cooldown
+	}
+}
+
+// CircuitBreaker stops checking a monitor after threshold consecutive
+// failures: for cooldown the checks fail with ErrCircuitOpen without reaching
+// the provider, then a single check is let through (half-open), closing the
+// circuit on success and opening it again on failure.
+//
+// Each wrapped monitor has its own circuit.
+func CircuitBreaker(opts ...status_neko.Option[*breakerOption]) Middleware {
+	o := &breakerOption{
+		threshold: defaultThreshold,
+		cooldown:  defaultCooldown,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(m status_neko.Monitor) status_neko.Monitor {
+		b := &breaker{option: o}
+		return &check{name: m.Name(), next: m, check: func(ctx context.Context) (*status_neko.Result, error) {
+			if err := b.allow(); err != nil {
+				return nil, err
+			}
+			result, err := m.Check(ctx)
+			b.done(failed(result, err))
+			return result, err
+		}}
+	}
+}
+
+type breaker struct {
+	option *breakerOption
+
+	mu       sync.Mutex
+	failures int
+	// until is the end of the cooldown, zero while the circuit is closed.
+	until   time.Time
+	probing bool
+}
+
+// allow reports whether a check may run, marking it as the probe of a
+// half-open circuit.
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case b.until.IsZero():
+		return nil
+	case b.probing:
+		return fmt.Errorf("%w: probe in progress", ErrCircuitOpen)
+	case b.option.now().Before(b.until):
+		return fmt.Errorf("%w until %s", ErrCircuitOpen, b.until.Format(time.DateTime))
+	}
+	b.probing = true
+	return nil
+}
+
+func (b *breaker) done(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	probe := b.probing
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		b.until = time.Time{}
+		return
+	}
+
+	b.failures++
+	if probe || b.failures >= b.option.threshold {
+		b.until = b.option.now().Add(b.option.cooldown)
+	}
+}
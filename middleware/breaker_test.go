@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	refused := errors.New("refused")
+	f := &fake{outcomes: []error{refused, refused, refused, refused, nil}}
+	m := CircuitBreaker(SetThreshold(2), SetCooldown(time.Minute), func(o *breakerOption) {
+		o.now = func() time.Time { return now }
+	})(f)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := m.Check(ctx)
+		assert.ErrorIs(t, err, refused)
+	}
+	_, err := m.Check(ctx)
+	assert.EqualError(t, err, "circuit breaker is open until 2024-01-01 00:01:00")
+	assert.Equal(t, 2, f.count())
+
+	// 冷却结束后放行一次, 失败则重新打开
+	now = now.Add(time.Minute)
+	_, err = m.Check(ctx)
+	assert.ErrorIs(t, err, refused)
+	_, err = m.Check(ctx)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 3, f.count())
+
+	// 成功后关闭, 重新计数
+	now = now.Add(time.Minute)
+	_, err = m.Check(ctx)
+	assert.ErrorIs(t, err, refused)
+	now = now.Add(time.Minute)
+	_, err = m.Check(ctx)
+	require.NoError(t, err)
+	_, err = m.Check(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 6, f.count())
+}
+
+func TestCircuitBreaker_PerMonitor(t *testing.T) {
+	refused := errors.New("refused")
+	breaker := CircuitBreaker(SetThreshold(1))
+	a := breaker(&fake{outcomes: []error{refused}})
+	b := breaker(&fake{outcomes: []error{nil}})
+
+	_, err := a.Check(context.Background())
+	assert.ErrorIs(t, err, refused)
+	_, err = a.Check(context.Background())
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	_, err = b.Check(context.Background())
+	require.NoError(t, err)
+}
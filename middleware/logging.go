@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+// Logging logs every check to logger, successful ones at debug level and
+// failed ones at warn level.
+func Logging(logger *slog.Logger) Middleware {
+	return func(m status_neko.Monitor) status_neko.Monitor {
+		return &check{name: m.Name(), next: m, check: func(ctx context.Context) (*status_neko.Result, error) {
+			start := time.Now()
+			result, err := m.Check(ctx)
+
+			attrs := []slog.Attr{
+				slog.String("provider", m.Name()),
+				slog.Duration("duration", time.Since(start)),
+			}
+			level := slog.LevelDebug
+			if result != nil {
+				attrs = append(attrs, slog.String("status", string(result.Status)), slog.Duration("latency", result.Latency))
+				if result.Message != "" {
+					attrs = append(attrs, slog.String("message", result.Message))
+				}
+			}
+			if failed(result, err) {
+				level = slog.LevelWarn
+			}
+			if err != nil {
+				attrs = append(attrs, slog.String("err", err.Error()))
+			}
+			logger.LogAttrs(ctx, level, "check", attrs...)
+			return result, err
+		}}
+	}
+}
@@ -0,0 +1,155 @@
+// Package middleware decorates a status_neko.Monitor with retries, hard
+// timeouts, circuit breaking and logging.
+//
+//	m = middleware.Chain(m,
+//		middleware.Logging(logger),
+//		middleware.CircuitBreaker(middleware.SetThreshold(5)),
+//		middleware.Retry(middleware.SetAttempts(3)),
+//		middleware.Timeout(5*time.Second),
+//	)
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+// Middleware wraps a Monitor, the returned Monitor keeps the Name of the wrapped one.
+type Middleware func(status_neko.Monitor) status_neko.Monitor
+
+// Chain wraps m with middlewares, the first one is the outermost and sees
+// the check first.
+func Chain(m status_neko.Monitor, middlewares ...Middleware) status_neko.Monitor {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		m = middlewares[i](m)
+	}
+	return m
+}
+
+// check adapts a function to the Monitor interface.
+type check struct {
+	name  string
+	check func(ctx context.Context) (*status_neko.Result, error)
+	// next is the wrapped monitor.
+	next status_neko.Monitor
+}
+
+func (c *check) Unwrap() status_neko.Monitor {
+	return c.next
+}
+
+func (c *check) Name() string {
+	return c.name
+}
+
+func (c *check) Check(ctx context.Context) (*status_neko.Result, error) {
+	return c.check(ctx)
+}
+
+// failed reports whether a check failed, either with an error or a DOWN result.
+func failed(r *status_neko.Result, err error) bool {
+	return err != nil || (r != nil && r.Status == status_neko.StatusDown)
+}
+
+// Config is the middleware part of a monitor definition, it is read from the
+// same JSON object as the provider config:
+//
+//	{"name": "redis", "type": "tcp", "retries": 2, "circuit_breaker": {"threshold": 5, "cooldown": "5m"}}
+type Config struct {
+	// Retries 是失败后的重试次数, 默认不重试
+	Retries int `json:"retries"`
+	// RetryBackoff 是第一次重试前的等待时间, 之后每次翻倍, 默认 200ms
+	RetryBackoff status_neko.Duration `json:"retry_backoff"`
+	// AttemptTimeout 限制每次尝试的时间, 默认只受监控项的 timeout 限制
+	AttemptTimeout status_neko.Duration `json:"attempt_timeout"`
+	// CircuitBreaker 连续失败后暂停检测, 不配置时不启用
+	CircuitBreaker *BreakerConfig `json:"circuit_breaker"`
+}
+
+type BreakerConfig struct {
+	// Threshold 是打开断路器的连续失败次数, 默认 5
+	Threshold int `json:"threshold"`
+	// Cooldown 是断路器打开后跳过检测的时间, 默认 1m
+	Cooldown status_neko.Duration `json:"cooldown"`
+}
+
+func (c *Config) Validate() error {
+	var fields status_neko.FieldErrors
+	if c.Retries < 0 {
+		fields = append(fields, &status_neko.FieldError{Field: "retries", Message: "must not be negative"})
+	}
+	if c.RetryBackoff < 0 {
+		fields = append(fields, &status_neko.FieldError{Field: "retry_backoff", Message: "must not be negative"})
+	}
+	if c.AttemptTimeout < 0 {
+		fields = append(fields, &status_neko.FieldError{Field: "attempt_timeout", Message: "must not be negative"})
+	}
+	if c.CircuitBreaker != nil {
+		if c.CircuitBreaker.Threshold < 0 {
+			fields = append(fields, &status_neko.FieldError{Field: "circuit_breaker.threshold", Message: "must not be negative"})
+		}
+		if c.CircuitBreaker.Cooldown < 0 {
+			fields = append(fields, &status_neko.FieldError{Field: "circuit_breaker.cooldown", Message: "must not be negative"})
+		}
+	}
+	if len(fields) > 0 {
+		return fields
+	}
+	return nil
+}
+
+// Middlewares returns the middlewares configured by c, outermost first.
+func (c *Config) Middlewares() []Middleware {
+	var middlewares []Middleware
+	if c.CircuitBreaker != nil {
+		var opts []status_neko.Option[*breakerOption]
+		if c.CircuitBreaker.Threshold > 0 {
+			opts = append(opts, SetThreshold(c.CircuitBreaker.Threshold))
+		}
+		if c.CircuitBreaker.Cooldown > 0 {
+			opts = append(opts, SetCooldown(time.Duration(c.CircuitBreaker.Cooldown)))
+		}
+		middlewares = append(middlewares, CircuitBreaker(opts...))
+	}
+	if c.Retries > 0 {
+		opts := []status_neko.Option[*retryOption]{SetAttempts(c.Retries + 1)}
+		if c.RetryBackoff > 0 {
+			opts = append(opts, SetBackoff(time.Duration(c.RetryBackoff), defaultMaxBackoff))
+		}
+		middlewares = append(middlewares, Retry(opts...))
+	}
+	if c.AttemptTimeout > 0 {
+		middlewares = append(middlewares, Timeout(time.Duration(c.AttemptTimeout)))
+	}
+	return middlewares
+}
+
+// Wrap applies the middlewares configured in the definition to m. The
+// timeout of the definition becomes a hard Timeout, so that providers
+// ignoring the context can't hold a worker, and the checks are logged with
+// logger unless it is nil.
+func Wrap(d status_neko.Definition, m status_neko.Monitor, logger *slog.Logger) (status_neko.Monitor, error) {
+	var c Config
+	if len(d.Config) > 0 {
+		if err := json.Unmarshal(d.Config, &c); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	var middlewares []Middleware
+	if logger != nil {
+		middlewares = append(middlewares, Logging(logger.With("monitor", d.Name)))
+	}
+	if d.Timeout > 0 {
+		middlewares = append(middlewares, Timeout(time.Duration(d.Timeout)))
+	}
+	middlewares = append(middlewares, c.Middlewares()...)
+	return Chain(m, middlewares...), nil
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fake returns the queued outcomes one per check, then the last one forever.
+type fake struct {
+	mu       sync.Mutex
+	outcomes []error
+	checks   int
+	delay    time.Duration
+}
+
+func (f *fake) Name() string {
+	return "fake"
+}
+
+func (f *fake) Check(ctx context.Context) (*status_neko.Result, error) {
+	f.mu.Lock()
+	err := f.outcomes[min(f.checks, len(f.outcomes)-1)]
+	f.checks++
+	f.mu.Unlock()
+
+	if f.delay > 0 {
+		time.Sleep(f.delay)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return status_neko.NewResult(status_neko.StatusUp, time.Now(), "", nil), nil
+}
+
+func (f *fake) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.checks
+}
+
+func TestChain(t *testing.T) {
+	var order []string
+	trace := func(name string) Middleware {
+		return func(m status_neko.Monitor) status_neko.Monitor {
+			return &check{name: m.Name(), check: func(ctx context.Context) (*status_neko.Result, error) {
+				order = append(order, name)
+				return m.Check(ctx)
+			}}
+		}
+	}
+
+	m := Chain(&fake{outcomes: []error{nil}}, trace("a"), trace("b"), trace("c"))
+	_, err := m.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, order)
+	assert.Equal(t, "fake", m.Name())
+}
+
+func TestWrap(t *testing.T) {
+	var d status_neko.Definition
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"name": "redis",
+		"type": "fake",
+		"timeout": "1s",
+		"retries": 2,
+		"retry_backoff": "1ms",
+		"circuit_breaker": {"threshold": 3, "cooldown": "1h"}
+	}`), &d))
+
+	f := &fake{outcomes: []error{errors.New("refused")}}
+	var buf bytes.Buffer
+	m, err := Wrap(d, f, slog.New(slog.NewTextHandler(&buf, nil)))
+	require.NoError(t, err)
+
+	_, err = m.Check(context.Background())
+	assert.EqualError(t, err, "3 attempts: refused")
+	assert.Equal(t, 3, f.count())
+	assert.Contains(t, buf.String(), `level=WARN msg=check monitor=redis provider=fake`)
+	assert.Contains(t, buf.String(), `err="3 attempts: refused"`)
+
+	// 断路器按检测计数, 一次检测内的重试不单独计数
+	for i := 0; i < 2; i++ {
+		_, err = m.Check(context.Background())
+		assert.ErrorIs(t, err, f.outcomes[0])
+	}
+	_, err = m.Check(context.Background())
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 9, f.count())
+
+	d.Config = json.RawMessage(`{"name": "redis", "type": "fake", "retries": -1, "circuit_breaker": {"cooldown": "-1s"}}`)
+	_, err = Wrap(d, f, nil)
+	assert.Equal(t, status_neko.FieldErrors{
+		{Field: "retries", Message: "must not be negative"},
+		{Field: "circuit_breaker.cooldown", Message: "must not be negative"},
+	}, status_neko.Fields(err))
+}
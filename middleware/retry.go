@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+var (
+	defaultAttempts   = 3
+	defaultBackoff    = 200 * time.Millisecond
+	defaultMaxBackoff = 10 * time.Second
+)
+
+type retryOption struct {
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	retryable  func(error) bool
+}
+
+// SetAttempts sets the number of attempts including the first one, default 3.
+func SetAttempts(attempts int) status_neko.Option[*retryOption] {
+	return func(o *retryOption) {
+		o.attempts = attempts
+	}
+}
+
+// SetBackoff sets the wait before the first retry, it doubles after each
+// retry up to max. Default 200ms and 10s.
+func SetBackoff(initial, max time.Duration) status_neko.Option[*retryOption] {
+	return func(o *retryOption) {
+		o.backoff = initial
+		o.maxBackoff = max
+	}
+}
+
+// SetRetryable classifies the errors worth a retry, default Retryable.
+func SetRetryable(fn func(error) bool) status_neko.Option[*retryOption] {
+	return func(o *retryOption) {
+		o.retryable = fn
+	}
+}
+
+// permanent marks an error that is not worth a retry.
+type permanent struct {
+	err error
+}
+
+func (p *permanent) Error() string {
+	return p.err.Error()
+}
+
+func (p *permanent) Unwrap() error {
+	return p.err
+}
+
+// Permanent marks err so that Retryable does not retry it, e.g. an
+// authentication failure that will fail again.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanent{err: err}
+}
+
+// Retryable is the default classifier of Retry: every failure is retried
+// except the ones marked Permanent, invalid configurations and checks
+// skipped by an open CircuitBreaker.
+func Retryable(err error) bool {
+	var p *permanent
+	var fields status_neko.FieldErrors
+	var field *status_neko.FieldError
+	switch {
+	case errors.As(err, &p), errors.As(err, &fields), errors.As(err, &field):
+		return false
+	case errors.Is(err, ErrCircuitOpen):
+		return false
+	}
+	return true
+}
+
+// Retry checks again after a failure, waiting an exponential backoff between
+// the attempts. A DOWN result without error is retried as well. It stops
+// once ctx is done and returns the last attempt.
+func Retry(opts ...status_neko.Option[*retryOption]) Middleware {
+	o := &retryOption{
+		attempts:   defaultAttempts,
+		backoff:    defaultBackoff,
+		maxBackoff: defaultMaxBackoff,
+		retryable:  Retryable,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(m status_neko.Monitor) status_neko.Monitor {
+		return &check{name: m.Name(), next: m, check: func(ctx context.Context) (*status_neko.Result, error) {
+			backoff := o.backoff
+			for attempt := 1; ; attempt++ {
+				result, err := m.Check(ctx)
+				if !failed(result, err) || attempt >= o.attempts || (err != nil && !o.retryable(err)) {
+					return result, attempts(attempt, err)
+				}
+
+				timer := time.NewTimer(backoff)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return result, attempts(attempt, err)
+				case <-timer.C:
+				}
+				backoff = min(backoff*2, o.maxBackoff)
+			}
+		}}
+	}
+}
+
+// attempts adds the number of attempts to the error of a retried check.
+func attempts(n int, err error) error {
+	if err == nil || n == 1 {
+		return err
+	}
+	return fmt.Errorf("%d attempts: %w", n, err)
+}
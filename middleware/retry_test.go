@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetry(t *testing.T) {
+	refused := errors.New("refused")
+
+	t.Run("succeeds after retries", func(t *testing.T) {
+		f := &fake{outcomes: []error{refused, refused, nil}}
+		_, err := Retry(SetBackoff(time.Millisecond, time.Millisecond))(f).Check(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 3, f.count())
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		f := &fake{outcomes: []error{refused}}
+		_, err := Retry(SetAttempts(2), SetBackoff(time.Millisecond, time.Millisecond))(f).Check(context.Background())
+		assert.ErrorIs(t, err, refused)
+		assert.EqualError(t, err, "2 attempts: refused")
+		assert.Equal(t, 2, f.count())
+	})
+
+	t.Run("permanent", func(t *testing.T) {
+		f := &fake{outcomes: []error{Permanent(refused)}}
+		_, err := Retry()(f).Check(context.Background())
+		assert.ErrorIs(t, err, refused)
+		assert.EqualError(t, err, "refused")
+		assert.Equal(t, 1, f.count())
+	})
+
+	t.Run("classifier", func(t *testing.T) {
+		f := &fake{outcomes: []error{refused}}
+		_, err := Retry(SetRetryable(func(err error) bool {
+			return !errors.Is(err, refused)
+		}))(f).Check(context.Background())
+		assert.Error(t, err)
+		assert.Equal(t, 1, f.count())
+	})
+
+	t.Run("context done while waiting", func(t *testing.T) {
+		f := &fake{outcomes: []error{refused}}
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := Retry(SetAttempts(10), SetBackoff(time.Hour, time.Hour))(f).Check(ctx)
+		assert.ErrorIs(t, err, refused)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, 1, f.count())
+	})
+}
+
+func TestRetryable(t *testing.T) {
+	assert.True(t, Retryable(errors.New("refused")))
+	assert.False(t, Retryable(Permanent(errors.New("unauthorized"))))
+	assert.False(t, Retryable(ErrCircuitOpen))
+}
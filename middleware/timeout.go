@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+// Timeout cancels the context of a check after timeout and returns without
+// waiting for the check, even when the provider ignores the context. The
+// abandoned check finishes in the background.
+func Timeout(timeout time.Duration) Middleware {
+	type outcome struct {
+		result *status_neko.Result
+		err    error
+	}
+
+	return func(m status_neko.Monitor) status_neko.Monitor {
+		return &check{name: m.Name(), next: m, check: func(ctx context.Context) (*status_neko.Result, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			done := make(chan outcome, 1)
+			go func() {
+				result, err := m.Check(ctx)
+				done <- outcome{result: result, err: err}
+			}()
+
+			select {
+			case o := <-done:
+				return o.result, o.err
+			case <-ctx.Done():
+				return nil, fmt.Errorf("check timed out after %s: %w", timeout, ctx.Err())
+			}
+		}}
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	// fake 忽略 ctx, Timeout 仍然按时返回
+	f := &fake{outcomes: []error{nil}, delay: time.Second}
+	start := time.Now()
+	_, err := Timeout(10 * time.Millisecond)(f).Check(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.EqualError(t, err, "check timed out after 10ms: context deadline exceeded")
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	f = &fake{outcomes: []error{nil}}
+	result, err := Timeout(time.Second)(f).Check(context.Background())
+	require.NoError(t, err)
+	assert.NotNil(t, result)
+}
@@ -1,6 +1,9 @@
 package status_neko
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Monitor is the interface that wraps the basic methods for monitoring the status of a service.
 type Monitor interface {
@@ -8,5 +11,68 @@ type Monitor interface {
 	Name() string
 
 	// Check returns the status of the service.
-	Check(ctx context.Context) (interface{}, error)
+	// A failed check returns the error, either with a nil Result or with a
+	// StatusDown Result carrying what was measured, e.g. the failed
+	// assertions of an HTTP check. Complete normalizes both.
+	Check(ctx context.Context) (*Result, error)
+}
+
+// Starter is implemented by monitors holding a resource outside of their
+// checks, e.g. the token a push monitor receives pushes on. Scheduler.Add
+// starts them, Scheduler.Remove stops them, so that a monitor which was
+// built but never added holds nothing.
+type Starter interface {
+	Start() error
+	Stop()
+}
+
+// Wrapper is implemented by monitors decorating another one, e.g. the
+// middlewares, so that Start and Stop reach the decorated monitor.
+type Wrapper interface {
+	Unwrap() Monitor
+}
+
+// Start starts m, or the monitor it wraps, when it is a Starter.
+func Start(m Monitor) error {
+	for m != nil {
+		if s, ok := m.(Starter); ok {
+			return s.Start()
+		}
+		w, ok := m.(Wrapper)
+		if !ok {
+			return nil
+		}
+		m = w.Unwrap()
+	}
+	return nil
+}
+
+// Stop stops m, or the monitor it wraps, when it is a Starter.
+func Stop(m Monitor) {
+	for m != nil {
+		if s, ok := m.(Starter); ok {
+			s.Stop()
+			return
+		}
+		w, ok := m.(Wrapper)
+		if !ok {
+			return
+		}
+		m = w.Unwrap()
+	}
+}
+
+// DefaultTimeout is the timeout of a check whose ctx has no deadline.
+const DefaultTimeout = 5 * time.Second
+
+// Remaining returns the time left until the deadline of ctx, for the providers
+// whose clients take a timeout instead of a context. It returns DefaultTimeout
+// when ctx has no deadline, and a minimal positive duration when the deadline
+// has already passed.
+func Remaining(ctx context.Context) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return DefaultTimeout
+	}
+	return max(time.Until(deadline), time.Millisecond)
 }
@@ -0,0 +1,23 @@
+package status_neko
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemaining(t *testing.T) {
+	assert.Equal(t, DefaultTimeout, Remaining(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	remaining := Remaining(ctx)
+	assert.Greater(t, remaining, 29*time.Second)
+	assert.LessOrEqual(t, remaining, 30*time.Second)
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	assert.Equal(t, time.Millisecond, Remaining(ctx))
+}
@@ -0,0 +1,241 @@
+package status_neko
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Notification is what a Notifier delivers, it is built from a Transition.
+type Notification struct {
+	Monitor       string        `json:"monitor"`
+	Provider      string        `json:"provider"`
+	State         State         `json:"state"`
+	PreviousState State         `json:"previous_state"`
+	Error         string        `json:"error,omitempty"`
+	Latency       time.Duration `json:"latency"`
+	Time          time.Time     `json:"time"`
+	Flapping      bool          `json:"flapping"`
+}
+
+// NewNotification builds the notification of a transition.
+func NewNotification(tr Transition) Notification {
+	n := Notification{
+		Monitor:       tr.Name,
+		Provider:      tr.Provider,
+		State:         tr.To,
+		PreviousState: tr.From,
+		Time:          tr.At,
+		Flapping:      tr.Flapping,
+	}
+	if tr.Result != nil {
+		n.Latency = tr.Result.Latency
+		if tr.Result.Status == StatusDown {
+			n.Error = tr.Result.Message
+		}
+	}
+	return n
+}
+
+// Notifier is the interface that wraps the basic methods for sending notifications.
+type Notifier interface {
+	// Name returns the name of the notifier.
+	Name() string
+
+	// Notify delivers the notification.
+	Notify(ctx context.Context, n Notification) error
+}
+
+// TemplateFuncs are available in the message templates of the notifiers:
+//
+//	json      encodes a value as JSON, use it for strings inside JSON bodies
+//	upper     strings.ToUpper
+//	lower     strings.ToLower
+//	duration  formats a time.Duration rounded to milliseconds
+//	datetime  formats a time.Time as 2006-01-02 15:04:05
+var TemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": func(v interface{}) string {
+		return strings.ToUpper(fmt.Sprint(v))
+	},
+	"lower": func(v interface{}) string {
+		return strings.ToLower(fmt.Sprint(v))
+	},
+	"duration": func(d time.Duration) string {
+		return d.Round(time.Millisecond).String()
+	},
+	"datetime": func(t time.Time) string {
+		return t.Format(time.DateTime)
+	},
+}
+
+// ParseTemplate parses a message template with TemplateFuncs.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(TemplateFuncs).Parse(text)
+}
+
+// ExecuteTemplate renders the notification with tmpl.
+func ExecuteTemplate(tmpl *template.Template, n Notification) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, n); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+type dispatcherOption struct {
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	timeout    time.Duration
+	filter     func(Transition) bool
+	onError    func(Notifier, Notification, error)
+}
+
+// SetRetry sets how many times a delivery is attempted and the backoff
+// before the first retry, it doubles up to maxBackoff.
+func SetRetry(attempts int, backoff, maxBackoff time.Duration) Option[*dispatcherOption] {
+	return func(o *dispatcherOption) {
+		o.attempts = attempts
+		o.backoff = backoff
+		o.maxBackoff = maxBackoff
+	}
+}
+
+// SetNotifyTimeout sets the deadline of a single delivery attempt.
+func SetNotifyTimeout(timeout time.Duration) Option[*dispatcherOption] {
+	return func(o *dispatcherOption) {
+		o.timeout = timeout
+	}
+}
+
+// SetFilter replaces DefaultFilter.
+func SetFilter(filter func(Transition) bool) Option[*dispatcherOption] {
+	return func(o *dispatcherOption) {
+		o.filter = filter
+	}
+}
+
+// SetErrorHandler is called when a delivery failed after all attempts.
+func SetErrorHandler(onError func(Notifier, Notification, error)) Option[*dispatcherOption] {
+	return func(o *dispatcherOption) {
+		o.onError = onError
+	}
+}
+
+// DefaultFilter notifies when a monitor goes DOWN, recovers from DOWN or
+// settles after flapping, transitions while flapping or into MAINTENANCE
+// or UNREACHABLE are suppressed.
+func DefaultFilter(tr Transition) bool {
+	if tr.Flapping || tr.To == StateMaintenance || tr.To == StateUnreachable {
+		return false
+	}
+	if tr.From == tr.To {
+		return true
+	}
+	return tr.To == StateDown || tr.From == StateDown
+}
+
+// Dispatcher delivers notifications for state transitions to notifiers,
+// every notifier is called concurrently and retried with backoff.
+type Dispatcher struct {
+	option    *dispatcherOption
+	notifiers []Notifier
+
+	wg sync.WaitGroup
+}
+
+func NewDispatcher(notifiers []Notifier, opts ...Option[*dispatcherOption]) *Dispatcher {
+	o := &dispatcherOption{
+		attempts:   3,
+		backoff:    time.Second,
+		maxBackoff: 30 * time.Second,
+		timeout:    10 * time.Second,
+		filter:     DefaultFilter,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.attempts <= 0 {
+		o.attempts = 1
+	}
+
+	return &Dispatcher{
+		option:    o,
+		notifiers: notifiers,
+	}
+}
+
+// Run dispatches transitions until the channel is closed or ctx is done,
+// then waits for the deliveries in flight.
+func (d *Dispatcher) Run(ctx context.Context, transitions <-chan Transition) {
+	defer d.wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case tr, ok := <-transitions:
+			if !ok {
+				return
+			}
+			if d.option.filter(tr) {
+				d.Dispatch(ctx, NewNotification(tr))
+			}
+		}
+	}
+}
+
+// Dispatch delivers n to every notifier in the background.
+func (d *Dispatcher) Dispatch(ctx context.Context, n Notification) {
+	for _, notifier := range d.notifiers {
+		d.wg.Add(1)
+		go func(notifier Notifier) {
+			defer d.wg.Done()
+
+			if err := d.deliver(ctx, notifier, n); err != nil && d.option.onError != nil {
+				d.option.onError(notifier, n, err)
+			}
+		}(notifier)
+	}
+}
+
+// Wait blocks until all deliveries started by Dispatch are done.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, notifier Notifier, n Notification) error {
+	backoff := d.option.backoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, d.option.timeout)
+		err = notifier.Notify(attemptCtx, n)
+		cancel()
+		if err == nil || attempt >= d.option.attempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if d.option.maxBackoff > 0 && backoff > d.option.maxBackoff {
+			backoff = d.option.maxBackoff
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", notifier.Name(), err)
+	}
+	return nil
+}
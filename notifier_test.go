@@ -0,0 +1,120 @@
+package status_neko
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockNotifier struct {
+	mu    sync.Mutex
+	fails int
+	calls int
+	got   []Notification
+}
+
+func (m *mockNotifier) Name() string {
+	return "mock"
+}
+
+func (m *mockNotifier) Notify(ctx context.Context, n Notification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls++
+	if m.calls <= m.fails {
+		return errors.New("unavailable")
+	}
+	m.got = append(m.got, n)
+	return nil
+}
+
+func TestNewNotification(t *testing.T) {
+	at := time.Now()
+	n := NewNotification(Transition{
+		Name:     "db",
+		Provider: "mysql",
+		From:     StateUp,
+		To:       StateDown,
+		At:       at,
+		Result:   &Result{Status: StatusDown, Latency: time.Second, Message: "refused"},
+	})
+
+	assert.Equal(t, Notification{
+		Monitor:       "db",
+		Provider:      "mysql",
+		State:         StateDown,
+		PreviousState: StateUp,
+		Error:         "refused",
+		Latency:       time.Second,
+		Time:          at,
+	}, n)
+}
+
+func TestDefaultFilter(t *testing.T) {
+	assert.True(t, DefaultFilter(Transition{From: StateUp, To: StateDown}))
+	assert.True(t, DefaultFilter(Transition{From: StatePending, To: StateDown}))
+	assert.True(t, DefaultFilter(Transition{From: StateDown, To: StateUp}))
+	assert.True(t, DefaultFilter(Transition{From: StateDown, To: StateDown}))
+	assert.False(t, DefaultFilter(Transition{From: StatePending, To: StateUp}))
+	assert.False(t, DefaultFilter(Transition{From: StateUp, To: StateDown, Flapping: true}))
+	assert.False(t, DefaultFilter(Transition{From: StateDown, To: StateMaintenance}))
+	assert.True(t, DefaultFilter(Transition{From: StateMaintenance, To: StateDown}))
+}
+
+func TestTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate("test", `{{upper .Monitor}} {{lower .State}} {{duration .Latency}} {{datetime .Time}} {{json .Error}}`)
+	require.NoError(t, err)
+
+	s, err := ExecuteTemplate(tmpl, Notification{
+		Monitor: "db",
+		State:   StateDown,
+		Latency: 1234567 * time.Microsecond,
+		Time:    time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC),
+		Error:   `"quoted"`,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `DB down 1.235s 2024-10-01 08:00:00 "\"quoted\""`, s)
+}
+
+func TestDispatcher_Run(t *testing.T) {
+	flaky := &mockNotifier{fails: 2}
+	broken := &mockNotifier{fails: 100}
+
+	var mu sync.Mutex
+	var failures []string
+	d := NewDispatcher([]Notifier{flaky, broken},
+		SetRetry(3, time.Millisecond, time.Millisecond),
+		SetErrorHandler(func(n Notifier, _ Notification, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			failures = append(failures, err.Error())
+		}),
+	)
+
+	transitions := make(chan Transition, 3)
+	transitions <- Transition{Name: "db", From: StatePending, To: StateUp}
+	transitions <- Transition{Name: "db", From: StateUp, To: StateDown}
+	transitions <- Transition{Name: "db", From: StateDown, To: StateUp, Flapping: true}
+	close(transitions)
+
+	d.Run(context.Background(), transitions)
+
+	require.Len(t, flaky.got, 1)
+	assert.Equal(t, StateDown, flaky.got[0].State)
+	assert.Equal(t, 3, flaky.calls)
+	assert.Equal(t, 3, broken.calls)
+	assert.Equal(t, []string{"mock: unavailable"}, failures)
+}
+
+func TestBuildNotifier_Error(t *testing.T) {
+	_, err := BuildNotifier([]byte(`{"type": "nope"}`))
+	assert.EqualError(t, err, `unknown notifier type "nope"`)
+	_, err = BuildNotifier([]byte(`{}`))
+	assert.EqualError(t, err, "notifier config has no type")
+}
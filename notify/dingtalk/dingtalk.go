@@ -0,0 +1,175 @@
+package dingtalk
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"text/template"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+var (
+	_                    status_neko.Notifier = (*DingTalk)(nil)
+	notifierDingTalkName                      = "dingtalk"
+
+	// DefaultTemplate is the markdown used when Config.Template is empty.
+	DefaultTemplate = `### {{if eq .State "DOWN"}}🔴{{else if eq .State "UP"}}🟢{{else}}🟡{{end}} {{.Monitor}} {{.State}}
+- **监控项**: {{.Monitor}}
+- **类型**: {{.Provider}}
+- **状态**: {{.PreviousState}} → {{.State}}
+- **延迟**: {{duration .Latency}}
+{{- if .Error}}
+- **错误**: {{.Error}}
+{{- end}}
+- **时间**: {{datetime .Time}}`
+)
+
+func init() {
+	status_neko.RegisterNotifier(notifierDingTalkName, status_neko.NewNotifierFactory(func(c Config) (status_neko.Notifier, error) {
+		return NewDingTalk(c)
+	}))
+}
+
+type Config struct {
+	// Webhook 机器人地址, 形如 https://oapi.dingtalk.com/robot/send?access_token=xxx
+	Webhook string `json:"webhook"`
+	// Secret 加签密钥, 为空时不签名
+	Secret    string   `json:"secret"`
+	AtMobiles []string `json:"at_mobiles"`
+	AtAll     bool     `json:"at_all"`
+	Template  string   `json:"template"`
+}
+
+type option struct {
+	client *http.Client
+}
+
+func SetClient(client *http.Client) status_neko.Option[*option] {
+	return func(o *option) {
+		o.client = client
+	}
+}
+
+// DingTalk sends markdown messages to a DingTalk group robot.
+type DingTalk struct {
+	config   Config
+	option   *option
+	template *template.Template
+}
+
+func NewDingTalk(config Config, opts ...status_neko.Option[*option]) (*DingTalk, error) {
+	o := &option{
+		client: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if config.Webhook == "" {
+		return nil, fmt.Errorf("dingtalk webhook is required")
+	}
+	if config.Template == "" {
+		config.Template = DefaultTemplate
+	}
+	tmpl, err := status_neko.ParseTemplate(notifierDingTalkName, config.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	return &DingTalk{
+		config:   config,
+		option:   o,
+		template: tmpl,
+	}, nil
+}
+
+func (d *DingTalk) Name() string {
+	return notifierDingTalkName
+}
+
+func (d *DingTalk) Notify(ctx context.Context, n status_neko.Notification) error {
+	text, err := status_neko.ExecuteTemplate(d.template, n)
+	if err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": fmt.Sprintf("%s %s", n.Monitor, n.State),
+			"text":  text,
+		},
+		"at": map[string]interface{}{
+			"atMobiles": d.config.AtMobiles,
+			"isAtAll":   d.config.AtAll,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	webhook, err := d.signedURL(time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.option.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("dingtalk returned %s: %w", resp.Status, err)
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("dingtalk returned errcode %d: %s", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
+
+// signedURL appends timestamp and sign to the webhook when a secret is set.
+func (d *DingTalk) signedURL(now time.Time) (string, error) {
+	if d.config.Secret == "" {
+		return d.config.Webhook, nil
+	}
+
+	u, err := url.Parse(d.config.Webhook)
+	if err != nil {
+		return "", err
+	}
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+
+	query := u.Query()
+	query.Set("timestamp", timestamp)
+	query.Set("sign", Sign(timestamp, d.config.Secret))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Sign computes the signature of a DingTalk robot request,
+// timestamp is in milliseconds.
+func Sign(timestamp, secret string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp + "\n" + secret))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,91 @@
+package dingtalk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var notification = status_neko.Notification{
+	Monitor:       "baidu",
+	Provider:      "http",
+	State:         status_neko.StateDown,
+	PreviousState: status_neko.StateUp,
+	Error:         "connection refused",
+	Latency:       1500 * time.Millisecond,
+	Time:          time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC),
+}
+
+func TestDingTalk_Notify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		assert.Equal(t, "token", query.Get("access_token"))
+
+		timestamp := query.Get("timestamp")
+		ms, err := strconv.ParseInt(timestamp, 10, 64)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), time.UnixMilli(ms), time.Minute)
+		assert.Equal(t, Sign(timestamp, "SEC123"), query.Get("sign"))
+
+		var body struct {
+			MsgType  string `json:"msgtype"`
+			Markdown struct {
+				Title string `json:"title"`
+				Text  string `json:"text"`
+			} `json:"markdown"`
+			At struct {
+				AtMobiles []string `json:"atMobiles"`
+				IsAtAll   bool     `json:"isAtAll"`
+			} `json:"at"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "markdown", body.MsgType)
+		assert.Equal(t, "baidu DOWN", body.Markdown.Title)
+		assert.Contains(t, body.Markdown.Text, "### 🔴 baidu DOWN")
+		assert.Contains(t, body.Markdown.Text, "- **类型**: http")
+		assert.Contains(t, body.Markdown.Text, "- **延迟**: 1.5s")
+		assert.Contains(t, body.Markdown.Text, "- **错误**: connection refused")
+		assert.Contains(t, body.Markdown.Text, "- **时间**: 2024-10-01 08:00:00")
+		assert.Equal(t, []string{"13800000000"}, body.At.AtMobiles)
+
+		w.Write([]byte(`{"errcode": 0, "errmsg": "ok"}`))
+	}))
+	defer server.Close()
+
+	d, err := NewDingTalk(Config{
+		Webhook:   server.URL + "/robot/send?access_token=token",
+		Secret:    "SEC123",
+		AtMobiles: []string{"13800000000"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, notifierDingTalkName, d.Name())
+	require.NoError(t, d.Notify(context.Background(), notification))
+}
+
+func TestDingTalk_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.URL.Query().Get("sign"))
+		w.Write([]byte(`{"errcode": 310000, "errmsg": "sign not match"}`))
+	}))
+	defer server.Close()
+
+	n, err := status_neko.BuildNotifier([]byte(`{"type": "dingtalk", "webhook": "` + server.URL + `"}`))
+	require.NoError(t, err)
+	assert.EqualError(t, n.Notify(context.Background(), notification), "dingtalk returned errcode 310000: sign not match")
+
+	_, err = NewDingTalk(Config{})
+	assert.Error(t, err)
+}
+
+func TestSign(t *testing.T) {
+	// HmacSHA256(key=secret, message=timestamp+"\n"+secret)
+	assert.Equal(t, "hmPWwU+7lVdm3ZZz0r9tSfx0L4Q26jWOZr9+Gs6EZQM=", Sign("1577262236757", "this is secret"))
+}
@@ -0,0 +1,151 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"text/template"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+var (
+	_                   status_neko.Notifier = (*Discord)(nil)
+	notifierDiscordName                      = "discord"
+
+	// DefaultTemplate is the embed description used when Config.Template is empty.
+	DefaultTemplate = `**Type**: {{.Provider}}
+**State**: {{.PreviousState}} → {{.State}}
+**Latency**: {{duration .Latency}}
+{{- if .Error}}
+**Error**: {{.Error}}
+{{- end}}`
+)
+
+func init() {
+	status_neko.RegisterNotifier(notifierDiscordName, status_neko.NewNotifierFactory(func(c Config) (status_neko.Notifier, error) {
+		return NewDiscord(c)
+	}))
+}
+
+type Config struct {
+	// Webhook 为频道的 Webhook 地址, 形如 https://discord.com/api/webhooks/<id>/<token>,
+	// 测试时可以指向本地服务
+	Webhook   string `json:"webhook"`
+	Username  string `json:"username"`
+	AvatarURL string `json:"avatar_url"`
+	Template  string `json:"template"`
+}
+
+type option struct {
+	client *http.Client
+}
+
+func SetClient(client *http.Client) status_neko.Option[*option] {
+	return func(o *option) {
+		o.client = client
+	}
+}
+
+// Discord sends embed messages to a Discord channel webhook.
+type Discord struct {
+	config   Config
+	option   *option
+	template *template.Template
+}
+
+func NewDiscord(config Config, opts ...status_neko.Option[*option]) (*Discord, error) {
+	o := &option{
+		client: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if config.Webhook == "" {
+		return nil, fmt.Errorf("discord webhook is required")
+	}
+	if config.Template == "" {
+		config.Template = DefaultTemplate
+	}
+	tmpl, err := status_neko.ParseTemplate(notifierDiscordName, config.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	return &Discord{
+		config:   config,
+		option:   o,
+		template: tmpl,
+	}, nil
+}
+
+func (d *Discord) Name() string {
+	return notifierDiscordName
+}
+
+func (d *Discord) Notify(ctx context.Context, n status_neko.Notification) error {
+	content, err := status_neko.ExecuteTemplate(d.template, n)
+	if err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	message := map[string]interface{}{
+		"embeds": []map[string]interface{}{
+			{
+				"title":       fmt.Sprintf("%s is %s", n.Monitor, n.State),
+				"description": content,
+				"color":       color(n.State),
+				"timestamp":   n.Time.Format(time.RFC3339),
+			},
+		},
+	}
+	if d.config.Username != "" {
+		message["username"] = d.config.Username
+	}
+	if d.config.AvatarURL != "" {
+		message["avatar_url"] = d.config.AvatarURL
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.config.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.option.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 成功时返回 204 No Content
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("discord returned %s: %s", resp.Status, b)
+	}
+	return nil
+}
+
+// color returns the embed color of a state.
+func color(state status_neko.State) int {
+	switch state {
+	case status_neko.StateUp:
+		return 0x57f287
+	case status_neko.StateDown:
+		return 0xed4245
+	case status_neko.StateMaintenance:
+		return 0x3498db
+	default:
+		return 0xfee75c
+	}
+}
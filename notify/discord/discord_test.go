@@ -0,0 +1,80 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var notification = status_neko.Notification{
+	Monitor:       "baidu",
+	Provider:      "http",
+	State:         status_neko.StateUp,
+	PreviousState: status_neko.StateDown,
+	Latency:       120 * time.Millisecond,
+	Time:          time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC),
+}
+
+func TestDiscord_Notify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/webhooks/1/token", r.URL.Path)
+
+		var body struct {
+			Username string `json:"username"`
+			Embeds   []struct {
+				Title       string `json:"title"`
+				Description string `json:"description"`
+				Color       int    `json:"color"`
+				Timestamp   string `json:"timestamp"`
+			} `json:"embeds"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "neko", body.Username)
+		require.Len(t, body.Embeds, 1)
+		assert.Equal(t, "baidu is UP", body.Embeds[0].Title)
+		assert.Equal(t, 0x57f287, body.Embeds[0].Color)
+		assert.Equal(t, "2024-10-01T08:00:00Z", body.Embeds[0].Timestamp)
+		assert.Contains(t, body.Embeds[0].Description, "**State**: DOWN → UP")
+		assert.Contains(t, body.Embeds[0].Description, "**Latency**: 120ms")
+		assert.NotContains(t, body.Embeds[0].Description, "Error")
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	d, err := NewDiscord(Config{Webhook: server.URL + "/api/webhooks/1/token", Username: "neko"})
+	require.NoError(t, err)
+	assert.Equal(t, notifierDiscordName, d.Name())
+	require.NoError(t, d.Notify(context.Background(), notification))
+}
+
+func TestDiscord_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, `{"message": "Unknown Webhook", "code": 10015}`)
+	}))
+	defer server.Close()
+
+	n, err := status_neko.BuildNotifier([]byte(`{"type": "discord", "webhook": "` + server.URL + `"}`))
+	require.NoError(t, err)
+	err = n.Notify(context.Background(), notification)
+	assert.EqualError(t, err, `discord returned 404 Not Found: {"message": "Unknown Webhook", "code": 10015}`)
+
+	_, err = NewDiscord(Config{})
+	assert.Error(t, err)
+}
+
+func TestColor(t *testing.T) {
+	assert.Equal(t, 0xed4245, color(status_neko.StateDown))
+	assert.Equal(t, 0x57f287, color(status_neko.StateUp))
+	assert.Equal(t, 0x3498db, color(status_neko.StateMaintenance))
+	assert.Equal(t, 0xfee75c, color(status_neko.StatePending))
+}
@@ -0,0 +1,478 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+type (
+	// AuthType is the SMTP authentication mechanism.
+	AuthType string
+
+	// Security is how the connection to the SMTP server is encrypted.
+	Security string
+)
+
+var (
+	_                 status_neko.Notifier = (*Email)(nil)
+	notifierEmailName                      = "email"
+
+	AuthTypeNone  AuthType = ""
+	AuthTypePlain AuthType = "plain"
+	AuthTypeLogin AuthType = "login"
+
+	SecurityNone     Security = ""
+	SecurityStartTLS Security = "starttls"
+	SecurityTLS      Security = "tls" // 隐式 TLS, 通常为 465 端口
+
+	defaultBatchWindow = 5 * time.Second
+	sendTimeout        = 30 * time.Second
+	// sentRetention 是已发送的通知保留多久, 期间重试的同一通知不再发送
+	sentRetention = time.Hour
+
+	// DefaultSubjectTemplate is used when Config.Subject is empty.
+	DefaultSubjectTemplate = `{{if eq (len .Notifications) 1}}{{with index .Notifications 0}}[{{.State}}] {{.Monitor}}{{end}}{{else}}[status-neko] {{len .Notifications}} monitors changed state{{end}}`
+
+	// DefaultTextTemplate is used when Config.TextTemplate is empty.
+	DefaultTextTemplate = `{{range .Notifications}}[{{.State}}] {{.Monitor}} ({{.Provider}})
+  状态: {{.PreviousState}} -> {{.State}}
+  延迟: {{duration .Latency}}
+{{- if .Error}}
+  错误: {{.Error}}
+{{- end}}
+  时间: {{datetime .Time}}
+
+{{end}}`
+
+	// DefaultHTMLTemplate is used when Config.HTMLTemplate is empty.
+	DefaultHTMLTemplate = `<table cellpadding="6" style="border-collapse: collapse; font-family: sans-serif;">
+<tr style="background: #f0f0f0;"><th align="left">监控项</th><th align="left">类型</th><th align="left">状态</th><th align="left">延迟</th><th align="left">错误</th><th align="left">时间</th></tr>
+{{range .Notifications}}<tr>
+<td>{{.Monitor}}</td>
+<td>{{.Provider}}</td>
+<td style="color: {{if eq .State "DOWN"}}#d9534f{{else if eq .State "UP"}}#5cb85c{{else}}#f0ad4e{{end}};">{{.PreviousState}} &rarr; <b>{{.State}}</b></td>
+<td>{{duration .Latency}}</td>
+<td>{{.Error}}</td>
+<td>{{datetime .Time}}</td>
+</tr>
+{{end}}</table>`
+)
+
+func init() {
+	status_neko.RegisterNotifier(notifierEmailName, status_neko.NewNotifierFactory(func(c Config) (status_neko.Notifier, error) {
+		return NewEmail(c)
+	}))
+}
+
+type Config struct {
+	Host                  string   `json:"host"`
+	Port                  int      `json:"port"`
+	Security              Security `json:"security"`
+	SkipCertificateVerify bool     `json:"skip_certificate_verify"`
+
+	AuthType AuthType `json:"auth_type"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+
+	From string   `json:"from"`
+	To   []string `json:"to"`
+	Cc   []string `json:"cc"`
+
+	// 邮件主题和正文模板, 模板数据为 Batch
+	Subject      string `json:"subject"`
+	TextTemplate string `json:"text_template"`
+	HTMLTemplate string `json:"html_template"`
+
+	// BatchWindow 窗口期内的多次状态变化合并为一封邮件, 默认 5s
+	BatchWindow status_neko.Duration `json:"batch_window"`
+}
+
+type option struct {
+	tlsConfig *tls.Config
+}
+
+// SetTLSConfig sets the TLS configuration used for TLS and STARTTLS,
+// e.g. to trust a private CA. ServerName defaults to Config.Host.
+func SetTLSConfig(tlsConfig *tls.Config) status_neko.Option[*option] {
+	return func(o *option) {
+		o.tlsConfig = tlsConfig
+	}
+}
+
+// Batch is the data of the email templates.
+type Batch struct {
+	Notifications []status_neko.Notification
+}
+
+type batch struct {
+	Batch
+	done chan struct{}
+	err  error
+}
+
+// identity tells the retries of a notification apart from new ones.
+type identity struct {
+	monitor string
+	from    status_neko.State
+	to      status_neko.State
+	at      int64
+}
+
+func identityOf(n status_neko.Notification) identity {
+	return identity{monitor: n.Monitor, from: n.PreviousState, to: n.State, at: n.Time.UnixNano()}
+}
+
+// Email sends notifications through an SMTP server. Notifications arriving
+// within the batch window share one email, Notify blocks until it is sent.
+// When Notify gives up earlier, e.g. on the timeout of the dispatcher, the
+// email is still sent, and a retry of the same notification waits for that
+// email instead of being sent again.
+type Email struct {
+	config  Config
+	option  *option
+	subject *template.Template
+	text    *template.Template
+	html    *htmltemplate.Template
+
+	mu      sync.Mutex
+	pending *batch
+	// batches 是待发送、发送中和最近发送成功的通知所在的批次
+	batches map[identity]*batch
+}
+
+func NewEmail(config Config, opts ...status_neko.Option[*option]) (*Email, error) {
+	o := &option{
+		tlsConfig: &tls.Config{},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if config.Host == "" {
+		return nil, errors.New("email host is required")
+	}
+	if config.From == "" {
+		return nil, errors.New("email from is required")
+	}
+	if len(config.To)+len(config.Cc) == 0 {
+		return nil, errors.New("email needs at least one recipient")
+	}
+	if config.Port == 0 {
+		switch config.Security {
+		case SecurityTLS:
+			config.Port = 465
+		case SecurityStartTLS:
+			config.Port = 587
+		default:
+			config.Port = 25
+		}
+	}
+	if config.BatchWindow == 0 {
+		config.BatchWindow = status_neko.Duration(defaultBatchWindow)
+	}
+	if config.Subject == "" {
+		config.Subject = DefaultSubjectTemplate
+	}
+	if config.TextTemplate == "" {
+		config.TextTemplate = DefaultTextTemplate
+	}
+	if config.HTMLTemplate == "" {
+		config.HTMLTemplate = DefaultHTMLTemplate
+	}
+
+	subject, err := template.New("subject").Funcs(status_neko.TemplateFuncs).Parse(config.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse subject: %w", err)
+	}
+	text, err := template.New("text").Funcs(status_neko.TemplateFuncs).Parse(config.TextTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse text template: %w", err)
+	}
+	html, err := htmltemplate.New("html").Funcs(htmltemplate.FuncMap(status_neko.TemplateFuncs)).Parse(config.HTMLTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse html template: %w", err)
+	}
+
+	return &Email{
+		config:  config,
+		option:  o,
+		subject: subject,
+		text:    text,
+		html:    html,
+		batches: make(map[identity]*batch),
+	}, nil
+}
+
+func (e *Email) Name() string {
+	return notifierEmailName
+}
+
+func (e *Email) Notify(ctx context.Context, n status_neko.Notification) error {
+	id := identityOf(n)
+	e.mu.Lock()
+	b, ok := e.batches[id]
+	if !ok {
+		b = e.pending
+		if b == nil {
+			b = &batch{done: make(chan struct{})}
+			e.pending = b
+			time.AfterFunc(time.Duration(e.config.BatchWindow), func() {
+				e.flush(b)
+			})
+		}
+		b.Notifications = append(b.Notifications, n)
+		e.batches[id] = b
+	}
+	e.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return b.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *Email) flush(b *batch) {
+	e.mu.Lock()
+	if e.pending == b {
+		e.pending = nil
+	}
+	e.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	msg, err := e.message(b.Batch)
+	if err == nil {
+		err = e.send(ctx, msg)
+	}
+	b.err = err
+	close(b.done)
+
+	// 发送失败的通知可以重试, 发送成功的保留一段时间以忽略重试
+	forget := func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		for _, n := range b.Notifications {
+			if id := identityOf(n); e.batches[id] == b {
+				delete(e.batches, id)
+			}
+		}
+	}
+	if err != nil {
+		forget()
+	} else {
+		time.AfterFunc(sentRetention, forget)
+	}
+}
+
+// message builds a multipart/alternative email with a text and an HTML part.
+func (e *Email) message(b Batch) ([]byte, error) {
+	var subject, text, html bytes.Buffer
+	if err := e.subject.Execute(&subject, b); err != nil {
+		return nil, fmt.Errorf("failed to render subject: %w", err)
+	}
+	if err := e.text.Execute(&text, b); err != nil {
+		return nil, fmt.Errorf("failed to render text template: %w", err)
+	}
+	if err := e.html.Execute(&html, b); err != nil {
+		return nil, fmt.Errorf("failed to render html template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	header := textproto.MIMEHeader{}
+	header.Set("From", e.config.From)
+	header.Set("To", strings.Join(e.config.To, ", "))
+	if len(e.config.Cc) > 0 {
+		header.Set("Cc", strings.Join(e.config.Cc, ", "))
+	}
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject.String())))
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("Message-ID", messageID(e.config.Host))
+	header.Set("MIME-Version", "1.0")
+	header.Set("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+
+	var out bytes.Buffer
+	for _, key := range []string{"From", "To", "Cc", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type"} {
+		if value := header.Get(key); value != "" {
+			fmt.Fprintf(&out, "%s: %s\r\n", key, value)
+		}
+	}
+	out.WriteString("\r\n")
+
+	for _, part := range []struct {
+		contentType string
+		body        []byte
+	}{
+		{"text/plain; charset=utf-8", text.Bytes()},
+		{"text/html; charset=utf-8", html.Bytes()},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write(part.body); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	out.Write(buf.Bytes())
+	return out.Bytes(), nil
+}
+
+func (e *Email) send(ctx context.Context, msg []byte) error {
+	addr := net.JoinHostPort(e.config.Host, strconv.Itoa(e.config.Port))
+	tlsConfig := e.option.tlsConfig.Clone()
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = e.config.Host
+	}
+	if e.config.SkipCertificateVerify {
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{}
+	if e.config.Security == SecurityTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, e.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if e.config.Security == SecurityStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+
+	switch e.config.AuthType {
+	case AuthTypePlain:
+		err = c.Auth(smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host))
+	case AuthTypeLogin:
+		err = c.Auth(&loginAuth{username: e.config.Username, password: e.config.Password, host: e.config.Host})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to authenticate: %w", err)
+	}
+
+	if err := c.Mail(address(e.config.From)); err != nil {
+		return err
+	}
+	for _, rcpt := range append(append([]string{}, e.config.To...), e.config.Cc...) {
+		if err := c.Rcpt(address(rcpt)); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// address strips the display name of "Name <user@example.com>".
+func address(s string) string {
+	if i := strings.LastIndex(s, "<"); i >= 0 {
+		if j := strings.LastIndex(s, ">"); j > i {
+			return s[i+1 : j]
+		}
+	}
+	return strings.TrimSpace(s)
+}
+
+func messageID(host string) string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("<%s.%d@%s>", hex.EncodeToString(b), time.Now().UnixNano(), host)
+}
+
+// loginAuth implements the LOGIN mechanism that net/smtp lacks.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	// 与 smtp.PlainAuth 一致, 只在加密连接或本地连接上发送密码
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge %q", fromServer)
+	}
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
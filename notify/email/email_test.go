@@ -0,0 +1,421 @@
+package email
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var notification = status_neko.Notification{
+	Monitor:       "baidu",
+	Provider:      "http",
+	State:         status_neko.StateDown,
+	PreviousState: status_neko.StateUp,
+	Error:         "connection refused",
+	Latency:       1500 * time.Millisecond,
+	Time:          time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC),
+}
+
+type envelope struct {
+	auth  string
+	tls   bool
+	from  string
+	rcpts []string
+	data  string
+}
+
+// smtpServer is a minimal SMTP server that records the mails it receives.
+type smtpServer struct {
+	ln         net.Listener
+	tlsConfig  *tls.Config
+	implicit   bool
+	rejectAuth bool
+
+	mu   sync.Mutex
+	mail []envelope
+}
+
+func newSMTPServer(t *testing.T, implicit bool) (*smtpServer, *tls.Config) {
+	cert, pool := certificate(t)
+	s := &smtpServer{
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+		implicit:  implicit,
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	if implicit {
+		ln = tls.NewListener(ln, s.tlsConfig)
+	}
+	s.ln = ln
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s, &tls.Config{RootCAs: pool}
+}
+
+func (s *smtpServer) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpServer) received() []envelope {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]envelope{}, s.mail...)
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	isTLS := s.implicit
+	var e envelope
+
+	_ = tp.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			if !isTLS {
+				_ = tp.PrintfLine("250-localhost")
+				_ = tp.PrintfLine("250-STARTTLS")
+			} else {
+				_ = tp.PrintfLine("250-localhost")
+			}
+			_ = tp.PrintfLine("250 AUTH PLAIN LOGIN")
+		case "STARTTLS":
+			_ = tp.PrintfLine("220 ready")
+			conn = tls.Server(conn, s.tlsConfig)
+			tp = textproto.NewConn(conn)
+			isTLS = true
+		case "AUTH":
+			mechanism, initial, _ := strings.Cut(arg, " ")
+			switch mechanism {
+			case "PLAIN":
+				b, _ := base64.StdEncoding.DecodeString(initial)
+				e.auth = "PLAIN " + strings.ReplaceAll(string(b), "\x00", " ")
+			case "LOGIN":
+				_ = tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte("Username:")))
+				user, _ := tp.ReadLine()
+				_ = tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte("Password:")))
+				pass, _ := tp.ReadLine()
+				u, _ := base64.StdEncoding.DecodeString(user)
+				p, _ := base64.StdEncoding.DecodeString(pass)
+				e.auth = "LOGIN " + string(u) + " " + string(p)
+			}
+			if s.rejectAuth {
+				_ = tp.PrintfLine("535 authentication failed")
+				continue
+			}
+			_ = tp.PrintfLine("235 ok")
+		case "MAIL":
+			e.tls = isTLS
+			e.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			_ = tp.PrintfLine("250 ok")
+		case "RCPT":
+			e.rcpts = append(e.rcpts, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			_ = tp.PrintfLine("250 ok")
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			b, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			e.data = string(b)
+			s.mu.Lock()
+			s.mail = append(s.mail, e)
+			s.mu.Unlock()
+			_ = tp.PrintfLine("250 ok")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("250 ok")
+		}
+	}
+}
+
+func certificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+// parse returns the decoded subject and the text and html parts of a mail.
+func parse(t *testing.T, data string) (subject, text, html string) {
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	require.NoError(t, err)
+	subject, err = new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.Equal(t, "quoted-printable", part.Header.Get("Content-Transfer-Encoding"))
+		b, err := io.ReadAll(quotedprintable.NewReader(part))
+		require.NoError(t, err)
+		if strings.HasPrefix(part.Header.Get("Content-Type"), "text/html") {
+			html = string(b)
+		} else {
+			text = string(b)
+		}
+	}
+	return subject, text, html
+}
+
+func TestEmail_Batch(t *testing.T) {
+	server, tlsConfig := newSMTPServer(t, false)
+
+	e, err := NewEmail(Config{
+		Host:        "127.0.0.1",
+		Port:        server.port(),
+		Security:    SecurityStartTLS,
+		AuthType:    AuthTypePlain,
+		Username:    "neko",
+		Password:    "secret",
+		From:        "Status Neko <neko@example.com>",
+		To:          []string{"ops@example.com"},
+		Cc:          []string{"dev@example.com"},
+		BatchWindow: status_neko.Duration(100 * time.Millisecond),
+	}, SetTLSConfig(tlsConfig))
+	require.NoError(t, err)
+	assert.Equal(t, notifierEmailName, e.Name())
+
+	recovered := notification
+	recovered.Monitor = "google"
+	recovered.State, recovered.PreviousState = status_neko.StateUp, status_neko.StateDown
+	recovered.Error = ""
+
+	var wg sync.WaitGroup
+	for _, n := range []status_neko.Notification{notification, recovered} {
+		wg.Add(1)
+		go func(n status_neko.Notification) {
+			defer wg.Done()
+			assert.NoError(t, e.Notify(context.Background(), n))
+		}(n)
+	}
+	wg.Wait()
+
+	received := server.received()
+	require.Len(t, received, 1)
+	m := received[0]
+	assert.True(t, m.tls)
+	assert.Equal(t, "PLAIN  neko secret", m.auth)
+	assert.Equal(t, "neko@example.com", m.from)
+	assert.Equal(t, []string{"ops@example.com", "dev@example.com"}, m.rcpts)
+
+	subject, text, html := parse(t, m.data)
+	assert.Equal(t, "[status-neko] 2 monitors changed state", subject)
+	assert.Contains(t, text, "[DOWN] baidu (http)")
+	assert.Contains(t, text, "错误: connection refused")
+	assert.Contains(t, text, "[UP] google (http)")
+	assert.Contains(t, html, "<td>baidu</td>")
+	assert.Contains(t, html, "<td>google</td>")
+	assert.Contains(t, html, "UP &rarr; <b>DOWN</b>")
+}
+
+func TestEmail_ImplicitTLS(t *testing.T) {
+	server, tlsConfig := newSMTPServer(t, true)
+
+	e, err := NewEmail(Config{
+		Host:         "127.0.0.1",
+		Port:         server.port(),
+		Security:     SecurityTLS,
+		AuthType:     AuthTypeLogin,
+		Username:     "neko",
+		Password:     "secret",
+		From:         "neko@example.com",
+		To:           []string{"ops@example.com"},
+		Subject:      `{{range .Notifications}}{{.Monitor}} 状态变为 {{.State}}{{end}}`,
+		HTMLTemplate: `{{range .Notifications}}<p>{{.Error}}</p>{{end}}`,
+		BatchWindow:  status_neko.Duration(time.Millisecond),
+	}, SetTLSConfig(tlsConfig))
+	require.NoError(t, err)
+
+	n := notification
+	n.Error = "<script>"
+	require.NoError(t, e.Notify(context.Background(), n))
+
+	received := server.received()
+	require.Len(t, received, 1)
+	assert.True(t, received[0].tls)
+	assert.Equal(t, "LOGIN neko secret", received[0].auth)
+
+	subject, _, html := parse(t, received[0].data)
+	assert.Equal(t, "baidu 状态变为 DOWN", subject)
+	// html/template 会转义内容
+	assert.Equal(t, "<p>&lt;script&gt;</p>", html)
+}
+
+func TestEmail_Error(t *testing.T) {
+	server, tlsConfig := newSMTPServer(t, false)
+	server.rejectAuth = true
+
+	e, err := NewEmail(Config{
+		Host:        "127.0.0.1",
+		Port:        server.port(),
+		Security:    SecurityStartTLS,
+		AuthType:    AuthTypePlain,
+		From:        "neko@example.com",
+		To:          []string{"ops@example.com"},
+		BatchWindow: status_neko.Duration(50 * time.Millisecond),
+	}, SetTLSConfig(tlsConfig))
+	require.NoError(t, err)
+
+	// 同一批次的每个调用者都收到发送错误
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := e.Notify(context.Background(), notification)
+			assert.ErrorContains(t, err, "failed to authenticate")
+		}()
+	}
+	wg.Wait()
+	assert.Empty(t, server.received())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, e.Notify(ctx, notification), context.DeadlineExceeded)
+}
+
+// TestEmail_Retry: the dispatcher gives up on Notify before the batch window
+// ends and retries, the notification is still sent only once.
+func TestEmail_Retry(t *testing.T) {
+	server, tlsConfig := newSMTPServer(t, false)
+
+	e, err := NewEmail(Config{
+		Host:        "127.0.0.1",
+		Port:        server.port(),
+		Security:    SecurityStartTLS,
+		From:        "neko@example.com",
+		To:          []string{"ops@example.com"},
+		BatchWindow: status_neko.Duration(100 * time.Millisecond),
+	}, SetTLSConfig(tlsConfig))
+	require.NoError(t, err)
+
+	var failed []error
+	d := status_neko.NewDispatcher([]status_neko.Notifier{e},
+		status_neko.SetNotifyTimeout(20*time.Millisecond),
+		status_neko.SetRetry(3, 10*time.Millisecond, 10*time.Millisecond),
+		status_neko.SetErrorHandler(func(_ status_neko.Notifier, _ status_neko.Notification, err error) {
+			failed = append(failed, err)
+		}),
+	)
+	d.Dispatch(context.Background(), notification)
+	d.Wait()
+	require.Len(t, failed, 1)
+
+	assert.Eventually(t, func() bool { return len(server.received()) == 1 }, time.Second, 10*time.Millisecond)
+	// 发送成功后重试的同一通知直接返回
+	require.NoError(t, e.Notify(context.Background(), notification))
+	time.Sleep(150 * time.Millisecond)
+	assert.Len(t, server.received(), 1)
+}
+
+func TestNewEmail(t *testing.T) {
+	_, err := NewEmail(Config{From: "a@example.com", To: []string{"b@example.com"}})
+	assert.EqualError(t, err, "email host is required")
+
+	_, err = NewEmail(Config{Host: "smtp.example.com", To: []string{"b@example.com"}})
+	assert.EqualError(t, err, "email from is required")
+
+	_, err = NewEmail(Config{Host: "smtp.example.com", From: "a@example.com"})
+	assert.EqualError(t, err, "email needs at least one recipient")
+
+	_, err = NewEmail(Config{Host: "smtp.example.com", From: "a@example.com", To: []string{"b@example.com"}, Subject: "{{"})
+	assert.ErrorContains(t, err, "failed to parse subject")
+
+	for security, port := range map[Security]int{SecurityNone: 25, SecurityStartTLS: 587, SecurityTLS: 465} {
+		e, err := NewEmail(Config{Host: "smtp.example.com", Security: security, From: "a@example.com", To: []string{"b@example.com"}})
+		require.NoError(t, err)
+		assert.Equal(t, port, e.config.Port)
+	}
+
+	n, err := status_neko.BuildNotifier([]byte(`{"type": "email", "host": "smtp.example.com", "from": "a@example.com", "to": ["b@example.com"], "batch_window": "1m"}`))
+	require.NoError(t, err)
+	assert.Equal(t, status_neko.Duration(time.Minute), n.(*Email).config.BatchWindow)
+}
+
+func TestLoginAuth(t *testing.T) {
+	a := &loginAuth{username: "neko", password: "secret", host: "smtp.example.com"}
+
+	_, _, err := a.Start(&smtp.ServerInfo{Name: "smtp.example.com"})
+	assert.EqualError(t, err, "unencrypted connection")
+
+	mechanism, _, err := a.Start(&smtp.ServerInfo{Name: "smtp.example.com", TLS: true})
+	require.NoError(t, err)
+	assert.Equal(t, "LOGIN", mechanism)
+
+	b, err := a.Next([]byte("Username:"), true)
+	require.NoError(t, err)
+	assert.Equal(t, "neko", string(b))
+	b, err = a.Next([]byte("Password:"), true)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(b))
+	_, err = a.Next([]byte("Token:"), true)
+	assert.Error(t, err)
+}
+
+func TestAddress(t *testing.T) {
+	assert.Equal(t, "neko@example.com", address("Status Neko <neko@example.com>"))
+	assert.Equal(t, "neko@example.com", address(" neko@example.com "))
+}
@@ -0,0 +1,173 @@
+package feishu
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"text/template"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+var (
+	_                  status_neko.Notifier = (*Feishu)(nil)
+	notifierFeishuName                      = "feishu"
+
+	// DefaultTemplate is the card markdown used when Config.Template is empty.
+	DefaultTemplate = `**监控项**: {{.Monitor}}
+**类型**: {{.Provider}}
+**状态**: {{.PreviousState}} → {{.State}}
+**延迟**: {{duration .Latency}}
+{{- if .Error}}
+**错误**: {{.Error}}
+{{- end}}
+**时间**: {{datetime .Time}}`
+)
+
+func init() {
+	status_neko.RegisterNotifier(notifierFeishuName, status_neko.NewNotifierFactory(func(c Config) (status_neko.Notifier, error) {
+		return NewFeishu(c)
+	}))
+}
+
+type Config struct {
+	// Webhook 机器人地址, 飞书为 https://open.feishu.cn/open-apis/bot/v2/hook/xxx,
+	// Lark 为 https://open.larksuite.com/open-apis/bot/v2/hook/xxx
+	Webhook string `json:"webhook"`
+	// Secret 签名校验密钥, 为空时不签名
+	Secret   string `json:"secret"`
+	Template string `json:"template"`
+}
+
+type option struct {
+	client *http.Client
+}
+
+func SetClient(client *http.Client) status_neko.Option[*option] {
+	return func(o *option) {
+		o.client = client
+	}
+}
+
+// Feishu sends interactive card messages to a Feishu/Lark group robot.
+type Feishu struct {
+	config   Config
+	option   *option
+	template *template.Template
+}
+
+func NewFeishu(config Config, opts ...status_neko.Option[*option]) (*Feishu, error) {
+	o := &option{
+		client: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if config.Webhook == "" {
+		return nil, fmt.Errorf("feishu webhook is required")
+	}
+	if config.Template == "" {
+		config.Template = DefaultTemplate
+	}
+	tmpl, err := status_neko.ParseTemplate(notifierFeishuName, config.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	return &Feishu{
+		config:   config,
+		option:   o,
+		template: tmpl,
+	}, nil
+}
+
+func (f *Feishu) Name() string {
+	return notifierFeishuName
+}
+
+func (f *Feishu) Notify(ctx context.Context, n status_neko.Notification) error {
+	content, err := status_neko.ExecuteTemplate(f.template, n)
+	if err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	message := map[string]interface{}{
+		"msg_type": "interactive",
+		"card": map[string]interface{}{
+			"config": map[string]bool{"wide_screen_mode": true},
+			"header": map[string]interface{}{
+				"title": map[string]string{
+					"tag":     "plain_text",
+					"content": fmt.Sprintf("%s %s", n.Monitor, n.State),
+				},
+				"template": color(n.State),
+			},
+			"elements": []map[string]string{
+				{"tag": "markdown", "content": content},
+			},
+		},
+	}
+	if f.config.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		message["timestamp"] = timestamp
+		message["sign"] = Sign(timestamp, f.config.Secret)
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.config.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := f.option.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("feishu returned %s: %w", resp.Status, err)
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("feishu returned code %d: %s", result.Code, result.Msg)
+	}
+	return nil
+}
+
+// color returns the card header template of a state.
+func color(state status_neko.State) string {
+	switch state {
+	case status_neko.StateUp:
+		return "green"
+	case status_neko.StateDown:
+		return "red"
+	case status_neko.StateMaintenance:
+		return "blue"
+	default:
+		return "orange"
+	}
+}
+
+// Sign computes the signature of a Feishu robot request, timestamp is in
+// seconds. Feishu signs an empty message with timestamp+"\n"+secret as key.
+func Sign(timestamp, secret string) string {
+	h := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
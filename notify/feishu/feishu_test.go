@@ -0,0 +1,94 @@
+package feishu
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var notification = status_neko.Notification{
+	Monitor:       "baidu",
+	Provider:      "http",
+	State:         status_neko.StateUp,
+	PreviousState: status_neko.StateDown,
+	Latency:       120 * time.Millisecond,
+	Time:          time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC),
+}
+
+func TestFeishu_Notify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Timestamp string `json:"timestamp"`
+			Sign      string `json:"sign"`
+			MsgType   string `json:"msg_type"`
+			Card      struct {
+				Header struct {
+					Title struct {
+						Content string `json:"content"`
+					} `json:"title"`
+					Template string `json:"template"`
+				} `json:"header"`
+				Elements []struct {
+					Tag     string `json:"tag"`
+					Content string `json:"content"`
+				} `json:"elements"`
+			} `json:"card"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		seconds, err := strconv.ParseInt(body.Timestamp, 10, 64)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), time.Unix(seconds, 0), time.Minute)
+		assert.Equal(t, Sign(body.Timestamp, "secret"), body.Sign)
+
+		assert.Equal(t, "interactive", body.MsgType)
+		assert.Equal(t, "baidu UP", body.Card.Header.Title.Content)
+		assert.Equal(t, "green", body.Card.Header.Template)
+		require.Len(t, body.Card.Elements, 1)
+		assert.Equal(t, "markdown", body.Card.Elements[0].Tag)
+		assert.Contains(t, body.Card.Elements[0].Content, "**状态**: DOWN → UP")
+		assert.Contains(t, body.Card.Elements[0].Content, "**延迟**: 120ms")
+		assert.NotContains(t, body.Card.Elements[0].Content, "错误")
+
+		w.Write([]byte(`{"code": 0, "msg": "success"}`))
+	}))
+	defer server.Close()
+
+	f, err := NewFeishu(Config{Webhook: server.URL, Secret: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, notifierFeishuName, f.Name())
+	require.NoError(t, f.Notify(context.Background(), notification))
+}
+
+func TestFeishu_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code": 19021, "msg": "sign match fail or timestamp is not within one hour from current time"}`))
+	}))
+	defer server.Close()
+
+	n, err := status_neko.BuildNotifier([]byte(`{"type": "feishu", "webhook": "` + server.URL + `", "template": "{{.Monitor}}"}`))
+	require.NoError(t, err)
+	err = n.Notify(context.Background(), notification)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "feishu returned code 19021")
+}
+
+func TestColor(t *testing.T) {
+	assert.Equal(t, "red", color(status_neko.StateDown))
+	assert.Equal(t, "green", color(status_neko.StateUp))
+	assert.Equal(t, "blue", color(status_neko.StateMaintenance))
+	assert.Equal(t, "orange", color(status_neko.StatePending))
+}
+
+func TestSign(t *testing.T) {
+	// HmacSHA256(key=timestamp+"\n"+secret, message="")
+	assert.Equal(t, "q4jswNiMy51J5JuQV566yJat0/lQ/c+22kINzUgKsGU=", Sign("1599360473", "secret"))
+}
@@ -0,0 +1,204 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+var (
+	_                 status_neko.Notifier = (*Slack)(nil)
+	notifierSlackName                      = "slack"
+
+	defaultBaseURL = "https://slack.com/api"
+
+	// DefaultTemplate is the mrkdwn text used when Config.Template is empty.
+	DefaultTemplate = `*Type*: {{.Provider}}
+*State*: {{.PreviousState}} → {{.State}}
+*Latency*: {{duration .Latency}}
+{{- if .Error}}
+*Error*: {{.Error}}
+{{- end}}`
+)
+
+func init() {
+	status_neko.RegisterNotifier(notifierSlackName, status_neko.NewNotifierFactory(func(c Config) (status_neko.Notifier, error) {
+		return NewSlack(c)
+	}))
+}
+
+type Config struct {
+	// Webhook 为 Incoming Webhook 地址, 与 Token 二选一
+	Webhook string `json:"webhook"`
+
+	// Token 为 Bot token, 通过 chat.postMessage 发送到 Channel
+	Token   string `json:"token"`
+	Channel string `json:"channel"`
+	// BaseURL 为 Web API 地址, 默认 https://slack.com/api
+	BaseURL string `json:"base_url"`
+
+	Username  string `json:"username"`
+	IconEmoji string `json:"icon_emoji"`
+	Template  string `json:"template"`
+}
+
+type option struct {
+	client *http.Client
+}
+
+func SetClient(client *http.Client) status_neko.Option[*option] {
+	return func(o *option) {
+		o.client = client
+	}
+}
+
+// Slack sends Block Kit messages through an incoming webhook or a bot token.
+type Slack struct {
+	config   Config
+	option   *option
+	template *template.Template
+}
+
+func NewSlack(config Config, opts ...status_neko.Option[*option]) (*Slack, error) {
+	o := &option{
+		client: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if config.Webhook == "" && config.Token == "" {
+		return nil, fmt.Errorf("slack webhook or token is required")
+	}
+	if config.Token != "" && config.Channel == "" {
+		return nil, fmt.Errorf("slack channel is required when using a token")
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = defaultBaseURL
+	}
+	if config.Template == "" {
+		config.Template = DefaultTemplate
+	}
+	tmpl, err := status_neko.ParseTemplate(notifierSlackName, config.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	return &Slack{
+		config:   config,
+		option:   o,
+		template: tmpl,
+	}, nil
+}
+
+func (s *Slack) Name() string {
+	return notifierSlackName
+}
+
+func (s *Slack) Notify(ctx context.Context, n status_neko.Notification) error {
+	content, err := status_neko.ExecuteTemplate(s.template, n)
+	if err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	title := fmt.Sprintf("%s is %s", n.Monitor, n.State)
+	message := map[string]interface{}{
+		// text 作为通知栏和不支持 blocks 的客户端的回退内容
+		"text": title,
+		"attachments": []map[string]interface{}{
+			{
+				"color": color(n.State),
+				"blocks": []map[string]interface{}{
+					{
+						"type": "header",
+						"text": map[string]string{"type": "plain_text", "text": title},
+					},
+					{
+						"type": "section",
+						"text": map[string]string{"type": "mrkdwn", "text": content},
+					},
+					{
+						"type": "context",
+						"elements": []map[string]string{
+							{"type": "mrkdwn", "text": fmt.Sprintf("<!date^%d^{date_short_pretty} {time_secs}|%s>", n.Time.Unix(), n.Time.Format("2006-01-02 15:04:05"))},
+						},
+					},
+				},
+			},
+		},
+	}
+	if s.config.Username != "" {
+		message["username"] = s.config.Username
+	}
+	if s.config.IconEmoji != "" {
+		message["icon_emoji"] = s.config.IconEmoji
+	}
+
+	url := s.config.Webhook
+	if s.config.Token != "" {
+		url = strings.TrimRight(s.config.BaseURL, "/") + "/chat.postMessage"
+		message["channel"] = s.config.Channel
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if s.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.Token)
+	}
+
+	resp, err := s.option.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("slack returned %s: %s", resp.Status, b)
+	}
+	if s.config.Token == "" {
+		// Incoming Webhook 成功时返回 "ok"
+		return nil
+	}
+
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(b, &result); err != nil {
+		return fmt.Errorf("slack returned %s: %w", resp.Status, err)
+	}
+	if !result.OK {
+		return fmt.Errorf("slack returned error: %s", result.Error)
+	}
+	return nil
+}
+
+// color returns the attachment color of a state.
+func color(state status_neko.State) string {
+	switch state {
+	case status_neko.StateUp:
+		return "#2eb886"
+	case status_neko.StateDown:
+		return "#e01e5a"
+	case status_neko.StateMaintenance:
+		return "#1d9bd1"
+	default:
+		return "#ecb22e"
+	}
+}
@@ -0,0 +1,123 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var notification = status_neko.Notification{
+	Monitor:       "baidu",
+	Provider:      "http",
+	State:         status_neko.StateDown,
+	PreviousState: status_neko.StateUp,
+	Error:         "connection refused",
+	Latency:       1500 * time.Millisecond,
+	Time:          time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC),
+}
+
+type message struct {
+	Text        string `json:"text"`
+	Channel     string `json:"channel"`
+	Username    string `json:"username"`
+	Attachments []struct {
+		Color  string `json:"color"`
+		Blocks []struct {
+			Type string `json:"type"`
+			Text struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"text"`
+		} `json:"blocks"`
+	} `json:"attachments"`
+}
+
+func TestSlack_Webhook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/services/T000/B000/XXX", r.URL.Path)
+		assert.Empty(t, r.Header.Get("Authorization"))
+
+		var body message
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "baidu is DOWN", body.Text)
+		assert.Equal(t, "neko", body.Username)
+		require.Len(t, body.Attachments, 1)
+		assert.Equal(t, "#e01e5a", body.Attachments[0].Color)
+
+		blocks := body.Attachments[0].Blocks
+		require.Len(t, blocks, 3)
+		assert.Equal(t, "header", blocks[0].Type)
+		assert.Equal(t, "baidu is DOWN", blocks[0].Text.Text)
+		assert.Equal(t, "mrkdwn", blocks[1].Text.Type)
+		assert.Contains(t, blocks[1].Text.Text, "*State*: UP → DOWN")
+		assert.Contains(t, blocks[1].Text.Text, "*Error*: connection refused")
+		assert.Equal(t, "context", blocks[2].Type)
+
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	s, err := NewSlack(Config{Webhook: server.URL + "/services/T000/B000/XXX", Username: "neko"})
+	require.NoError(t, err)
+	assert.Equal(t, notifierSlackName, s.Name())
+	require.NoError(t, s.Notify(context.Background(), notification))
+}
+
+func TestSlack_Token(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/chat.postMessage", r.URL.Path)
+		assert.Equal(t, "Bearer xoxb-token", r.Header.Get("Authorization"))
+
+		var body message
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "#alerts", body.Channel)
+		assert.Equal(t, "baidu DOWN", body.Attachments[0].Blocks[1].Text.Text)
+
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	n, err := status_neko.BuildNotifier([]byte(`{"type": "slack", "token": "xoxb-token", "channel": "#alerts", "base_url": "` + server.URL + `/api/", "template": "{{.Monitor}} {{.State}}"}`))
+	require.NoError(t, err)
+	require.NoError(t, n.Notify(context.Background(), notification))
+}
+
+func TestSlack_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chat.postMessage" {
+			w.Write([]byte(`{"ok": false, "error": "channel_not_found"}`))
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, "invalid_token")
+	}))
+	defer server.Close()
+
+	s, err := NewSlack(Config{Token: "xoxb-token", Channel: "#missing", BaseURL: server.URL})
+	require.NoError(t, err)
+	assert.EqualError(t, s.Notify(context.Background(), notification), "slack returned error: channel_not_found")
+
+	s, err = NewSlack(Config{Webhook: server.URL + "/services/T000/B000/XXX"})
+	require.NoError(t, err)
+	assert.EqualError(t, s.Notify(context.Background(), notification), "slack returned 403 Forbidden: invalid_token")
+
+	_, err = NewSlack(Config{})
+	assert.Error(t, err)
+	_, err = NewSlack(Config{Token: "xoxb-token"})
+	assert.Error(t, err)
+}
+
+func TestColor(t *testing.T) {
+	assert.Equal(t, "#e01e5a", color(status_neko.StateDown))
+	assert.Equal(t, "#2eb886", color(status_neko.StateUp))
+	assert.Equal(t, "#1d9bd1", color(status_neko.StateMaintenance))
+	assert.Equal(t, "#ecb22e", color(status_neko.StatePending))
+}
@@ -0,0 +1,175 @@
+package teams
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"text/template"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+var (
+	_                 status_neko.Notifier = (*Teams)(nil)
+	notifierTeamsName                      = "teams"
+
+	// DefaultTemplate is the card text used when Config.Template is empty,
+	// Adaptive Cards need a blank line to break lines.
+	DefaultTemplate = `**Type**: {{.Provider}}
+
+**State**: {{.PreviousState}} → {{.State}}
+
+**Latency**: {{duration .Latency}}
+{{- if .Error}}
+
+**Error**: {{.Error}}
+{{- end}}
+
+**Time**: {{datetime .Time}}`
+)
+
+func init() {
+	status_neko.RegisterNotifier(notifierTeamsName, status_neko.NewNotifierFactory(func(c Config) (status_neko.Notifier, error) {
+		return NewTeams(c)
+	}))
+}
+
+type Config struct {
+	// Webhook 为 Incoming Webhook 或 Workflows 的 HTTP 触发地址,
+	// 测试时可以指向本地服务
+	Webhook  string `json:"webhook"`
+	Template string `json:"template"`
+}
+
+type option struct {
+	client *http.Client
+}
+
+func SetClient(client *http.Client) status_neko.Option[*option] {
+	return func(o *option) {
+		o.client = client
+	}
+}
+
+// Teams sends Adaptive Card messages to a Microsoft Teams channel webhook.
+type Teams struct {
+	config   Config
+	option   *option
+	template *template.Template
+}
+
+func NewTeams(config Config, opts ...status_neko.Option[*option]) (*Teams, error) {
+	o := &option{
+		client: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if config.Webhook == "" {
+		return nil, fmt.Errorf("teams webhook is required")
+	}
+	if config.Template == "" {
+		config.Template = DefaultTemplate
+	}
+	tmpl, err := status_neko.ParseTemplate(notifierTeamsName, config.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	return &Teams{
+		config:   config,
+		option:   o,
+		template: tmpl,
+	}, nil
+}
+
+func (t *Teams) Name() string {
+	return notifierTeamsName
+}
+
+func (t *Teams) Notify(ctx context.Context, n status_neko.Notification) error {
+	content, err := status_neko.ExecuteTemplate(t.template, n)
+	if err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	style, textColor := color(n.State)
+	message := map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{
+			{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]interface{}{
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"type":    "AdaptiveCard",
+					"version": "1.4",
+					"msteams": map[string]string{"width": "Full"},
+					"body": []map[string]interface{}{
+						{
+							"type":  "Container",
+							"style": style,
+							"bleed": true,
+							"items": []map[string]interface{}{
+								{
+									"type":   "TextBlock",
+									"text":   fmt.Sprintf("%s is %s", n.Monitor, n.State),
+									"size":   "Large",
+									"weight": "Bolder",
+									"color":  textColor,
+									"wrap":   true,
+								},
+							},
+						},
+						{
+							"type": "TextBlock",
+							"text": content,
+							"wrap": true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.config.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.option.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Incoming Webhook 返回 200, Workflows 返回 202
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("teams returned %s: %s", resp.Status, b)
+	}
+	return nil
+}
+
+// color returns the container style and the title color of a state.
+func color(state status_neko.State) (style, textColor string) {
+	switch state {
+	case status_neko.StateUp:
+		return "good", "Good"
+	case status_neko.StateDown:
+		return "attention", "Attention"
+	case status_neko.StateMaintenance:
+		return "accent", "Accent"
+	default:
+		return "warning", "Warning"
+	}
+}
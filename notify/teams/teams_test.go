@@ -0,0 +1,98 @@
+package teams
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var notification = status_neko.Notification{
+	Monitor:       "baidu",
+	Provider:      "http",
+	State:         status_neko.StateDown,
+	PreviousState: status_neko.StateUp,
+	Error:         "connection refused",
+	Latency:       1500 * time.Millisecond,
+	Time:          time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC),
+}
+
+func TestTeams_Notify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Type        string `json:"type"`
+			Attachments []struct {
+				ContentType string `json:"contentType"`
+				Content     struct {
+					Type string `json:"type"`
+					Body []struct {
+						Type  string `json:"type"`
+						Style string `json:"style"`
+						Text  string `json:"text"`
+						Items []struct {
+							Text  string `json:"text"`
+							Color string `json:"color"`
+						} `json:"items"`
+					} `json:"body"`
+				} `json:"content"`
+			} `json:"attachments"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "message", body.Type)
+		require.Len(t, body.Attachments, 1)
+		assert.Equal(t, "application/vnd.microsoft.card.adaptive", body.Attachments[0].ContentType)
+
+		card := body.Attachments[0].Content
+		assert.Equal(t, "AdaptiveCard", card.Type)
+		require.Len(t, card.Body, 2)
+		assert.Equal(t, "attention", card.Body[0].Style)
+		require.Len(t, card.Body[0].Items, 1)
+		assert.Equal(t, "baidu is DOWN", card.Body[0].Items[0].Text)
+		assert.Equal(t, "Attention", card.Body[0].Items[0].Color)
+		assert.Contains(t, card.Body[1].Text, "**State**: UP → DOWN")
+		assert.Contains(t, card.Body[1].Text, "**Error**: connection refused")
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	tm, err := NewTeams(Config{Webhook: server.URL})
+	require.NoError(t, err)
+	assert.Equal(t, notifierTeamsName, tm.Name())
+	require.NoError(t, tm.Notify(context.Background(), notification))
+}
+
+func TestTeams_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, "Webhook message delivery failed")
+	}))
+	defer server.Close()
+
+	n, err := status_neko.BuildNotifier([]byte(`{"type": "teams", "webhook": "` + server.URL + `", "template": "{{.Monitor}}"}`))
+	require.NoError(t, err)
+	err = n.Notify(context.Background(), notification)
+	assert.EqualError(t, err, "teams returned 400 Bad Request: Webhook message delivery failed")
+
+	_, err = NewTeams(Config{})
+	assert.Error(t, err)
+}
+
+func TestColor(t *testing.T) {
+	for state, want := range map[status_neko.State][2]string{
+		status_neko.StateUp:          {"good", "Good"},
+		status_neko.StateDown:        {"attention", "Attention"},
+		status_neko.StateMaintenance: {"accent", "Accent"},
+		status_neko.StatePending:     {"warning", "Warning"},
+	} {
+		style, textColor := color(state)
+		assert.Equal(t, want, [2]string{style, textColor})
+	}
+}
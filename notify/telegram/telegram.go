@@ -0,0 +1,176 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+var (
+	_                    status_neko.Notifier = (*Telegram)(nil)
+	notifierTelegramName                      = "telegram"
+
+	defaultBaseURL = "https://api.telegram.org"
+
+	// DefaultTemplate is the HTML message used when Config.Template is empty,
+	// user supplied text has to be escaped with the builtin html function.
+	DefaultTemplate = `<b>{{html .Monitor}}</b> is <b>{{.State}}</b>
+Type: {{html .Provider}}
+State: {{.PreviousState}} → {{.State}}
+Latency: {{duration .Latency}}
+{{- if .Error}}
+Error: <code>{{html .Error}}</code>
+{{- end}}
+Time: {{datetime .Time}}`
+)
+
+func init() {
+	status_neko.RegisterNotifier(notifierTelegramName, status_neko.NewNotifierFactory(func(c Config) (status_neko.Notifier, error) {
+		return NewTelegram(c)
+	}))
+}
+
+type Config struct {
+	Token  string `json:"token"`
+	ChatID string `json:"chat_id"`
+	// BaseURL 为 Bot API 地址, 默认 https://api.telegram.org
+	BaseURL string `json:"base_url"`
+	// ParseMode 默认 HTML, 自定义模板时可改为 MarkdownV2
+	ParseMode           string `json:"parse_mode"`
+	DisableNotification bool   `json:"disable_notification"`
+	Template            string `json:"template"`
+}
+
+type option struct {
+	client *http.Client
+}
+
+func SetClient(client *http.Client) status_neko.Option[*option] {
+	return func(o *option) {
+		o.client = client
+	}
+}
+
+// Telegram sends messages through the sendMessage method of the Bot API.
+type Telegram struct {
+	config   Config
+	option   *option
+	template *template.Template
+}
+
+func NewTelegram(config Config, opts ...status_neko.Option[*option]) (*Telegram, error) {
+	o := &option{
+		client: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if config.Token == "" {
+		return nil, fmt.Errorf("telegram token is required")
+	}
+	if config.ChatID == "" {
+		return nil, fmt.Errorf("telegram chat_id is required")
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = defaultBaseURL
+	}
+	if config.ParseMode == "" {
+		config.ParseMode = "HTML"
+	}
+	if config.Template == "" {
+		config.Template = DefaultTemplate
+	}
+	tmpl, err := status_neko.ParseTemplate(notifierTelegramName, config.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	return &Telegram{
+		config:   config,
+		option:   o,
+		template: tmpl,
+	}, nil
+}
+
+func (t *Telegram) Name() string {
+	return notifierTelegramName
+}
+
+func (t *Telegram) Notify(ctx context.Context, n status_neko.Notification) error {
+	content, err := status_neko.ExecuteTemplate(t.template, n)
+	if err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	// Telegram 消息没有颜色, 用 emoji 区分状态
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id":                  t.config.ChatID,
+		"text":                     emoji(n.State) + " " + content,
+		"parse_mode":               t.config.ParseMode,
+		"disable_notification":     t.config.DisableNotification,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimRight(t.config.BaseURL, "/"), t.config.Token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.option.client.Do(req)
+	if err != nil {
+		// 错误信息中的地址包含 token
+		return fmt.Errorf("failed to send message: %w", redact(err, t.config.Token))
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		ErrorCode   int    `json:"error_code"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("telegram returned %s: %w", resp.Status, err)
+	}
+	if !result.OK {
+		return fmt.Errorf("telegram returned error %d: %s", result.ErrorCode, result.Description)
+	}
+	return nil
+}
+
+// emoji returns the marker of a state.
+func emoji(state status_neko.State) string {
+	switch state {
+	case status_neko.StateUp:
+		return "🟢"
+	case status_neko.StateDown:
+		return "🔴"
+	case status_neko.StateMaintenance:
+		return "🔵"
+	default:
+		return "🟠"
+	}
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+func redact(err error, token string) error {
+	return &redactedError{msg: strings.ReplaceAll(err.Error(), token, "<token>"), err: err}
+}
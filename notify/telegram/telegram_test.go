@@ -0,0 +1,84 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var notification = status_neko.Notification{
+	Monitor:       "baidu",
+	Provider:      "http",
+	State:         status_neko.StateDown,
+	PreviousState: status_neko.StateUp,
+	Error:         "unexpected <html> response",
+	Latency:       1500 * time.Millisecond,
+	Time:          time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC),
+}
+
+func TestTelegram_Notify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/bot123:abc/sendMessage", r.URL.Path)
+
+		var body struct {
+			ChatID              string `json:"chat_id"`
+			Text                string `json:"text"`
+			ParseMode           string `json:"parse_mode"`
+			DisableNotification bool   `json:"disable_notification"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "-100123", body.ChatID)
+		assert.Equal(t, "HTML", body.ParseMode)
+		assert.True(t, body.DisableNotification)
+		assert.Contains(t, body.Text, "🔴 <b>baidu</b> is <b>DOWN</b>")
+		assert.Contains(t, body.Text, "Error: <code>unexpected &lt;html&gt; response</code>")
+		assert.Contains(t, body.Text, "Time: 2024-10-01 08:00:00")
+
+		w.Write([]byte(`{"ok": true, "result": {"message_id": 1}}`))
+	}))
+	defer server.Close()
+
+	tg, err := NewTelegram(Config{Token: "123:abc", ChatID: "-100123", BaseURL: server.URL + "/", DisableNotification: true})
+	require.NoError(t, err)
+	assert.Equal(t, notifierTelegramName, tg.Name())
+	require.NoError(t, tg.Notify(context.Background(), notification))
+}
+
+func TestTelegram_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"ok": false, "error_code": 400, "description": "Bad Request: chat not found"}`))
+	}))
+	defer server.Close()
+
+	n, err := status_neko.BuildNotifier([]byte(`{"type": "telegram", "token": "123:abc", "chat_id": "1", "base_url": "` + server.URL + `"}`))
+	require.NoError(t, err)
+	err = n.Notify(context.Background(), notification)
+	assert.EqualError(t, err, "telegram returned error 400: Bad Request: chat not found")
+
+	// 连接失败时不泄露 token
+	tg, err := NewTelegram(Config{Token: "123:abc", ChatID: "1", BaseURL: "http://127.0.0.1:1"})
+	require.NoError(t, err)
+	err = tg.Notify(context.Background(), notification)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "123:abc")
+
+	_, err = NewTelegram(Config{ChatID: "1"})
+	assert.Error(t, err)
+	_, err = NewTelegram(Config{Token: "123:abc"})
+	assert.Error(t, err)
+}
+
+func TestEmoji(t *testing.T) {
+	assert.Equal(t, "🔴", emoji(status_neko.StateDown))
+	assert.Equal(t, "🟢", emoji(status_neko.StateUp))
+	assert.Equal(t, "🔵", emoji(status_neko.StateMaintenance))
+	assert.Equal(t, "🟠", emoji(status_neko.StatePending))
+}
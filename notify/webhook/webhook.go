@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"text/template"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+var (
+	_                   status_neko.Notifier = (*Webhook)(nil)
+	notifierWebhookName                      = "webhook"
+
+	// DefaultTemplate is used when Config.Template is empty.
+	DefaultTemplate = `{
+  "monitor": {{json .Monitor}},
+  "provider": {{json .Provider}},
+  "state": {{json .State}},
+  "previous_state": {{json .PreviousState}},
+  "error": {{json .Error}},
+  "latency_ms": {{.Latency.Milliseconds}},
+  "time": {{json .Time}}
+}`
+)
+
+func init() {
+	status_neko.RegisterNotifier(notifierWebhookName, status_neko.NewNotifierFactory(func(c Config) (status_neko.Notifier, error) {
+		return NewWebhook(c)
+	}))
+}
+
+type Config struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method"` // 默认 POST
+	Headers map[string]string `json:"headers"`
+	// Template 是请求体的 text/template 模板, 可以使用 status_neko.TemplateFuncs
+	Template string `json:"template"`
+}
+
+type option struct {
+	client *http.Client
+}
+
+func SetClient(client *http.Client) status_neko.Option[*option] {
+	return func(o *option) {
+		o.client = client
+	}
+}
+
+// Webhook sends the notification as templated JSON to a URL.
+type Webhook struct {
+	config   Config
+	option   *option
+	template *template.Template
+}
+
+func NewWebhook(config Config, opts ...status_neko.Option[*option]) (*Webhook, error) {
+	o := &option{
+		client: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if config.URL == "" {
+		return nil, fmt.Errorf("webhook url is required")
+	}
+	if config.Method == "" {
+		config.Method = http.MethodPost
+	}
+	if config.Template == "" {
+		config.Template = DefaultTemplate
+	}
+
+	tmpl, err := status_neko.ParseTemplate(notifierWebhookName, config.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	return &Webhook{
+		config:   config,
+		option:   o,
+		template: tmpl,
+	}, nil
+}
+
+func (w *Webhook) Name() string {
+	return notifierWebhookName
+}
+
+func (w *Webhook) Notify(ctx context.Context, n status_neko.Notification) error {
+	body, err := status_neko.ExecuteTemplate(w.template, n)
+	if err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, w.config.Method, w.config.URL, bytes.NewBufferString(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range w.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := w.option.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, b)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var notification = status_neko.Notification{
+	Monitor:       "baidu",
+	Provider:      "http",
+	State:         status_neko.StateDown,
+	PreviousState: status_neko.StateUp,
+	Error:         `dial tcp: "connection refused"`,
+	Latency:       1500 * time.Millisecond,
+	Time:          time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC),
+}
+
+func TestWebhook_Notify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("X-Token"))
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "baidu", body["monitor"])
+		assert.Equal(t, "http", body["provider"])
+		assert.Equal(t, "DOWN", body["state"])
+		assert.Equal(t, "UP", body["previous_state"])
+		assert.Equal(t, `dial tcp: "connection refused"`, body["error"])
+		assert.Equal(t, float64(1500), body["latency_ms"])
+		assert.Equal(t, "2024-10-01T08:00:00Z", body["time"])
+	}))
+	defer server.Close()
+
+	w, err := NewWebhook(Config{URL: server.URL, Headers: map[string]string{"X-Token": "secret"}})
+	require.NoError(t, err)
+	assert.Equal(t, notifierWebhookName, w.Name())
+	require.NoError(t, w.Notify(context.Background(), notification))
+}
+
+func TestWebhook_Template(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		b, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"text": "[DOWN] baidu (http): dial tcp: \"connection refused\""}`, string(b))
+	}))
+	defer server.Close()
+
+	w, err := NewWebhook(Config{
+		URL:      server.URL,
+		Method:   http.MethodPut,
+		Template: `{"text": {{json (printf "[%s] %s (%s): %s" .State .Monitor .Provider .Error)}}}`,
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.Notify(context.Background(), notification))
+
+	_, err = NewWebhook(Config{URL: server.URL, Template: "{{"})
+	assert.Error(t, err)
+	_, err = NewWebhook(Config{})
+	assert.Error(t, err)
+}
+
+func TestWebhook_Retry(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notifier, err := status_neko.BuildNotifier([]byte(`{"type": "webhook", "url": "` + server.URL + `"}`))
+	require.NoError(t, err)
+
+	var failed error
+	d := status_neko.NewDispatcher([]status_neko.Notifier{notifier},
+		status_neko.SetRetry(3, time.Millisecond, 10*time.Millisecond),
+		status_neko.SetErrorHandler(func(_ status_neko.Notifier, _ status_neko.Notification, err error) {
+			failed = err
+		}),
+	)
+	d.Dispatch(context.Background(), notification)
+	d.Wait()
+
+	assert.NoError(t, failed)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// 超过重试次数后报告错误
+	calls.Store(-10)
+	d.Dispatch(context.Background(), notification)
+	d.Wait()
+	require.Error(t, failed)
+	assert.Contains(t, failed.Error(), "502 Bad Gateway")
+}
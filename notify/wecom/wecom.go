@@ -0,0 +1,127 @@
+package wecom
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+var (
+	_                 status_neko.Notifier = (*WeCom)(nil)
+	notifierWeComName                      = "wecom"
+
+	// DefaultTemplate is the markdown used when Config.Template is empty.
+	DefaultTemplate = `### {{.Monitor}} <font color="{{if eq .State "DOWN"}}warning{{else if eq .State "UP"}}info{{else}}comment{{end}}">{{.State}}</font>
+> 类型: <font color="comment">{{.Provider}}</font>
+> 状态: <font color="comment">{{.PreviousState}} → {{.State}}</font>
+> 延迟: <font color="comment">{{duration .Latency}}</font>
+{{- if .Error}}
+> 错误: <font color="warning">{{.Error}}</font>
+{{- end}}
+> 时间: <font color="comment">{{datetime .Time}}</font>`
+)
+
+func init() {
+	status_neko.RegisterNotifier(notifierWeComName, status_neko.NewNotifierFactory(func(c Config) (status_neko.Notifier, error) {
+		return NewWeCom(c)
+	}))
+}
+
+type Config struct {
+	// Webhook 群机器人地址, 形如 https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=xxx
+	Webhook  string `json:"webhook"`
+	Template string `json:"template"`
+}
+
+type option struct {
+	client *http.Client
+}
+
+func SetClient(client *http.Client) status_neko.Option[*option] {
+	return func(o *option) {
+		o.client = client
+	}
+}
+
+// WeCom sends markdown messages to a WeCom (企业微信) group robot.
+type WeCom struct {
+	config   Config
+	option   *option
+	template *template.Template
+}
+
+func NewWeCom(config Config, opts ...status_neko.Option[*option]) (*WeCom, error) {
+	o := &option{
+		client: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if config.Webhook == "" {
+		return nil, fmt.Errorf("wecom webhook is required")
+	}
+	if config.Template == "" {
+		config.Template = DefaultTemplate
+	}
+	tmpl, err := status_neko.ParseTemplate(notifierWeComName, config.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	return &WeCom{
+		config:   config,
+		option:   o,
+		template: tmpl,
+	}, nil
+}
+
+func (w *WeCom) Name() string {
+	return notifierWeComName
+}
+
+func (w *WeCom) Notify(ctx context.Context, n status_neko.Notification) error {
+	content, err := status_neko.ExecuteTemplate(w.template, n)
+	if err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"content": content,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.option.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("wecom returned %s: %w", resp.Status, err)
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("wecom returned errcode %d: %s", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package wecom
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var notification = status_neko.Notification{
+	Monitor:       "db",
+	Provider:      "mysql",
+	State:         status_neko.StateDown,
+	PreviousState: status_neko.StateUp,
+	Error:         "failed to ping database",
+	Latency:       5 * time.Second,
+	Time:          time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC),
+}
+
+func TestWeCom_Notify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key", r.URL.Query().Get("key"))
+
+		var body struct {
+			MsgType  string `json:"msgtype"`
+			Markdown struct {
+				Content string `json:"content"`
+			} `json:"markdown"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "markdown", body.MsgType)
+		assert.Contains(t, body.Markdown.Content, `### db <font color="warning">DOWN</font>`)
+		assert.Contains(t, body.Markdown.Content, `> 延迟: <font color="comment">5s</font>`)
+		assert.Contains(t, body.Markdown.Content, `> 错误: <font color="warning">failed to ping database</font>`)
+
+		w.Write([]byte(`{"errcode": 0, "errmsg": "ok"}`))
+	}))
+	defer server.Close()
+
+	n, err := status_neko.BuildNotifier([]byte(`{"type": "wecom", "webhook": "` + server.URL + `/cgi-bin/webhook/send?key=key"}`))
+	require.NoError(t, err)
+	assert.Equal(t, notifierWeComName, n.Name())
+	require.NoError(t, n.Notify(context.Background(), notification))
+}
+
+func TestWeCom_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errcode": 93000, "errmsg": "invalid webhook url"}`))
+	}))
+	defer server.Close()
+
+	w, err := NewWeCom(Config{Webhook: server.URL})
+	require.NoError(t, err)
+	assert.EqualError(t, w.Notify(context.Background(), notification), "wecom returned errcode 93000: invalid webhook url")
+
+	_, err = NewWeCom(Config{Webhook: server.URL, Template: "{{"})
+	assert.Error(t, err)
+}
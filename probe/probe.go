@@ -0,0 +1,271 @@
+// Package probe serves a /probe endpoint compatible with the Prometheus
+// blackbox_exporter: every scrape runs one check against the target.
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/songzhibin97/status-neko/metrics"
+)
+
+var (
+	_ http.Handler = (*Handler)(nil)
+
+	defaultModule  = "http_2xx"
+	defaultTimeout = 10 * time.Second
+
+	scrapeTimeoutHeader = "X-Prometheus-Scrape-Timeout-Seconds"
+)
+
+// Module is a provider config without its target, the target of each
+// probe is filled in by the TargetFunc of the type.
+//
+//	{"type": "http", "method": "GET", "timeout": "5s"}
+type Module struct {
+	Type    string               `json:"type"`
+	Timeout status_neko.Duration `json:"timeout"`
+
+	// Config is the raw JSON object the module was decoded from.
+	Config json.RawMessage `json:"-"`
+}
+
+func (m *Module) UnmarshalJSON(b []byte) error {
+	type module Module
+	var v module
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*m = Module(v)
+	m.Config = append(json.RawMessage(nil), b...)
+	return nil
+}
+
+// TargetFunc writes the target of a probe into the provider config.
+type TargetFunc func(target string, config map[string]interface{}) error
+
+// DefaultTargets maps the provider types to their TargetFunc:
+//
+//	http, grpc  target is the url, http:// is added when there is no scheme
+//	tcp         target is host:port
+//	icmp        target is the host
+//	dns         target is the server (host or host:port), the module sets the queried host
+var DefaultTargets = map[string]TargetFunc{
+	"http": func(target string, config map[string]interface{}) error {
+		if !strings.Contains(target, "://") {
+			target = "http://" + target
+		}
+		config["url"] = target
+		return nil
+	},
+	"grpc": func(target string, config map[string]interface{}) error {
+		config["url"] = target
+		return nil
+	},
+	"tcp": func(target string, config map[string]interface{}) error {
+		host, port, err := splitHostPort(target)
+		if err != nil {
+			return err
+		}
+		if port == 0 {
+			return fmt.Errorf("target %q has no port", target)
+		}
+		config["host"], config["port"] = host, port
+		return nil
+	},
+	"icmp": func(target string, config map[string]interface{}) error {
+		config["host"] = target
+		return nil
+	},
+	"dns": func(target string, config map[string]interface{}) error {
+		host, port, err := splitHostPort(target)
+		if err != nil {
+			return err
+		}
+		config["parse_server"] = host
+		if port != 0 {
+			config["port"] = port
+		}
+		return nil
+	},
+}
+
+type option struct {
+	targets       map[string]TargetFunc
+	timeoutOffset time.Duration
+}
+
+// SetTarget sets how the target is applied to the config of a provider
+// type, e.g. for a custom provider.
+func SetTarget(typ string, fn TargetFunc) status_neko.Option[*option] {
+	return func(o *option) {
+		o.targets[typ] = fn
+	}
+}
+
+// SetTimeoutOffset is subtracted from the scrape timeout so that the probe
+// answers before Prometheus gives up, 0.5s by default like blackbox_exporter.
+func SetTimeoutOffset(offset time.Duration) status_neko.Option[*option] {
+	return func(o *option) {
+		o.timeoutOffset = offset
+	}
+}
+
+// Handler serves /probe?target=...&module=..., the module defaults to http_2xx.
+type Handler struct {
+	modules map[string]Module
+	option  *option
+}
+
+func NewHandler(modules map[string]Module, opts ...status_neko.Option[*option]) *Handler {
+	o := &option{
+		targets:       make(map[string]TargetFunc, len(DefaultTargets)),
+		timeoutOffset: 500 * time.Millisecond,
+	}
+	for typ, fn := range DefaultTargets {
+		o.targets[typ] = fn
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &Handler{
+		modules: modules,
+		option:  o,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	target := query.Get("target")
+	if target == "" {
+		http.Error(w, "Target parameter is missing", http.StatusBadRequest)
+		return
+	}
+	name := query.Get("module")
+	if name == "" {
+		name = defaultModule
+	}
+	module, ok := h.modules[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("Unknown module %q", name), http.StatusBadRequest)
+		return
+	}
+
+	monitor, err := h.build(module, target)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to build module %q for target %q: %s", name, target, err), http.StatusBadRequest)
+		return
+	}
+	timeout, err := h.timeout(r, module)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// 每次探测都是新的监控项, 结束后释放它的连接
+	if err := status_neko.Start(monitor); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to start module %q for target %q: %s", name, target, err), http.StatusBadRequest)
+		return
+	}
+	defer status_neko.Stop(monitor)
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	start := time.Now()
+	result, err := monitor.Check(ctx)
+	duration := time.Since(start)
+	result = status_neko.Complete(result, err, start)
+
+	var b bytes.Buffer
+	success := 1.0
+	if result.Status == status_neko.StatusDown {
+		success = 0
+	}
+	gauge(&b, "probe_success", "Displays whether or not the probe was a success", success)
+	gauge(&b, "probe_duration_seconds", "Returns how long the probe took to complete in seconds", duration.Seconds())
+
+	if m, ok := result.Details.(status_neko.Measurer); ok {
+		measurements := m.Measurements()
+		keys := make([]string, 0, len(measurements))
+		for key := range measurements {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			gauge(&b, metrics.Sanitize("probe_"+monitor.Name()+"_"+key), fmt.Sprintf("%s reported by the %s provider", key, monitor.Name()), measurements[key])
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(b.Bytes())
+}
+
+// build creates the monitor of module with target filled in.
+func (h *Handler) build(module Module, target string) (status_neko.Monitor, error) {
+	config := make(map[string]interface{})
+	if len(module.Config) > 0 {
+		if err := json.Unmarshal(module.Config, &config); err != nil {
+			return nil, err
+		}
+	}
+
+	fn, ok := h.option.targets[module.Type]
+	if !ok {
+		return nil, fmt.Errorf("type %q does not support targets", module.Type)
+	}
+	if err := fn(target, config); err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	return status_neko.Build(raw)
+}
+
+// timeout is the scrape timeout minus the offset, capped by the module timeout.
+func (h *Handler) timeout(r *http.Request, module Module) (time.Duration, error) {
+	timeout := defaultTimeout
+	if v := r.Header.Get(scrapeTimeoutHeader); v != "" {
+		seconds, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse timeout from Prometheus header: %w", err)
+		}
+		timeout = time.Duration(seconds * float64(time.Second))
+		if timeout > h.option.timeoutOffset {
+			timeout -= h.option.timeoutOffset
+		}
+	}
+	if module.Timeout > 0 && time.Duration(module.Timeout) < timeout {
+		timeout = time.Duration(module.Timeout)
+	}
+	return timeout, nil
+}
+
+func gauge(b *bytes.Buffer, name, help string, value float64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", name, help, name, name, metrics.FormatValue(value))
+}
+
+// splitHostPort accepts a host with or without a port.
+func splitHostPort(target string) (string, int, error) {
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		// 没有端口
+		return strings.Trim(target, "[]"), 0, nil
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port in target %q", target)
+	}
+	return host, n, nil
+}
@@ -0,0 +1,182 @@
+package probe
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	_ "github.com/songzhibin97/status-neko/provide/http"
+	_ "github.com/songzhibin97/status-neko/provide/tcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func modules(t *testing.T) map[string]Module {
+	var m map[string]Module
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"http_2xx": {"type": "http", "method": "GET", "timeout": "5s"},
+		"tcp_connect": {"type": "tcp"},
+		"broken": {"type": "nope"}
+	}`), &m))
+	return m
+}
+
+func probe(t *testing.T, h http.Handler, query url.Values, header http.Header) (int, string) {
+	req := httptest.NewRequest(http.MethodGet, "/probe?"+query.Encode(), nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	b, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return rec.Code, string(b)
+}
+
+func TestHandler_HTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	h := NewHandler(modules(t))
+	code, body := probe(t, h, url.Values{"target": {server.URL}}, nil)
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Contains(t, body, "# TYPE probe_success gauge\nprobe_success 1\n")
+	assert.Contains(t, body, "# TYPE probe_duration_seconds gauge\nprobe_duration_seconds ")
+	assert.Contains(t, body, "probe_http_status_code 200\n")
+	assert.Contains(t, body, "probe_http_content_length 5\n")
+
+	// 没有协议时默认使用 http
+	code, body = probe(t, h, url.Values{"target": {server.Listener.Addr().String()}, "module": {"http_2xx"}}, nil)
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Contains(t, body, "probe_success 1\n")
+}
+
+func TestHandler_CloseIdleConnections(t *testing.T) {
+	closed := make(chan struct{}, 16)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			closed <- struct{}{}
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	// 探测结束后不保留空闲的长连接
+	h := NewHandler(modules(t))
+	for i := 0; i < 3; i++ {
+		code, body := probe(t, h, url.Values{"target": {server.URL}}, nil)
+		require.Equal(t, http.StatusOK, code, body)
+		select {
+		case <-closed:
+		case <-time.After(time.Second):
+			t.Fatal("connection of the probe was kept open")
+		}
+	}
+}
+
+func TestHandler_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	h := NewHandler(modules(t))
+	code, body := probe(t, h, url.Values{"target": {ln.Addr().String()}, "module": {"tcp_connect"}}, nil)
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Contains(t, body, "probe_success 1\n")
+
+	// 检测失败时仍返回 200
+	addr := ln.Addr().String()
+	ln.Close()
+	code, body = probe(t, h, url.Values{"target": {addr}, "module": {"tcp_connect"}}, nil)
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Contains(t, body, "probe_success 0\n")
+}
+
+func TestHandler_Error(t *testing.T) {
+	h := NewHandler(modules(t))
+
+	code, body := probe(t, h, url.Values{}, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body, "Target parameter is missing")
+
+	code, body = probe(t, h, url.Values{"target": {"example.com"}, "module": {"missing"}}, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body, `Unknown module "missing"`)
+
+	code, body = probe(t, h, url.Values{"target": {"example.com"}, "module": {"broken"}}, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body, `type "nope" does not support targets`)
+
+	code, body = probe(t, h, url.Values{"target": {"example.com"}, "module": {"tcp_connect"}}, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body, "has no port")
+
+	code, body = probe(t, h, url.Values{"target": {"example.com"}}, http.Header{scrapeTimeoutHeader: {"soon"}})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body, "failed to parse timeout")
+}
+
+func TestHandler_Timeout(t *testing.T) {
+	h := NewHandler(nil)
+	m := modules(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/probe", nil)
+	timeout, err := h.timeout(req, m["tcp_connect"])
+	require.NoError(t, err)
+	assert.Equal(t, defaultTimeout, timeout)
+
+	req.Header.Set(scrapeTimeoutHeader, "3")
+	timeout, err = h.timeout(req, m["tcp_connect"])
+	require.NoError(t, err)
+	assert.Equal(t, 2500*time.Millisecond, timeout)
+
+	// 模块的超时更短时优先
+	req.Header.Set(scrapeTimeoutHeader, "10")
+	timeout, err = h.timeout(req, m["http_2xx"])
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, timeout)
+}
+
+func TestDefaultTargets(t *testing.T) {
+	config := map[string]interface{}{"host": "example.com"}
+	require.NoError(t, DefaultTargets["dns"]("8.8.8.8:5353", config))
+	assert.Equal(t, map[string]interface{}{"host": "example.com", "parse_server": "8.8.8.8", "port": 5353}, config)
+
+	config = map[string]interface{}{}
+	require.NoError(t, DefaultTargets["dns"]("[2001:4860:4860::8888]", config))
+	assert.Equal(t, "2001:4860:4860::8888", config["parse_server"])
+
+	config = map[string]interface{}{}
+	require.NoError(t, DefaultTargets["icmp"]("example.com", config))
+	assert.Equal(t, "example.com", config["host"])
+
+	config = map[string]interface{}{}
+	require.NoError(t, DefaultTargets["http"]("https://example.com", config))
+	assert.Equal(t, "https://example.com", config["url"])
+
+	assert.Error(t, DefaultTargets["tcp"]("example.com:http", map[string]interface{}{}))
+
+	h := NewHandler(nil, SetTarget("redis", func(target string, config map[string]interface{}) error {
+		config["addr"] = target
+		return nil
+	}))
+	assert.Contains(t, h.option.targets, "redis")
+	assert.Contains(t, h.option.targets, "http")
+}
@@ -3,7 +3,9 @@ package certificate_expires
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
@@ -13,15 +15,46 @@ import (
 )
 
 var (
-	_                              status_neko.Monitor = (*CertificateExpires)(nil)
-	providerCertificateExpiresName                     = "certificate_expires"
+	_                              status_neko.Monitor  = (*CertificateExpires)(nil)
+	_                              status_neko.Measurer = (*Details)(nil)
+	providerCertificateExpiresName                      = "certificate_expires"
 )
 
+func init() {
+	status_neko.Register(providerCertificateExpiresName, func(raw json.RawMessage) (status_neko.Monitor, error) {
+		var c struct {
+			URL string `json:"url"`
+		}
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return nil, err
+		}
+		return NewCertificateExpires(c.URL), nil
+	})
+}
+
 type CertificateExpires struct {
 	url    string
 	option *option
 }
 
+// Details is the Result.Details of a certificate check.
+type Details struct {
+	Domain   string    `json:"domain"`
+	NotAfter time.Time `json:"not_after"`
+}
+
+// DaysLeft returns the number of days until the certificate expires.
+func (d Details) DaysLeft(now time.Time) float64 {
+	return d.NotAfter.Sub(now).Hours() / 24
+}
+
+func (d *Details) Measurements() map[string]float64 {
+	return map[string]float64{
+		"days_left":                   d.DaysLeft(time.Now()),
+		"not_after_timestamp_seconds": float64(d.NotAfter.Unix()),
+	}
+}
+
 type option struct {
 	client HTTPClient
 }
@@ -61,13 +94,24 @@ func (c CertificateExpires) Name() string {
 	return providerCertificateExpiresName
 }
 
-func (c CertificateExpires) Check(ctx context.Context) (interface{}, error) {
+func (c CertificateExpires) Check(ctx context.Context) (*status_neko.Result, error) {
+	start := time.Now()
+
 	domain, err := getDomainFromURL(c.url)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.getCertExpiry(domain)
+	notAfter, err := c.getCertExpiry(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &Details{
+		Domain:   domain,
+		NotAfter: notAfter,
+	}
+	return status_neko.NewResult(status_neko.StatusUp, start, fmt.Sprintf("certificate expires in %.0f days", details.DaysLeft(start)), details), nil
 }
 
 func (c CertificateExpires) getCertExpiry(domain string) (time.Time, error) {
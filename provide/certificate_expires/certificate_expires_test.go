@@ -53,15 +53,24 @@ func TestCertificateExpires(t *testing.T) {
 		t.Fatalf("Check 方法返回错误：%v", err)
 	}
 
-	expiry, ok := result.(time.Time)
+	details, ok := result.Details.(*Details)
 	if !ok {
-		t.Fatalf("期望结果类型为 time.Time，实际得到 %T", result)
+		t.Fatalf("期望结果类型为 *Details，实际得到 %T", result.Details)
 	}
 
 	// 验证返回的过期时间是否正确
 	expectedExpiry := cert.Leaf.NotAfter
-	if !expiry.Equal(expectedExpiry) {
-		t.Errorf("期望过期时间为 %v，实际得到 %v", expectedExpiry, expiry)
+	if !details.NotAfter.Equal(expectedExpiry) {
+		t.Errorf("期望过期时间为 %v，实际得到 %v", expectedExpiry, details.NotAfter)
+	}
+	if details.Domain != "example.com" {
+		t.Errorf("期望域名为 example.com，实际得到 %s", details.Domain)
+	}
+	if days := details.DaysLeft(time.Now()); days <= 0 || days > 1 {
+		t.Errorf("期望剩余天数在 (0, 1] 之间，实际得到 %v", days)
+	}
+	if days := details.Measurements()["days_left"]; days <= 0 || days > 1 {
+		t.Errorf("期望 days_left 在 (0, 1] 之间，实际得到 %v", days)
 	}
 
 	// 测试 getDomainFromURL 函数
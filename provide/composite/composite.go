@@ -0,0 +1,305 @@
+package composite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+// Mode decides the status of a composite from the status of its children.
+type Mode string
+
+var (
+	_ status_neko.Monitor  = (*Composite)(nil)
+	_ status_neko.Starter  = (*Composite)(nil)
+	_ status_neko.Measurer = (*Details)(nil)
+
+	providerCompositeName = "composite"
+
+	// ModeAll is UP when every child is healthy.
+	ModeAll Mode = "all"
+	// ModeAny is UP when at least one child is healthy.
+	ModeAny Mode = "any"
+	// ModeQuorum is UP when at least Quorum children are healthy.
+	ModeQuorum Mode = "quorum"
+	// ModeWeighted is UP when the healthy children carry at least Threshold
+	// of the total weight.
+	ModeWeighted Mode = "weighted"
+
+	defaultThreshold = 0.5
+)
+
+func init() {
+	status_neko.Register(providerCompositeName, func(raw json.RawMessage) (status_neko.Monitor, error) {
+		var config Config
+		if err := json.Unmarshal(raw, &config); err != nil {
+			return nil, err
+		}
+		return NewComposite(config)
+	})
+}
+
+// Config aggregates child monitors. A child is healthy when it is UP or
+// DEGRADED, the composite is DOWN when the rule of Mode is not met, UP when
+// every child is UP and DEGRADED otherwise.
+//
+//	{"type": "composite", "mode": "quorum", "quorum": 2, "children": [
+//		{"name": "replica-1", "type": "http", "url": "http://10.0.0.1/health"},
+//		{"name": "replica-2", "type": "http", "url": "http://10.0.0.2/health"},
+//		{"name": "replica-3", "type": "http", "url": "http://10.0.0.3/health"}
+//	]}
+type Config struct {
+	Mode Mode `json:"mode"` // 默认 all
+	// Quorum 是 quorum 模式下至少需要健康的子监控项数量
+	Quorum int `json:"quorum"`
+	// Threshold 是 weighted 模式下健康的子监控项至少占有的权重比例, 默认 0.5
+	Threshold float64 `json:"threshold"`
+	Children  []Child `json:"children"`
+}
+
+// Child is a monitor of a composite: its name, weight and timeout next to
+// the provider config understood by status_neko.Build.
+type Child struct {
+	Name string `json:"name"`
+	// Weight 只在 weighted 模式下使用, 默认 1
+	Weight  float64              `json:"weight"`
+	Timeout status_neko.Duration `json:"timeout"`
+
+	// Config is the raw JSON object the child was decoded from.
+	Config json.RawMessage `json:"-"`
+}
+
+func (c *Child) UnmarshalJSON(b []byte) error {
+	type child Child
+	var v child
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*c = Child(v)
+	c.Config = append(json.RawMessage(nil), b...)
+	return nil
+}
+
+// ChildResult is the outcome of a child in Details.
+type ChildResult struct {
+	Name     string              `json:"name"`
+	Provider string              `json:"provider"`
+	Weight   float64             `json:"weight"`
+	Result   *status_neko.Result `json:"result"`
+	Error    string              `json:"error,omitempty"`
+}
+
+// Details is the Result.Details of a composite check.
+type Details struct {
+	Mode          Mode          `json:"mode"`
+	Healthy       int           `json:"healthy"`
+	Total         int           `json:"total"`
+	HealthyWeight float64       `json:"healthy_weight"`
+	TotalWeight   float64       `json:"total_weight"`
+	Children      []ChildResult `json:"children"`
+}
+
+func (d *Details) Measurements() map[string]float64 {
+	m := map[string]float64{
+		"healthy_children": float64(d.Healthy),
+		"children":         float64(d.Total),
+	}
+	if d.TotalWeight > 0 {
+		m["healthy_weight_ratio"] = d.HealthyWeight / d.TotalWeight
+	}
+	return m
+}
+
+type child struct {
+	Child
+	monitor status_neko.Monitor
+}
+
+type Composite struct {
+	config   Config
+	children []child
+}
+
+// NewComposite builds the children, the errors point to the invalid fields,
+// e.g. "children[1].url".
+func NewComposite(config Config) (*Composite, error) {
+	if config.Mode == "" {
+		config.Mode = ModeAll
+	}
+	if config.Threshold == 0 {
+		config.Threshold = defaultThreshold
+	}
+
+	var fields status_neko.FieldErrors
+	invalid := func(field, format string, args ...interface{}) {
+		fields = append(fields, &status_neko.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch config.Mode {
+	case ModeAll, ModeAny:
+	case ModeQuorum:
+		if config.Quorum <= 0 || config.Quorum > len(config.Children) {
+			invalid("quorum", "must be between 1 and the number of children")
+		}
+	case ModeWeighted:
+		if config.Threshold < 0 || config.Threshold > 1 {
+			invalid("threshold", "must be between 0 and 1")
+		}
+	default:
+		invalid("mode", "unknown mode %q", config.Mode)
+	}
+	if len(config.Children) == 0 {
+		invalid("children", "is required")
+	}
+
+	c := &Composite{config: config}
+	names := make(map[string]bool, len(config.Children))
+	for i, ch := range config.Children {
+		prefix := fmt.Sprintf("children[%d]", i)
+		if ch.Name == "" {
+			invalid(prefix+".name", "is required")
+		} else if names[ch.Name] {
+			invalid(prefix+".name", "duplicate child %s", ch.Name)
+		}
+		names[ch.Name] = true
+
+		if ch.Weight < 0 {
+			invalid(prefix+".weight", "must not be negative")
+		}
+		if ch.Weight == 0 {
+			ch.Weight = 1
+		}
+
+		m, err := status_neko.Build(ch.Config)
+		if err != nil {
+			if errs := status_neko.Fields(err); len(errs) > 0 {
+				for _, e := range errs {
+					invalid(prefix+"."+e.Field, "%s", e.Message)
+				}
+			} else {
+				invalid(prefix, "%v", err)
+			}
+			continue
+		}
+		c.children = append(c.children, child{Child: ch, monitor: m})
+	}
+	if len(fields) > 0 {
+		return nil, fields
+	}
+	return c, nil
+}
+
+func (c *Composite) Name() string {
+	return providerCompositeName
+}
+
+// Start starts the children, e.g. registers the token of a push child.
+func (c *Composite) Start() error {
+	for i, ch := range c.children {
+		if err := status_neko.Start(ch.monitor); err != nil {
+			for _, started := range c.children[:i] {
+				status_neko.Stop(started.monitor)
+			}
+			return fmt.Errorf("children[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (c *Composite) Stop() {
+	for _, ch := range c.children {
+		status_neko.Stop(ch.monitor)
+	}
+}
+
+// Check runs all children concurrently and combines their results.
+func (c *Composite) Check(ctx context.Context) (*status_neko.Result, error) {
+	start := time.Now()
+
+	details := &Details{
+		Mode:     c.config.Mode,
+		Total:    len(c.children),
+		Children: make([]ChildResult, len(c.children)),
+	}
+	var wg sync.WaitGroup
+	for i, ch := range c.children {
+		wg.Add(1)
+		go func(i int, ch child) {
+			defer wg.Done()
+			details.Children[i] = check(ctx, ch)
+		}(i, ch)
+	}
+	wg.Wait()
+
+	allUp := true
+	var failed []string
+	for _, r := range details.Children {
+		details.TotalWeight += r.Weight
+		switch r.Result.Status {
+		case status_neko.StatusUp:
+			details.Healthy++
+			details.HealthyWeight += r.Weight
+		case status_neko.StatusDegraded:
+			details.Healthy++
+			details.HealthyWeight += r.Weight
+			allUp = false
+		default:
+			allUp = false
+			failed = append(failed, fmt.Sprintf("%s: %s", r.Name, r.Result.Message))
+		}
+	}
+
+	status := status_neko.StatusDown
+	if c.met(details) {
+		status = status_neko.StatusDegraded
+		if allUp {
+			status = status_neko.StatusUp
+		}
+	}
+
+	message := fmt.Sprintf("%d/%d children healthy", details.Healthy, details.Total)
+	if len(failed) > 0 {
+		message += "; " + strings.Join(failed, "; ")
+	}
+	return status_neko.NewResult(status, start, message, details), nil
+}
+
+// met reports whether enough children are healthy for the mode.
+func (c *Composite) met(d *Details) bool {
+	switch c.config.Mode {
+	case ModeAny:
+		return d.Healthy >= 1
+	case ModeQuorum:
+		return d.Healthy >= c.config.Quorum
+	case ModeWeighted:
+		return d.TotalWeight > 0 && d.HealthyWeight/d.TotalWeight >= c.config.Threshold
+	default:
+		return d.Healthy == d.Total
+	}
+}
+
+func check(ctx context.Context, ch child) ChildResult {
+	if ch.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(ch.Timeout))
+		defer cancel()
+	}
+
+	start := time.Now()
+	result, err := ch.monitor.Check(ctx)
+	r := ChildResult{
+		Name:     ch.Name,
+		Provider: ch.monitor.Name(),
+		Weight:   ch.Weight,
+		Result:   status_neko.Complete(result, err, start),
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
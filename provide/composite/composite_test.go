@@ -0,0 +1,168 @@
+package composite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/songzhibin97/status-neko/provide/push"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeConfig struct {
+	Status status_neko.Status   `json:"status"`
+	Delay  status_neko.Duration `json:"delay"`
+}
+
+type fake struct {
+	config fakeConfig
+}
+
+func (f fake) Name() string {
+	return "fake"
+}
+
+func (f fake) Check(ctx context.Context) (*status_neko.Result, error) {
+	start := time.Now()
+	select {
+	case <-time.After(time.Duration(f.config.Delay)):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if f.config.Status == status_neko.StatusDown {
+		return nil, errors.New("boom")
+	}
+	return status_neko.NewResult(f.config.Status, start, "", nil), nil
+}
+
+func init() {
+	status_neko.Register("fake", status_neko.NewFactory(func(c fakeConfig) status_neko.Monitor {
+		return fake{config: c}
+	}))
+}
+
+func build(t *testing.T, raw string) *Composite {
+	m, err := status_neko.Build(json.RawMessage(raw))
+	require.NoError(t, err)
+	return m.(*Composite)
+}
+
+func TestComposite_Check(t *testing.T) {
+	children := func(statuses ...string) string {
+		var b []byte
+		for i, s := range statuses {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = append(b, `{"name": "c`+string(rune('0'+i))+`", "type": "fake", "status": "`+s+`", "weight": `+string(rune('1'+i))+`}`...)
+		}
+		return "[" + string(b) + "]"
+	}
+
+	tests := []struct {
+		name     string
+		config   string
+		statuses []string
+		want     status_neko.Status
+	}{
+		{"all up", `"mode": "all"`, []string{"up", "up", "up"}, status_neko.StatusUp},
+		{"all degraded", `"mode": "all"`, []string{"up", "degraded", "up"}, status_neko.StatusDegraded},
+		{"all down", `"mode": "all"`, []string{"up", "down", "up"}, status_neko.StatusDown},
+		{"default all", ``, []string{"up", "down"}, status_neko.StatusDown},
+		{"any up", `"mode": "any"`, []string{"down", "down", "up"}, status_neko.StatusDegraded},
+		{"any down", `"mode": "any"`, []string{"down", "down", "down"}, status_neko.StatusDown},
+		{"quorum met", `"mode": "quorum", "quorum": 2`, []string{"up", "down", "up"}, status_neko.StatusDegraded},
+		{"quorum missed", `"mode": "quorum", "quorum": 2`, []string{"up", "down", "down"}, status_neko.StatusDown},
+		// 权重为 1, 2, 3
+		{"weighted met", `"mode": "weighted", "threshold": 0.5`, []string{"down", "down", "up"}, status_neko.StatusDegraded},
+		{"weighted higher threshold", `"mode": "weighted", "threshold": 0.6`, []string{"up", "down", "up"}, status_neko.StatusDegraded},
+		{"weighted below", `"mode": "weighted", "threshold": 0.7`, []string{"up", "down", "up"}, status_neko.StatusDown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := `{"type": "composite", "children": ` + children(tt.statuses...)
+			if tt.config != "" {
+				config += ", " + tt.config
+			}
+			c := build(t, config+"}")
+
+			result, err := c.Check(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result.Status)
+
+			details := result.Details.(*Details)
+			require.Len(t, details.Children, len(tt.statuses))
+			for i, s := range tt.statuses {
+				assert.Equal(t, status_neko.Status(s), details.Children[i].Result.Status)
+				assert.Equal(t, "fake", details.Children[i].Provider)
+			}
+		})
+	}
+}
+
+func TestComposite_Details(t *testing.T) {
+	c := build(t, `{"type": "composite", "mode": "any", "children": [
+		{"name": "fast", "type": "fake", "status": "up"},
+		{"name": "slow", "type": "fake", "status": "up", "delay": "1s", "timeout": "20ms"},
+		{"name": "broken", "type": "fake", "status": "down"}
+	]}`)
+
+	start := time.Now()
+	result, err := c.Check(context.Background())
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, status_neko.StatusDegraded, result.Status)
+	assert.Equal(t, "1/3 children healthy; slow: context deadline exceeded; broken: boom", result.Message)
+
+	details := result.Details.(*Details)
+	assert.Equal(t, 1, details.Healthy)
+	assert.Equal(t, "context deadline exceeded", details.Children[1].Error)
+	assert.Equal(t, map[string]float64{
+		"healthy_children":     1,
+		"children":             3,
+		"healthy_weight_ratio": 1.0 / 3,
+	}, details.Measurements())
+
+	b, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"name":"broken"`)
+}
+
+func TestNewComposite_Error(t *testing.T) {
+	_, err := status_neko.Build(json.RawMessage(`{"type": "composite", "mode": "quorum", "quorum": 4, "children": [
+		{"name": "a", "type": "fake"},
+		{"name": "a", "type": "fake"},
+		{"type": "nope", "weight": -1}
+	]}`))
+	require.Error(t, err)
+
+	var fields []string
+	for _, f := range status_neko.Fields(err) {
+		fields = append(fields, f.Field)
+	}
+	assert.Equal(t, []string{"quorum", "children[1].name", "children[2].name", "children[2].weight", "children[2]"}, fields)
+
+	_, err = status_neko.Build(json.RawMessage(`{"type": "composite", "mode": "majority"}`))
+	fields = nil
+	for _, f := range status_neko.Fields(err) {
+		fields = append(fields, f.Field)
+	}
+	assert.Equal(t, []string{"mode", "children"}, fields)
+}
+
+func TestComposite_Start(t *testing.T) {
+	m, err := status_neko.Build([]byte(`{"type": "composite", "children": [
+		{"name": "job", "type": "push", "token": "composite-job"}
+	]}`))
+	require.NoError(t, err)
+	assert.ErrorIs(t, push.DefaultHub.Push("composite-job", push.Ping{}), push.ErrUnknownToken)
+
+	require.NoError(t, status_neko.Start(m))
+	assert.NoError(t, push.DefaultHub.Push("composite-job", push.Ping{Signal: push.SignalSuccess}))
+	status_neko.Stop(m)
+	assert.ErrorIs(t, push.DefaultHub.Push("composite-job", push.Ping{}), push.ErrUnknownToken)
+}
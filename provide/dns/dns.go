@@ -14,8 +14,9 @@ import (
 type ResourceType string
 
 var (
-	_               status_neko.Monitor = (*DNS)(nil)
-	providerDNSName                     = "dns"
+	_               status_neko.Monitor  = (*DNS)(nil)
+	_               status_neko.Measurer = (*Details)(nil)
+	providerDNSName                      = "dns"
 
 	ResourceTypeA     ResourceType = "A"
 	ResourceTypeAAAA  ResourceType = "AAAA"
@@ -29,6 +30,12 @@ var (
 	ResourceTypeTXT   ResourceType = "TXT"
 )
 
+func init() {
+	status_neko.Register(providerDNSName, status_neko.NewFactory(func(c Config) status_neko.Monitor {
+		return NewDNS(c)
+	}))
+}
+
 type Config struct {
 	Host         string       `json:"host"`
 	Port         int          `json:"port"`
@@ -36,6 +43,37 @@ type Config struct {
 	ResourceType ResourceType `json:"resource_type"` // 资源类型
 }
 
+func (c *Config) Validate() error {
+	var fields status_neko.FieldErrors
+	if c.Host == "" {
+		fields = append(fields, &status_neko.FieldError{Field: "host", Message: "is required"})
+	}
+	if c.Port < 0 || c.Port > 65535 {
+		fields = append(fields, &status_neko.FieldError{Field: "port", Message: "must be between 1 and 65535"})
+	}
+	if len(fields) > 0 {
+		return fields
+	}
+	return nil
+}
+
+// Details is the Result.Details of a DNS check.
+type Details struct {
+	Host           string        `json:"host"`
+	ParseServer    string        `json:"parse_server"`
+	ResourceType   ResourceType  `json:"resource_type"`
+	RTT            time.Duration `json:"rtt"`
+	ResolutionTime time.Duration `json:"resolution_time"`
+	Answers        []string      `json:"answers"`
+}
+
+func (d *Details) Measurements() map[string]float64 {
+	return map[string]float64{
+		"lookup_time_seconds": d.RTT.Seconds(),
+		"answer_rrs":          float64(len(d.Answers)),
+	}
+}
+
 type DNS struct {
 	config Config
 }
@@ -50,7 +88,7 @@ func (d DNS) Name() string {
 	return providerDNSName
 }
 
-func (d DNS) Check(ctx context.Context) (interface{}, error) {
+func (d DNS) Check(ctx context.Context) (*status_neko.Result, error) {
 	if d.config.Port == 0 {
 		d.config.Port = 53 // 使用默认 DNS 端口
 	}
@@ -60,7 +98,7 @@ func (d DNS) Check(ctx context.Context) (interface{}, error) {
 	}
 
 	c := new(dns.Client)
-	c.Timeout = 5 * time.Second // 设置超时
+	c.Timeout = status_neko.Remaining(ctx) // 读写超时跟随 ctx 的 deadline, 没有 deadline 时为 5s
 
 	m := new(dns.Msg)
 	m.SetQuestion(dns.Fqdn(d.config.Host), resourceTypeToInt(d.config.ResourceType))
@@ -79,19 +117,21 @@ func (d DNS) Check(ctx context.Context) (interface{}, error) {
 		return nil, fmt.Errorf("no DNS answers for host: %s", d.config.Host)
 	}
 
-	result := map[string]interface{}{
-		"host":            d.config.Host,
-		"parse_server":    d.config.ParseServer,
-		"resource_type":   string(d.config.ResourceType),
-		"latency":         rtt.String(),
-		"resolution_time": time.Since(start).String(),
-		"answers":         []string{},
+	details := &Details{
+		Host:           d.config.Host,
+		ParseServer:    d.config.ParseServer,
+		ResourceType:   d.config.ResourceType,
+		RTT:            rtt,
+		ResolutionTime: time.Since(start),
+		Answers:        make([]string, 0, len(r.Answer)),
 	}
 
 	for _, ans := range r.Answer {
-		result["answers"] = append(result["answers"].([]string), ans.String())
+		details.Answers = append(details.Answers, ans.String())
 	}
 
+	result := status_neko.NewResult(status_neko.StatusUp, start, fmt.Sprintf("%d answers", len(details.Answers)), details)
+	result.Latency = rtt
 	return result, nil
 }
 
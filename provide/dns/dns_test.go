@@ -78,20 +78,19 @@ func TestDNSCheck(t *testing.T) {
 
 			// 如果没有错误，检查返回结果
 			if err == nil {
-				resultMap, ok := result.(map[string]interface{})
+				details, ok := result.Details.(*Details)
 				if !ok {
-					t.Errorf("result should be a map, got: %T", result)
+					t.Fatalf("result details should be *Details, got: %T", result.Details)
 				}
 
 				// 检查结果中主机名是否正确
-				if resultMap["host"] != tt.expectedHost {
-					t.Errorf("expected host: %s, got: %s", tt.expectedHost, resultMap["host"])
+				if details.Host != tt.expectedHost {
+					t.Errorf("expected host: %s, got: %s", tt.expectedHost, details.Host)
 				}
 
 				// 检查结果是否包含有效的答案（仅在非错误情况）
-				answers, ok := resultMap["answers"].([]string)
-				if !ok || len(answers) == 0 {
-					t.Errorf("expected some answers, got: %v", answers)
+				if len(details.Answers) == 0 {
+					t.Errorf("expected some answers, got: %v", details.Answers)
 				}
 			}
 		})
@@ -124,3 +123,11 @@ func TestResourceTypeToInt(t *testing.T) {
 		})
 	}
 }
+
+func TestDetails_Measurements(t *testing.T) {
+	d := &Details{RTT: 15 * time.Millisecond, Answers: []string{"1.1.1.1", "1.0.0.1"}}
+	assert.Equal(t, map[string]float64{
+		"lookup_time_seconds": 0.015,
+		"answer_rrs":          2,
+	}, d.Measurements())
+}
@@ -25,6 +25,12 @@ var (
 	providerGrpcName                     = "grpc"
 )
 
+func init() {
+	status_neko.Register(providerGrpcName, status_neko.NewFactory(func(c Config) status_neko.Monitor {
+		return NewGrpc(c)
+	}))
+}
+
 type options struct {
 	dialer func(context.Context, string) (net.Conn, error)
 }
@@ -39,6 +45,14 @@ type Config struct {
 	Metadata         map[string]string `json:"metadata"`
 }
 
+// Details is the Result.Details of a gRPC check.
+type Details struct {
+	URL          string        `json:"url"`
+	Method       string        `json:"method"`
+	Response     interface{}   `json:"response"` // 响应的 JSON 对象
+	ResponseTime time.Duration `json:"response_time"`
+}
+
 type Grpc struct {
 	config Config
 
@@ -67,7 +81,7 @@ func (g Grpc) Name() string {
 	return providerGrpcName
 }
 
-func (g Grpc) Check(ctx context.Context) (interface{}, error) {
+func (g Grpc) Check(ctx context.Context) (*status_neko.Result, error) {
 	// 1. Parse the ProtoContent directly without writing to a file
 	parser := protoparse.Parser{
 		Accessor: protoparse.FileContentsFromMap(g.config.ProtoContents),
@@ -149,12 +163,10 @@ func (g Grpc) Check(ctx context.Context) (interface{}, error) {
 		return nil, fmt.Errorf("响应不是有效的 JSON: %w", err)
 	}
 
-	result := map[string]interface{}{
-		"url":           g.config.URL,
-		"method":        g.config.ProtoMethod,
-		"response":      jsonObj,
-		"response_time": time.Since(start),
-	}
-
-	return result, nil
+	return status_neko.NewResult(status_neko.StatusUp, start, "", &Details{
+		URL:          g.config.URL,
+		Method:       g.config.ProtoMethod,
+		Response:     jsonObj,
+		ResponseTime: time.Since(start),
+	}), nil
 }
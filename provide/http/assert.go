@@ -0,0 +1,343 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+// Operator compares the values selected by a JSONPathAssertion.
+type Operator string
+
+var (
+	OperatorEqual          Operator = "=="
+	OperatorNotEqual       Operator = "!="
+	OperatorGreater        Operator = ">"
+	OperatorGreaterOrEqual Operator = ">="
+	OperatorLess           Operator = "<"
+	OperatorLessOrEqual    Operator = "<="
+	OperatorContains       Operator = "contains"
+	OperatorExists         Operator = "exists"
+
+	defaultAcceptedStatusCodes = []string{"200-299"}
+
+	// maxActual 限制报告中实际值的长度, 避免把整个响应体放进消息
+	maxActual = 100
+)
+
+// JSONPathAssertion checks the values selected by Path in a JSON response,
+// every selected value has to pass, e.g.
+//
+//	{"path": "$.checks[*].status", "value": "ok"}
+//	{"path": "$.queue.size", "operator": "<", "value": 1000}
+type JSONPathAssertion struct {
+	Path string `json:"path"`
+	// Operator 默认为 ==, exists 只要求 Path 存在
+	Operator Operator    `json:"operator"`
+	Value    interface{} `json:"value"`
+}
+
+// AssertionError is a failed assertion with the actual value of the response.
+type AssertionError struct {
+	Assertion string `json:"assertion"`
+	Expected  string `json:"expected"`
+	Actual    string `json:"actual"`
+}
+
+func (e *AssertionError) Error() string {
+	return fmt.Sprintf("%s: expected %s, got %s", e.Assertion, e.Expected, e.Actual)
+}
+
+// AssertionErrors are the failed assertions of a check.
+type AssertionErrors []*AssertionError
+
+func (e AssertionErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+type statusRange struct {
+	from, to int
+}
+
+type jsonPathAssertion struct {
+	JSONPathAssertion
+	path jsonPath
+}
+
+// assertions are the compiled assertions of a Config.
+type assertions struct {
+	statusCodes []statusRange
+	accepted    string
+	keyword     string
+	regex       *regexp.Regexp
+	invert      bool
+	jsonPaths   []jsonPathAssertion
+	headers     map[string]string
+	maxTime     time.Duration
+}
+
+func compileAssertions(c *Config) (*assertions, error) {
+	var fields status_neko.FieldErrors
+	a := &assertions{
+		keyword: c.Keyword,
+		invert:  c.InvertMatch,
+		headers: c.ResponseHeaders,
+		maxTime: time.Duration(c.MaxResponseTime),
+	}
+
+	codes := c.AcceptedStatusCodes
+	if len(codes) == 0 {
+		codes = defaultAcceptedStatusCodes
+	}
+	a.accepted = strings.Join(codes, ", ")
+	for i, code := range codes {
+		r, err := parseStatusRange(code)
+		if err != nil {
+			fields = append(fields, &status_neko.FieldError{Field: fmt.Sprintf("accepted_status_codes[%d]", i), Message: err.Error()})
+			continue
+		}
+		a.statusCodes = append(a.statusCodes, r)
+	}
+
+	if c.Regex != "" {
+		re, err := regexp.Compile(c.Regex)
+		if err != nil {
+			fields = append(fields, &status_neko.FieldError{Field: "regex", Message: err.Error()})
+		}
+		a.regex = re
+	}
+
+	for i, j := range c.JSONPath {
+		path, err := parseJSONPath(j.Path)
+		if err != nil {
+			fields = append(fields, &status_neko.FieldError{Field: fmt.Sprintf("json_path[%d].path", i), Message: err.Error()})
+		}
+		if j.Operator == "" {
+			j.Operator = OperatorEqual
+		}
+		switch j.Operator {
+		case OperatorEqual, OperatorNotEqual, OperatorContains, OperatorExists:
+		case OperatorGreater, OperatorGreaterOrEqual, OperatorLess, OperatorLessOrEqual:
+			if _, ok := number(j.Value); !ok {
+				fields = append(fields, &status_neko.FieldError{Field: fmt.Sprintf("json_path[%d].value", i), Message: "must be a number"})
+			}
+		default:
+			fields = append(fields, &status_neko.FieldError{Field: fmt.Sprintf("json_path[%d].operator", i), Message: fmt.Sprintf("unknown operator %q", j.Operator)})
+		}
+		a.jsonPaths = append(a.jsonPaths, jsonPathAssertion{JSONPathAssertion: j, path: path})
+	}
+
+	if c.MaxResponseTime < 0 {
+		fields = append(fields, &status_neko.FieldError{Field: "max_response_time", Message: "must not be negative"})
+	}
+	if len(fields) > 0 {
+		return nil, fields
+	}
+	return a, nil
+}
+
+// parseStatusRange parses "200", "200-299" or "2xx".
+func parseStatusRange(s string) (statusRange, error) {
+	invalid := fmt.Errorf("invalid status code %q, expected e.g. 200, 200-299 or 2xx", s)
+	if len(s) == 3 && strings.HasSuffix(strings.ToLower(s), "xx") && s[0] >= '1' && s[0] <= '5' {
+		from := int(s[0]-'0') * 100
+		return statusRange{from: from, to: from + 99}, nil
+	}
+
+	from, to, isRange := strings.Cut(s, "-")
+	f, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil {
+		return statusRange{}, invalid
+	}
+	t := f
+	if isRange {
+		if t, err = strconv.Atoi(strings.TrimSpace(to)); err != nil {
+			return statusRange{}, invalid
+		}
+	}
+	if f < 100 || t > 599 || f > t {
+		return statusRange{}, invalid
+	}
+	return statusRange{from: f, to: t}, nil
+}
+
+// check returns the failed assertions of a response.
+func (a *assertions) check(statusCode int, header http.Header, body []byte, latency time.Duration) AssertionErrors {
+	var failures AssertionErrors
+	fail := func(assertion, expected, actual string) {
+		failures = append(failures, &AssertionError{Assertion: assertion, Expected: expected, Actual: actual})
+	}
+
+	accepted := false
+	for _, r := range a.statusCodes {
+		if statusCode >= r.from && statusCode <= r.to {
+			accepted = true
+			break
+		}
+	}
+	if !accepted {
+		fail("status_code", a.accepted, strconv.Itoa(statusCode))
+	}
+
+	if a.keyword != "" {
+		i := bytes.Index(body, []byte(a.keyword))
+		switch {
+		case i < 0 && !a.invert:
+			fail("keyword", fmt.Sprintf("body containing %q", a.keyword), truncate(string(body)))
+		case i >= 0 && a.invert:
+			fail("keyword", fmt.Sprintf("body without %q", a.keyword), fmt.Sprintf("%q at offset %d", a.keyword, i))
+		}
+	}
+	if a.regex != nil {
+		match := a.regex.Find(body)
+		switch {
+		case match == nil && !a.invert:
+			fail("regex", fmt.Sprintf("body matching %s", a.regex), truncate(string(body)))
+		case match != nil && a.invert:
+			fail("regex", fmt.Sprintf("body not matching %s", a.regex), strconv.Quote(truncate(string(match))))
+		}
+	}
+
+	if len(a.jsonPaths) > 0 {
+		var doc interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			fail("json_path", "a JSON body", "invalid JSON: "+err.Error())
+		} else {
+			for _, j := range a.jsonPaths {
+				if expected, actual, ok := j.check(doc); !ok {
+					fail("json_path "+j.Path, expected, actual)
+				}
+			}
+		}
+	}
+
+	for name, expected := range a.headers {
+		values := header.Values(name)
+		switch {
+		case len(values) == 0:
+			fail("header "+name, "present", "missing")
+		case expected != "" && !contains(values, expected):
+			fail("header "+name, strconv.Quote(expected), strconv.Quote(strings.Join(values, ", ")))
+		}
+	}
+
+	if a.maxTime > 0 && latency > a.maxTime {
+		fail("response_time", "at most "+a.maxTime.String(), latency.String())
+	}
+	return failures
+}
+
+// check returns the expected and the first failing actual value unless all
+// values selected by the path pass.
+func (j *jsonPathAssertion) check(doc interface{}) (string, string, bool) {
+	values := j.path.lookup(doc)
+	expected := format(j.Value)
+	if j.Operator != OperatorEqual {
+		expected = string(j.Operator) + " " + expected
+	}
+	if j.Operator == OperatorExists {
+		return "to exist", "no match", len(values) > 0
+	}
+	if len(values) == 0 {
+		return expected, "no match", false
+	}
+
+	for _, v := range values {
+		if !j.compare(v) {
+			return expected, truncate(format(v)), false
+		}
+	}
+	return expected, "", true
+}
+
+func (j *jsonPathAssertion) compare(actual interface{}) bool {
+	switch j.Operator {
+	case OperatorEqual:
+		return format(actual) == format(j.Value)
+	case OperatorNotEqual:
+		return format(actual) != format(j.Value)
+	case OperatorContains:
+		switch actual := actual.(type) {
+		case string:
+			return strings.Contains(actual, fmt.Sprint(j.Value))
+		case []interface{}:
+			for _, v := range actual {
+				if format(v) == format(j.Value) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	a, ok := number(actual)
+	if !ok {
+		return false
+	}
+	e, _ := number(j.Value)
+	switch j.Operator {
+	case OperatorGreater:
+		return a > e
+	case OperatorGreaterOrEqual:
+		return a >= e
+	case OperatorLess:
+		return a < e
+	case OperatorLessOrEqual:
+		return a <= e
+	}
+	return false
+}
+
+// format prints strings as is and other values as JSON, so that "ok" from
+// the configuration equals "ok" in the response and 1 equals 1.0.
+func format(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// number converts JSON numbers and numeric strings to float64.
+func number(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func truncate(s string) string {
+	if len(s) <= maxActual {
+		return s
+	}
+	return strings.ToValidUTF8(s[:maxActual], "") + "..."
+}
@@ -0,0 +1,205 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_CheckAssertions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Version", "1.2.0")
+		switch r.URL.Path {
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error": "database unavailable"}`))
+		case "/text":
+			w.Write([]byte("<html>please login</html>"))
+		default:
+			w.Write([]byte(`{"status": "ok", "queue": {"size": 12}, "checks": [{"status": "up"}, {"status": "down"}], "tags": ["a", "b"]}`))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		config   Config
+		failures AssertionErrors
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "default status codes",
+			path: "/error",
+			failures: AssertionErrors{
+				{Assertion: "status_code", Expected: "200-299", Actual: "500"},
+			},
+		},
+		{
+			name:   "accepted status codes",
+			path:   "/error",
+			config: Config{AcceptedStatusCodes: []string{"2xx", "500-503"}},
+		},
+		{
+			name:   "keyword",
+			path:   "/text",
+			config: Config{Keyword: "Welcome"},
+			failures: AssertionErrors{
+				{Assertion: "keyword", Expected: `body containing "Welcome"`, Actual: "<html>please login</html>"},
+			},
+		},
+		{
+			name:   "inverted keyword",
+			path:   "/text",
+			config: Config{Keyword: "login", InvertMatch: true},
+			failures: AssertionErrors{
+				{Assertion: "keyword", Expected: `body without "login"`, Actual: `"login" at offset 13`},
+			},
+		},
+		{
+			name:   "regex",
+			config: Config{Regex: `"size": \d+`},
+		},
+		{
+			name:   "inverted regex",
+			path:   "/error",
+			config: Config{AcceptedStatusCodes: []string{"500"}, Regex: `"error": "[^"]+"`, InvertMatch: true},
+			failures: AssertionErrors{
+				{Assertion: "regex", Expected: `body not matching "error": "[^"]+"`, Actual: `"\"error\": \"database unavailable\""`},
+			},
+		},
+		{
+			name: "json path",
+			config: Config{JSONPath: []JSONPathAssertion{
+				{Path: "$.status", Value: "ok"},
+				{Path: "$.queue.size", Operator: OperatorLess, Value: 100.0},
+				{Path: "$.queue.size", Operator: OperatorEqual, Value: "12"},
+				{Path: "$.tags", Operator: OperatorContains, Value: "b"},
+				{Path: "$.queue", Operator: OperatorExists},
+			}},
+		},
+		{
+			name: "json path failures",
+			config: Config{JSONPath: []JSONPathAssertion{
+				{Path: "$.checks[*].status", Value: "up"},
+				{Path: "$.queue.size", Operator: OperatorGreaterOrEqual, Value: 100.0},
+				{Path: "$.missing", Operator: OperatorExists},
+				{Path: "$.missing", Operator: OperatorNotEqual, Value: "x"},
+			}},
+			failures: AssertionErrors{
+				{Assertion: "json_path $.checks[*].status", Expected: "up", Actual: "down"},
+				{Assertion: "json_path $.queue.size", Expected: ">= 100", Actual: "12"},
+				{Assertion: "json_path $.missing", Expected: "to exist", Actual: "no match"},
+				{Assertion: "json_path $.missing", Expected: "!= x", Actual: "no match"},
+			},
+		},
+		{
+			name:   "json path on text",
+			path:   "/text",
+			config: Config{JSONPath: []JSONPathAssertion{{Path: "$.status", Value: "ok"}}},
+			failures: AssertionErrors{
+				{Assertion: "json_path", Expected: "a JSON body", Actual: "invalid JSON: invalid character '<' looking for beginning of value"},
+			},
+		},
+		{
+			name: "headers",
+			config: Config{ResponseHeaders: map[string]string{
+				"content-type": "application/json",
+				"X-Version":    "",
+			}},
+		},
+		{
+			name: "header failures",
+			config: Config{ResponseHeaders: map[string]string{
+				"X-Version": "2.0.0",
+			}},
+			failures: AssertionErrors{
+				{Assertion: "header X-Version", Expected: `"2.0.0"`, Actual: `"1.2.0"`},
+			},
+		},
+		{
+			name:   "missing header",
+			config: Config{ResponseHeaders: map[string]string{"X-Request-Id": ""}},
+			failures: AssertionErrors{
+				{Assertion: "header X-Request-Id", Expected: "present", Actual: "missing"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.URL = server.URL + tt.path
+			tt.config.Method = GET
+			result, err := NewHTTP(tt.config, SetClient(resty.New())).Check(context.Background())
+			require.NotNil(t, result)
+			details := result.Details.(*Details)
+			assert.Equal(t, tt.failures, details.Failures)
+
+			if len(tt.failures) == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, status_neko.StatusUp, result.Status)
+				return
+			}
+			assert.Equal(t, tt.failures, err)
+			assert.Equal(t, status_neko.StatusDown, result.Status)
+			assert.Equal(t, tt.failures.Error(), result.Message)
+		})
+	}
+}
+
+func TestHTTP_CheckMaxResponseTime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	h := NewHTTP(Config{URL: server.URL, Method: GET, MaxResponseTime: status_neko.Duration(time.Millisecond)}, SetClient(resty.New()))
+	result, err := h.Check(context.Background())
+	require.Error(t, err)
+	failures := result.Details.(*Details).Failures
+	require.Len(t, failures, 1)
+	assert.Equal(t, "response_time", failures[0].Assertion)
+	assert.Equal(t, "at most 1ms", failures[0].Expected)
+	assert.Contains(t, err.Error(), "response_time: expected at most 1ms, got ")
+}
+
+func TestConfig_ValidateAssertions(t *testing.T) {
+	_, err := status_neko.Build([]byte(`{
+		"type": "http",
+		"url": "http://example.com",
+		"accepted_status_codes": ["200", "300-200", "6xx"],
+		"regex": "(",
+		"json_path": [
+			{"path": "status", "value": "ok"},
+			{"path": "$.size", "operator": ">", "value": "many"},
+			{"path": "$.size", "operator": "~"}
+		],
+		"max_response_time": "-1s"
+	}`))
+	var fields []string
+	for _, f := range status_neko.Fields(err) {
+		fields = append(fields, f.Field)
+	}
+	assert.Equal(t, []string{
+		"accepted_status_codes[1]",
+		"accepted_status_codes[2]",
+		"regex",
+		"json_path[0].path",
+		"json_path[1].value",
+		"json_path[2].operator",
+		"max_response_time",
+	}, fields)
+
+	_, err = NewHTTP(Config{URL: "http://example.com", Regex: "("}).Check(context.Background())
+	assert.Error(t, err)
+}
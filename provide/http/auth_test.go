@@ -0,0 +1,132 @@
+package http
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// digestServer implements RFC 7616 with qop=auth and MD5.
+func digestServer(username, password string) http.HandlerFunc {
+	const realm, nonce = "neko", "dcd98b7102dd2f0e8b11d0f600bfb0c093"
+	param := regexp.MustCompile(`(\w+)=("([^"]*)"|[^,\s]*)`)
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := map[string]string{}
+		for _, m := range param.FindAllStringSubmatch(r.Header.Get("Authorization"), -1) {
+			params[m[1]] = m[3]
+			if m[3] == "" {
+				params[m[1]] = m[2]
+			}
+		}
+		ha1 := md5Hex(username + ":" + realm + ":" + password)
+		ha2 := md5Hex(r.Method + ":" + params["uri"])
+		expected := md5Hex(fmt.Sprintf("%s:%s:%s:%s:%s:%s", ha1, nonce, params["nc"], params["cnonce"], params["qop"], ha2))
+		if params["username"] != username || params["nonce"] != nonce || params["response"] != expected {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Digest realm="%s", nonce="%s", qop="auth", algorithm=MD5`, realm, nonce))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("welcome " + username))
+	}
+}
+
+func TestHTTP_CheckWithDigest(t *testing.T) {
+	server := httptest.NewServer(digestServer("neko", "secret"))
+	defer server.Close()
+
+	result, err := NewHTTP(Config{
+		URL:        server.URL + "/status?full=1",
+		Method:     GET,
+		AuthType:   AuthTypeDigest,
+		AuthConfig: AuthDigestConfig{Username: "neko", Password: "secret"},
+	}).Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "welcome neko", string(result.Details.(*Details).Body))
+
+	_, err = NewHTTP(Config{
+		URL:        server.URL,
+		Method:     GET,
+		AuthType:   AuthTypeDigest,
+		AuthConfig: AuthDigestConfig{Username: "neko", Password: "wrong"},
+	}).Check(context.Background())
+	assert.Error(t, err)
+}
+
+func TestHTTP_CheckWithToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("Authorization") == "Bearer token",
+			r.Header.Get("X-API-Key") == "key",
+			r.URL.Query().Get("api_key") == "key":
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	for _, tt := range []struct {
+		name       string
+		authType   AuthType
+		authConfig interface{}
+		wantErr    bool
+	}{
+		{name: "bearer", authType: AuthTypeBearer, authConfig: AuthBearerConfig{Token: "token"}},
+		{name: "bearer invalid", authType: AuthTypeBearer, authConfig: AuthBearerConfig{Token: "invalid"}, wantErr: true},
+		{name: "api key header", authType: AuthTypeAPIKey, authConfig: AuthAPIKeyConfig{Name: "X-API-Key", Value: "key"}},
+		{name: "api key query", authType: AuthTypeAPIKey, authConfig: AuthAPIKeyConfig{Name: "api_key", Value: "key", In: APIKeyInQuery}},
+		{name: "api key wrong location", authType: AuthTypeAPIKey, authConfig: AuthAPIKeyConfig{Name: "api_key", Value: "key", In: APIKeyInHeader}, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewHTTP(Config{
+				URL:        server.URL,
+				Method:     GET,
+				AuthType:   tt.authType,
+				AuthConfig: tt.authConfig,
+			}).Check(context.Background())
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "got 401")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, status_neko.StatusUp, result.Status)
+		})
+	}
+}
+
+func TestConfig_ValidateAuth(t *testing.T) {
+	for _, tt := range []struct {
+		config string
+		fields []string
+	}{
+		{config: `"auth_type": "Bearer"`, fields: []string{"auth_config"}},
+		{config: `"auth_type": "Bearer", "auth_config": {}`, fields: []string{"auth_config.token"}},
+		{config: `"auth_type": "Digest", "auth_config": {"password": "secret"}`, fields: []string{"auth_config.username"}},
+		{config: `"auth_type": "APIKey", "auth_config": {"name": "X-API-Key", "value": "key", "in": "cookie"}`, fields: []string{"auth_config.in"}},
+		{config: `"auth_type": "AWSSigV4", "auth_config": {"access_key_id": "id", "secret_access_key": "key"}`, fields: []string{"auth_config.region", "auth_config.service"}},
+		{config: `"auth_type": "Kerberos"`, fields: []string{"auth_type"}},
+	} {
+		_, err := status_neko.Build([]byte(`{"type": "http", "url": "http://example.com", ` + tt.config + `}`))
+		var fields []string
+		for _, f := range status_neko.Fields(err) {
+			fields = append(fields, f.Field)
+		}
+		assert.Equal(t, tt.fields, fields, tt.config)
+	}
+
+	_, err := status_neko.Build([]byte(`{"type": "http", "url": "http://example.com", "auth_type": "APIKey", "auth_config": {"name": "api_key", "value": "key", "in": "query"}}`))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+// newClient builds the client of a monitor, with its own transport and so
+// its own connection pool, TLS settings and proxy. c is used instead of a new
+// client when not nil, its transport is replaced. The client doesn't retry,
+// retries are left to middleware.Retry.
+func newClient(config Config, c *resty.Client) (*resty.Client, error) {
+	transport, err := newTransport(config)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		c = resty.New()
+	}
+	c.SetTransport(transport)
+	if digest, ok := config.AuthConfig.(AuthDigestConfig); ok && config.AuthType == AuthTypeDigest {
+		// 收到 Digest challenge 时带上认证信息重发请求
+		c.SetDigestAuth(digest.Username, digest.Password)
+	}
+	return c, nil
+}
+
+// closeIdleConnections closes the idle connections of transport when it
+// supports it, like http.Client.CloseIdleConnections.
+func closeIdleConnections(transport http.RoundTripper) {
+	if t, ok := transport.(interface{ CloseIdleConnections() }); ok {
+		t.CloseIdleConnections()
+	}
+}
+
+// newTransport returns a *status_neko.FieldError for invalid certificates or proxies.
+func newTransport(config Config) (http.RoundTripper, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config.SkipCertificateExpires,
+	}
+	if mTLS, ok := config.AuthConfig.(AuthMTLSConfig); ok && config.AuthType == AuthTypeMTLS && mTLS.Cert != "" {
+		rootCAs, certificates, err := LoadCertFromByte([]byte(mTLS.Cert), []byte(mTLS.Key), []byte(mTLS.CA))
+		if err != nil {
+			return nil, &status_neko.FieldError{Field: "auth_config", Message: err.Error()}
+		}
+		// 没有配置 CA 时使用系统的根证书
+		if mTLS.CA != "" {
+			tlsConfig.RootCAs = rootCAs
+		}
+		tlsConfig.Certificates = certificates
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	// 处理代理设置
+	if err := setProxy(transport, config); err != nil {
+		return nil, err
+	}
+
+	switch auth := config.AuthConfig.(type) {
+	case AuthNTLMConfig:
+		if config.AuthType == AuthTypeNTLM {
+			return newNTLMTransport(auth, transport), nil
+		}
+	case AuthAWSSigV4Config:
+		if config.AuthType == AuthTypeAWSSigV4 {
+			return &sigV4Transport{config: auth, transport: transport, now: time.Now}, nil
+		}
+	}
+	return transport, nil
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHTTP_CheckIsolation runs monitors with different TLS and proxy settings
+// in parallel, none of them may see the settings of another.
+func TestHTTP_CheckIsolation(t *testing.T) {
+	reply := func(body string, count *atomic.Int32) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			count.Add(1)
+			w.Write([]byte(body + r.URL.String()))
+		})
+	}
+
+	var directs, proxied, tlsRequests, mTLSRequests atomic.Int32
+	direct := httptest.NewServer(reply("direct ", &directs))
+	defer direct.Close()
+	proxy := httptest.NewServer(reply("proxy ", &proxied))
+	defer proxy.Close()
+	plainTLS := httptest.NewTLSServer(reply("tls ", &tlsRequests))
+	defer plainTLS.Close()
+	mTLS := httptest.NewUnstartedServer(reply("mtls ", &mTLSRequests))
+	mTLS.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	mTLS.StartTLS()
+	defer mTLS.Close()
+
+	cert, key, err := generateTestCert()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		config  Config
+		body    string
+		wantErr string
+	}{
+		{
+			name:   "direct",
+			config: Config{URL: direct.URL + "/a"},
+			body:   "direct /a",
+		},
+		{
+			name:   "proxy",
+			config: Config{URL: "http://upstream.invalid/b", ProxyType: ProxyTypeHTTP, ProxyAddress: proxy.URL},
+			body:   "proxy http://upstream.invalid/b",
+		},
+		{
+			name:   "skip verify",
+			config: Config{URL: plainTLS.URL + "/c", SkipCertificateExpires: true},
+			body:   "tls /c",
+		},
+		{
+			name:    "verify",
+			config:  Config{URL: plainTLS.URL + "/d"},
+			wantErr: "certificate",
+		},
+		{
+			name: "mtls",
+			config: Config{
+				URL:                    mTLS.URL + "/e",
+				SkipCertificateExpires: true,
+				AuthType:               AuthTypeMTLS,
+				AuthConfig:             AuthMTLSConfig{Cert: string(cert), Key: string(key)},
+			},
+			body: "mtls /e",
+		},
+		{
+			name:    "mtls without certificate",
+			config:  Config{URL: mTLS.URL + "/f", SkipCertificateExpires: true},
+			wantErr: "certificate",
+		},
+	}
+
+	const rounds = 20
+	var wg sync.WaitGroup
+	for _, tt := range tests {
+		tt.config.Method = GET
+		h := NewHTTP(tt.config)
+		for i := 0; i < rounds; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := h.Check(context.Background())
+				if tt.wantErr != "" {
+					assert.ErrorContains(t, err, tt.wantErr, tt.name)
+					return
+				}
+				if assert.NoError(t, err, tt.name) {
+					assert.Equal(t, tt.body, string(result.Details.(*Details).Body), tt.name)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, rounds, directs.Load())
+	assert.EqualValues(t, rounds, proxied.Load())
+	assert.EqualValues(t, rounds, tlsRequests.Load())
+	assert.EqualValues(t, rounds, mTLSRequests.Load())
+}
+
+func TestNewHTTP_InvalidCertificate(t *testing.T) {
+	_, err := NewHTTP(Config{
+		URL:        "https://example.com",
+		AuthType:   AuthTypeMTLS,
+		AuthConfig: AuthMTLSConfig{Cert: "invalid", Key: "invalid"},
+	}).Check(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, "auth_config", status_neko.Fields(err)[0].Field)
+}
+
+// TestHTTP_CheckNoRetry: retries are left to middleware.Retry, a failed
+// request is sent only once.
+func TestHTTP_CheckNoRetry(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		conn.Close()
+	}))
+	defer server.Close()
+
+	_, err := NewHTTP(Config{URL: server.URL, Method: GET}).Check(context.Background())
+	require.Error(t, err)
+	assert.EqualValues(t, 1, requests.Load())
+}
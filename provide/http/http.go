@@ -4,13 +4,14 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"sync"
 	"time"
 
-	"github.com/Azure/go-ntlmssp"
-
 	"golang.org/x/oauth2/clientcredentials"
 
 	"golang.org/x/oauth2"
@@ -32,17 +33,20 @@ type (
 	// AuthenticationMethod is the interface that wraps the basic methods for authentication.
 	AuthenticationMethod string
 
+	// APIKeyLocation is where an API key is sent.
+	APIKeyLocation string
+
 	// ProxyType is the type of proxy to be used in the request.
 	ProxyType string
 )
 
 var (
-	_ status_neko.Monitor = (*HTTP)(nil)
+	_ status_neko.Monitor  = (*HTTP)(nil)
+	_ status_neko.Starter  = (*HTTP)(nil)
+	_ status_neko.Measurer = (*Details)(nil)
 
 	providerHttpName = "http"
 
-	client = resty.New().SetRetryCount(3)
-
 	GET     Method = resty.MethodGet
 	POST    Method = resty.MethodPost
 	PUT     Method = resty.MethodPut
@@ -59,6 +63,15 @@ var (
 	AuthTypeOAuth2 AuthType = "Oauth2"
 	AuthTypeNTLM   AuthType = "NTLM"
 	AuthTypeMTLS   AuthType = "mTls"
+	AuthTypeDigest AuthType = "Digest"
+	AuthTypeBearer AuthType = "Bearer"
+	AuthTypeAPIKey AuthType = "APIKey"
+	// AuthTypeAWSSigV4 signs the requests with AWS Signature Version 4,
+	// e.g. for private API Gateway or S3 compatible endpoints.
+	AuthTypeAWSSigV4 AuthType = "AWSSigV4"
+
+	APIKeyInHeader APIKeyLocation = "header"
+	APIKeyInQuery  APIKeyLocation = "query"
 
 	AuthenticationMethodHeader AuthenticationMethod = "client_secret_basic"
 	AuthenticationMethodParam  AuthenticationMethod = "client_secret_post"
@@ -72,6 +85,12 @@ var (
 	ProxyTypeSocksV4    ProxyType = "SOCKS4"
 )
 
+func init() {
+	status_neko.Register(providerHttpName, status_neko.NewFactory(func(c Config) status_neko.Monitor {
+		return NewHTTP(c)
+	}))
+}
+
 type AuthBasicConfig struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
@@ -83,11 +102,11 @@ type AuthOAuth2Config struct {
 	ClientID             string               `json:"client_id"`
 	ClientSecret         string               `json:"client_secret"`
 	OAuthScope           string               `json:"oauth_scope"`
-	// 这两个是后续请求时需要的 token 和过期时间
-	// 作为cache存储
+	// TokenSet 是初始的 token, 过期后由监控项重新获取并缓存
 	*TokenSet `json:"-"`
 }
 
+// AuthNTLMConfig 的 Username 也可以写作 DOMAIN\user
 type AuthNTLMConfig struct {
 	Username    string `json:"username"`
 	Password    string `json:"password"`
@@ -95,6 +114,32 @@ type AuthNTLMConfig struct {
 	Workstation string `json:"workstation"`
 }
 
+type AuthDigestConfig struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type AuthBearerConfig struct {
+	Token string `json:"token"`
+}
+
+type AuthAPIKeyConfig struct {
+	// Name 是请求头或查询参数的名称, 如 X-API-Key
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// In 默认为 header
+	In APIKeyLocation `json:"in"`
+}
+
+type AuthAWSSigV4Config struct {
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	SessionToken    string `json:"session_token"`
+	Region          string `json:"region"`
+	// Service 如 execute-api、s3
+	Service string `json:"service"`
+}
+
 type TokenSet struct {
 	AccessToken string    `json:"access_token"`
 	TokenType   string    `json:"token_type"`
@@ -113,10 +158,46 @@ type ProxyAuthConfig struct {
 	Password string `json:"password"`
 }
 
+// Details is the Result.Details of an HTTP check.
+type Details struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	// Timing 是各阶段的耗时
+	Timing *Timing `json:"timing,omitempty"`
+	// Failures 是未通过的响应断言
+	Failures AssertionErrors `json:"failures,omitempty"`
+
+	// Response 原始响应, 便于需要更多信息的调用方使用
+	Response *resty.Response `json:"-"`
+}
+
+func (d *Details) Measurements() map[string]float64 {
+	m := map[string]float64{
+		"status_code":    float64(d.StatusCode),
+		"content_length": float64(len(d.Body)),
+	}
+	if d.Timing != nil {
+		m["dns_lookup_seconds"] = d.Timing.DNSLookup.Seconds()
+		m["connect_seconds"] = d.Timing.Connect.Seconds()
+		m["tls_handshake_seconds"] = d.Timing.TLSHandshake.Seconds()
+		m["first_byte_seconds"] = d.Timing.FirstByte.Seconds()
+		m["content_transfer_seconds"] = d.Timing.ContentTransfer.Seconds()
+	}
+	return m
+}
+
 type HTTP struct {
 	option *option
 
 	config Config
+
+	// client 由 Config 创建, 每个监控项独立, 不与其他监控项共享连接和 TLS、代理设置
+	client     *resty.Client
+	token      *oauth2Token
+	assertions *assertions
+	// err 是编译断言或创建 client 时的错误, 未经 Validate 的配置在 Check 时返回
+	err error
 }
 
 type Config struct {
@@ -132,77 +213,211 @@ type Config struct {
 	ProxyAuthEnabled       bool              `json:"proxy_auth_enabled"`
 	ProxyAuthConfig        ProxyAuthConfig   `json:"proxy_auth_config"`
 	SkipCertificateExpires bool              `json:"skip_certificate_expires"`
+
+	// 以下为响应断言, 任何一项不满足时检测结果为 DOWN
+
+	// AcceptedStatusCodes 如 "200", "200-299", "2xx", 默认 200-299
+	AcceptedStatusCodes []string `json:"accepted_status_codes"`
+	// Keyword 和 Regex 是响应体中必须出现的内容, InvertMatch 时为不能出现的内容
+	Keyword     string              `json:"keyword"`
+	Regex       string              `json:"regex"`
+	InvertMatch bool                `json:"invert_match"`
+	JSONPath    []JSONPathAssertion `json:"json_path"`
+	// ResponseHeaders 是必须出现的响应头, 值不为空时还需等于该值
+	ResponseHeaders map[string]string    `json:"response_headers"`
+	MaxResponseTime status_neko.Duration `json:"max_response_time"`
+}
+
+// UnmarshalJSON decodes auth_config into the struct matching auth_type,
+// e.g. AuthBasicConfig for AuthTypeBasic.
+func (c *Config) UnmarshalJSON(b []byte) error {
+	type config Config
+	var raw struct {
+		config
+		AuthConfig json.RawMessage `json:"auth_config"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*c = Config(raw.config)
+	c.AuthConfig = nil
+
+	if len(raw.AuthConfig) == 0 || string(raw.AuthConfig) == "null" {
+		return nil
+	}
+
+	var err error
+	switch c.AuthType {
+	case AuthTypeBasic:
+		var auth AuthBasicConfig
+		err = json.Unmarshal(raw.AuthConfig, &auth)
+		c.AuthConfig = auth
+	case AuthTypeOAuth2:
+		var auth AuthOAuth2Config
+		err = json.Unmarshal(raw.AuthConfig, &auth)
+		c.AuthConfig = auth
+	case AuthTypeNTLM:
+		var auth AuthNTLMConfig
+		err = json.Unmarshal(raw.AuthConfig, &auth)
+		c.AuthConfig = auth
+	case AuthTypeMTLS:
+		var auth AuthMTLSConfig
+		err = json.Unmarshal(raw.AuthConfig, &auth)
+		c.AuthConfig = auth
+	case AuthTypeDigest:
+		var auth AuthDigestConfig
+		err = json.Unmarshal(raw.AuthConfig, &auth)
+		c.AuthConfig = auth
+	case AuthTypeBearer:
+		var auth AuthBearerConfig
+		err = json.Unmarshal(raw.AuthConfig, &auth)
+		c.AuthConfig = auth
+	case AuthTypeAPIKey:
+		var auth AuthAPIKeyConfig
+		err = json.Unmarshal(raw.AuthConfig, &auth)
+		c.AuthConfig = auth
+	case AuthTypeAWSSigV4:
+		var auth AuthAWSSigV4Config
+		err = json.Unmarshal(raw.AuthConfig, &auth)
+		c.AuthConfig = auth
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		typeErr.Field = "auth_config." + typeErr.Field
+		return typeErr
+	}
+	if err != nil {
+		return fmt.Errorf("auth_config: %w", err)
+	}
+	return nil
+}
+
+func (c *Config) Validate() error {
+	var fields status_neko.FieldErrors
+	if c.URL == "" {
+		fields = append(fields, &status_neko.FieldError{Field: "url", Message: "is required"})
+	} else if u, err := url.Parse(c.URL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		fields = append(fields, &status_neko.FieldError{Field: "url", Message: "must be an absolute http or https URL"})
+	}
+	fields = append(fields, validateAuth(c)...)
+	if _, err := newTransport(*c); err != nil {
+		fields = append(fields, status_neko.Fields(err)...)
+	}
+	if _, err := compileAssertions(c); err != nil {
+		fields = append(fields, status_neko.Fields(err)...)
+	}
+	if len(fields) > 0 {
+		return fields
+	}
+	return nil
+}
+
+// validateAuth checks the required fields of auth_config.
+func validateAuth(c *Config) status_neko.FieldErrors {
+	var fields status_neko.FieldErrors
+	required := func(name, value string) {
+		if value == "" {
+			fields = append(fields, &status_neko.FieldError{Field: "auth_config." + name, Message: "is required"})
+		}
+	}
+
+	switch auth := c.AuthConfig.(type) {
+	case AuthNTLMConfig:
+		required("username", auth.Username)
+	case AuthDigestConfig:
+		required("username", auth.Username)
+	case AuthBearerConfig:
+		required("token", auth.Token)
+	case AuthAPIKeyConfig:
+		required("name", auth.Name)
+		required("value", auth.Value)
+		if auth.In != "" && auth.In != APIKeyInHeader && auth.In != APIKeyInQuery {
+			fields = append(fields, &status_neko.FieldError{Field: "auth_config.in", Message: "must be header or query"})
+		}
+	case AuthAWSSigV4Config:
+		required("access_key_id", auth.AccessKeyID)
+		required("secret_access_key", auth.SecretAccessKey)
+		required("region", auth.Region)
+		required("service", auth.Service)
+	}
+
+	switch c.AuthType {
+	case AuthTypeNone, AuthTypeBasic, AuthTypeOAuth2, AuthTypeMTLS:
+	case AuthTypeNTLM, AuthTypeDigest, AuthTypeBearer, AuthTypeAPIKey, AuthTypeAWSSigV4:
+		if c.AuthConfig == nil {
+			fields = append(fields, &status_neko.FieldError{Field: "auth_config", Message: "is required"})
+		}
+	default:
+		fields = append(fields, &status_neko.FieldError{Field: "auth_type", Message: fmt.Sprintf("unknown auth type %q", c.AuthType)})
+	}
+	return fields
 }
 
 type option struct {
 	client *resty.Client
 }
 
-func SetClient(c *option) status_neko.Option[*option] {
+// SetClient uses client instead of a new one, its transport is replaced by
+// the one built from the Config, so it must not be shared between monitors.
+func SetClient(client *resty.Client) status_neko.Option[*option] {
 	return func(o *option) {
-		o.client = c.client
+		o.client = client
 	}
 }
 
 func NewHTTP(c Config, opts ...status_neko.Option[*option]) *HTTP {
-	o := &option{
-		client: client,
-	}
+	o := &option{}
 	for _, opt := range opts {
 		opt(o)
 	}
 
-	return &HTTP{
+	h := &HTTP{
 		option: o,
 		config: c,
 	}
+	h.assertions, h.err = compileAssertions(&c)
+	if h.err == nil {
+		h.client, h.err = newClient(c, o.client)
+	}
+	if oauthConfig, ok := c.AuthConfig.(AuthOAuth2Config); ok && h.err == nil {
+		// token 请求与检测使用同一个 transport, 即同样的代理和 TLS 设置
+		h.token = &oauth2Token{
+			config: oauthConfig,
+			client: &http.Client{Transport: h.client.GetClient().Transport},
+			token:  oauthConfig.TokenSet,
+		}
+	}
+	return h
 }
 
-func (h HTTP) Name() string {
-	return providerHttpName
+// Start does nothing, the connections are opened by the checks.
+func (h *HTTP) Start() error {
+	return nil
 }
 
-func (h HTTP) Check(ctx context.Context) (interface{}, error) {
-	// 创建请求对象
-
-	client := h.option.client
-
-	if h.config.SkipCertificateExpires {
-		client = client.SetTLSClientConfig(&tls.Config{
-			InsecureSkipVerify: h.config.SkipCertificateExpires,
-		})
+// Stop closes the idle keep-alive connections of the monitor, e.g. once it
+// was removed from the scheduler or after a one-shot probe.
+func (h *HTTP) Stop() {
+	if h.client != nil {
+		h.client.GetClient().CloseIdleConnections()
 	}
+}
 
-	switch h.config.AuthType {
-	case AuthTypeNTLM:
-		ntlmTransport := &ntlmssp.Negotiator{
-			RoundTripper: &http.Transport{},
-		}
-		client.SetTransport(ntlmTransport)
+func (h HTTP) Name() string {
+	return providerHttpName
+}
 
-	case AuthTypeMTLS:
-		if mTlsConfig, ok := h.config.AuthConfig.(AuthMTLSConfig); ok {
-			if mTlsConfig.Cert != "" {
-				rootCAs, certificates, err := LoadCertFromByte([]byte(mTlsConfig.Cert), []byte(mTlsConfig.Key), []byte(mTlsConfig.CA))
-				if err != nil {
-					return nil, err
-				}
-				client = client.SetTLSClientConfig(&tls.Config{
-					RootCAs:            rootCAs,
-					Certificates:       certificates,
-					InsecureSkipVerify: h.config.SkipCertificateExpires,
-				})
-			}
-		}
+func (h HTTP) Check(ctx context.Context) (*status_neko.Result, error) {
+	start := time.Now()
+	if h.err != nil {
+		return nil, h.err
 	}
 
-	// 处理代理设置
-	if h.config.ProxyType != ProxyTypeNone {
-		client.SetProxy(h.config.ProxyAddress)
-
-	}
+	// 创建请求对象
+	client := h.client
 
-	req := client.R().SetContext(ctx)
+	trace := &tracer{}
+	req := client.R().SetContext(trace.context(ctx))
 
 	// 设置请求方法、URL、Content-Type
 	req.Method = string(h.config.Method)
@@ -226,34 +441,28 @@ func (h HTTP) Check(ctx context.Context) (interface{}, error) {
 		}
 
 	case AuthTypeOAuth2:
-		if oauthConfig, ok := h.config.AuthConfig.(AuthOAuth2Config); ok {
-			if oauthConfig.TokenSet == nil || oauthConfig.TokenSet.Expiry.Before(time.Now()) {
-				tokenSet, err := getOidcTokenClient(ctx, oauthConfig)
-				if err != nil {
-					return nil, err
-				}
-				oauthConfig.TokenSet = tokenSet
+		if h.token != nil {
+			tokenSet, err := h.token.get(ctx)
+			if err != nil {
+				return nil, err
 			}
-			req = req.SetAuthScheme(oauthConfig.TokenSet.TokenType)
-			req = req.SetAuthToken(oauthConfig.TokenSet.AccessToken)
+			req = req.SetAuthScheme(tokenSet.TokenType)
+			req = req.SetAuthToken(tokenSet.AccessToken)
 		}
-	case AuthTypeNTLM:
-		if ntlmConfig, ok := h.config.AuthConfig.(AuthNTLMConfig); ok {
-			req = req.SetBasicAuth(ntlmConfig.Username, ntlmConfig.Password)
-			req = req.SetHeader("Authorization", "NTLM")
-			if ntlmConfig.Domain != "" {
-				req.SetHeader("X-NTLM-Domain", ntlmConfig.Domain)
-			}
-			if ntlmConfig.Workstation != "" {
-				req.SetHeader("X-NTLM-Workstation", ntlmConfig.Workstation)
+	case AuthTypeBearer:
+		if bearerConfig, ok := h.config.AuthConfig.(AuthBearerConfig); ok {
+			req = req.SetAuthToken(bearerConfig.Token)
+		}
+	case AuthTypeAPIKey:
+		if apiKeyConfig, ok := h.config.AuthConfig.(AuthAPIKeyConfig); ok {
+			if apiKeyConfig.In == APIKeyInQuery {
+				req = req.SetQueryParam(apiKeyConfig.Name, apiKeyConfig.Value)
+			} else {
+				req = req.SetHeader(apiKeyConfig.Name, apiKeyConfig.Value)
 			}
 		}
 	}
-
-	if h.config.ProxyAddress != "" && h.config.ProxyAuthEnabled {
-		authHeader := "Basic " + base64.StdEncoding.EncodeToString([]byte(h.config.ProxyAuthConfig.Username+":"+h.config.ProxyAuthConfig.Password))
-		req = req.SetHeader("Proxy-Authorization", authHeader)
-	}
+	// NTLM 和 AWS SigV4 由 transport 处理, Digest 由 client 处理, 见 newClient
 
 	// 发送请求并获取响应
 	resp, err := req.Send()
@@ -262,10 +471,53 @@ func (h HTTP) Check(ctx context.Context) (interface{}, error) {
 	}
 
 	// 返回响应内容
-	return resp, nil
+	details := &Details{
+		StatusCode: resp.StatusCode(),
+		Header:     resp.Header(),
+		Body:       resp.Body(),
+		Timing:     trace.timing(resp.ReceivedAt()),
+		Response:   resp,
+	}
+	result := status_neko.NewResult(status_neko.StatusUp, start, resp.Status(), details)
+	result.Latency = resp.Time()
+
+	details.Failures = h.assertions.check(details.StatusCode, details.Header, details.Body, result.Latency)
+	if len(details.Failures) > 0 {
+		result.Status = status_neko.StatusDown
+		result.Message = details.Failures.Error()
+		return result, details.Failures
+	}
+	return result, nil
+}
+
+// oauth2TokenExpiryDelta 提前刷新即将过期的 token
+var oauth2TokenExpiryDelta = 10 * time.Second
+
+// oauth2Token caches the token of a monitor between checks.
+type oauth2Token struct {
+	config AuthOAuth2Config
+	client *http.Client
+
+	mu    sync.Mutex
+	token *TokenSet
+}
+
+func (o *oauth2Token) get(ctx context.Context) (*TokenSet, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.token != nil && (o.token.Expiry.IsZero() || time.Now().Add(oauth2TokenExpiryDelta).Before(o.token.Expiry)) {
+		return o.token, nil
+	}
+	tokenSet, err := getOidcTokenClient(ctx, o.client, o.config)
+	if err != nil {
+		return nil, err
+	}
+	o.token = tokenSet
+	return tokenSet, nil
 }
 
-func getOidcTokenClient(ctx context.Context, authOAuth2Config AuthOAuth2Config) (*TokenSet, error) {
+func getOidcTokenClient(ctx context.Context, httpClient *http.Client, authOAuth2Config AuthOAuth2Config) (*TokenSet, error) {
 	// Determine the AuthStyle based on the authMethod parameter
 	var authStyle oauth2.AuthStyle
 	switch authOAuth2Config.AuthenticationMethod {
@@ -286,10 +538,7 @@ func getOidcTokenClient(ctx context.Context, authOAuth2Config AuthOAuth2Config)
 		AuthStyle:    authStyle, // Use the determined authStyle
 	}
 
-	// Create a context with a custom HTTP client
-	httpClient := &http.Client{
-		Timeout: 10 * time.Second,
-	}
+	// Use the HTTP client of the monitor, the deadline comes from ctx
 	ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
 
 	// Retrieve the token
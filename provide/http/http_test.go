@@ -6,14 +6,17 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/json"
 	"encoding/pem"
 	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/go-resty/resty/v2"
+	status_neko "github.com/songzhibin97/status-neko"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -61,7 +64,7 @@ func TestHTTP_Check(t *testing.T) {
 
 			tt.config.URL = server.URL
 			client := resty.New()
-			httpClient := NewHTTP(tt.config, SetClient(&option{client: client}))
+			httpClient := NewHTTP(tt.config, SetClient(client))
 
 			result, err := httpClient.Check(context.Background())
 
@@ -73,17 +76,20 @@ func TestHTTP_Check(t *testing.T) {
 			require.NoError(t, err)
 			require.NotNil(t, result)
 
-			response, ok := result.(*resty.Response)
+			details, ok := result.Details.(*Details)
 			require.True(t, ok)
-			assert.Equal(t, tt.expectedStatus, response.StatusCode())
-			assert.Equal(t, tt.expectedBody, string(response.Body()))
+			assert.Equal(t, tt.expectedStatus, details.StatusCode)
+			assert.Equal(t, tt.expectedBody, string(details.Body))
 		})
 	}
 }
 
 func TestHTTP_CheckWithOAuth2(t *testing.T) {
-	// Mock OAuth2 token server
-	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	// Mock OAuth2 token server, its self-signed certificate is only accepted
+	// through the transport of the monitor
+	var tokenRequests atomic.Int32
+	tokenServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Add(1)
 		w.Header().Set("Content-Type", "application/json")
 		w.Write([]byte(`{
 			"access_token": "test_access_token",
@@ -102,10 +108,11 @@ func TestHTTP_CheckWithOAuth2(t *testing.T) {
 	defer apiServer.Close()
 
 	config := Config{
-		URL:         apiServer.URL,
-		Method:      GET,
-		ContentType: ContentTypeJSON,
-		AuthType:    AuthTypeOAuth2,
+		URL:                    apiServer.URL,
+		Method:                 GET,
+		ContentType:            ContentTypeJSON,
+		SkipCertificateExpires: true,
+		AuthType:               AuthTypeOAuth2,
 		AuthConfig: AuthOAuth2Config{
 			AuthenticationMethod: AuthenticationMethodHeader,
 			OathTokenURL:         tokenServer.URL,
@@ -116,16 +123,23 @@ func TestHTTP_CheckWithOAuth2(t *testing.T) {
 	}
 
 	client := resty.New()
-	httpClient := NewHTTP(config, SetClient(&option{client: client}))
+	httpClient := NewHTTP(config, SetClient(client))
 
 	result, err := httpClient.Check(context.Background())
 	require.NoError(t, err)
 	require.NotNil(t, result)
 
-	response, ok := result.(*resty.Response)
+	details, ok := result.Details.(*Details)
 	require.True(t, ok)
-	assert.Equal(t, http.StatusOK, response.StatusCode())
-	assert.Equal(t, `{"status": "authenticated"}`, string(response.Body()))
+	assert.Equal(t, http.StatusOK, details.StatusCode)
+	assert.Equal(t, `{"status": "authenticated"}`, string(details.Body))
+
+	// token 在过期前被复用
+	for i := 0; i < 3; i++ {
+		_, err = httpClient.Check(context.Background())
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 1, tokenRequests.Load())
 }
 
 func TestLoadCertFromByte(t *testing.T) {
@@ -147,43 +161,6 @@ func TestLoadCertFromByte(t *testing.T) {
 	assert.Equal(t, "test.example.com", parsedCert.Subject.CommonName)
 }
 
-func TestHTTP_CheckWithNTLM(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// The Authorization header will be handled by the NTLM negotiator
-		// so we won't check for it here
-		assert.Equal(t, "testdomain", r.Header.Get("X-NTLM-Domain"))
-		assert.Equal(t, "testworkstation", r.Header.Get("X-NTLM-Workstation"))
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status": "ntlm_configured"}`))
-	}))
-	defer server.Close()
-
-	config := Config{
-		URL:         server.URL,
-		Method:      GET,
-		ContentType: ContentTypeJSON,
-		AuthType:    AuthTypeNTLM,
-		AuthConfig: AuthNTLMConfig{
-			Username:    "testuser",
-			Password:    "testpass",
-			Domain:      "testdomain",
-			Workstation: "testworkstation",
-		},
-	}
-
-	client := resty.New()
-	httpClient := NewHTTP(config, SetClient(&option{client: client}))
-
-	result, err := httpClient.Check(context.Background())
-	require.NoError(t, err)
-	require.NotNil(t, result)
-
-	response, ok := result.(*resty.Response)
-	require.True(t, ok)
-	assert.Equal(t, http.StatusOK, response.StatusCode())
-	assert.Equal(t, `{"status": "ntlm_configured"}`, string(response.Body()))
-}
-
 // Helper function to generate test certificates
 func generateTestCert() ([]byte, []byte, error) {
 	priv, err := rsa.GenerateKey(rand.Reader, 2048)
@@ -221,3 +198,48 @@ func randomInt() *big.Int {
 	serialNumber, _ := rand.Int(rand.Reader, max)
 	return serialNumber
 }
+
+func TestConfig_UnmarshalJSON(t *testing.T) {
+	monitor, err := status_neko.Build([]byte(`{
+		"type": "http",
+		"url": "http://example.com",
+		"method": "POST",
+		"auth_type": "Basic",
+		"auth_config": {"username": "testuser", "password": "testpass"}
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, providerHttpName, monitor.Name())
+
+	h := monitor.(*HTTP)
+	assert.Equal(t, "http://example.com", h.config.URL)
+	assert.Equal(t, POST, h.config.Method)
+	assert.Equal(t, AuthBasicConfig{Username: "testuser", Password: "testpass"}, h.config.AuthConfig)
+
+	var config Config
+	require.NoError(t, json.Unmarshal([]byte(`{"auth_type": "NTLM", "auth_config": {"domain": "testdomain"}}`), &config))
+	assert.Equal(t, AuthNTLMConfig{Domain: "testdomain"}, config.AuthConfig)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"url": "http://example.com"}`), &config))
+	assert.Nil(t, config.AuthConfig)
+
+	err = json.Unmarshal([]byte(`{"auth_type": "Basic", "auth_config": {"username": 1}}`), &config)
+	require.Error(t, err)
+	assert.Equal(t, "auth_config.username", status_neko.Fields(err)[0].Field)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	for _, url := range []string{"", "example.com", "ftp://example.com", "http://"} {
+		_, err := status_neko.Build([]byte(`{"type": "http", "url": "` + url + `"}`))
+		fields := status_neko.Fields(err)
+		require.Len(t, fields, 1, url)
+		assert.Equal(t, "url", fields[0].Field)
+	}
+}
+
+func TestDetails_Measurements(t *testing.T) {
+	d := &Details{StatusCode: http.StatusNotFound, Body: []byte("not found")}
+	assert.Equal(t, map[string]float64{
+		"status_code":    404,
+		"content_length": 9,
+	}, d.Measurements())
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is the subset of JSONPath used by assertions:
+//
+//	$.data.items[0].name   keys and indexes, negative indexes count from the end
+//	$['content-type']      quoted keys
+//	$.checks[*].status     wildcards over arrays and objects
+type jsonPath []pathStep
+
+type pathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func parseJSONPath(s string) (jsonPath, error) {
+	if !strings.HasPrefix(s, "$") {
+		return nil, fmt.Errorf("must start with $")
+	}
+
+	var path jsonPath
+	rest := s[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			if key == "" {
+				return nil, fmt.Errorf("empty key in %q", s)
+			}
+			path = append(path, pathStep{key: key, wildcard: key == "*"})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in %q", s)
+			}
+			step, err := parseBracket(rest[1:end])
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, step)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q in %q", rest[0], s)
+		}
+	}
+	return path, nil
+}
+
+func parseBracket(s string) (pathStep, error) {
+	switch {
+	case s == "*":
+		return pathStep{wildcard: true}, nil
+	case len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0]:
+		return pathStep{key: s[1 : len(s)-1]}, nil
+	}
+	index, err := strconv.Atoi(s)
+	if err != nil {
+		return pathStep{}, fmt.Errorf("invalid index [%s]", s)
+	}
+	return pathStep{index: index, isIndex: true}, nil
+}
+
+// lookup returns the values matched by the path in a document decoded by encoding/json.
+func (p jsonPath) lookup(v interface{}) []interface{} {
+	values := []interface{}{v}
+	for _, step := range p {
+		var next []interface{}
+		for _, v := range values {
+			switch v := v.(type) {
+			case map[string]interface{}:
+				if step.wildcard {
+					for _, child := range v {
+						next = append(next, child)
+					}
+				} else if child, ok := v[step.key]; ok && !step.isIndex {
+					next = append(next, child)
+				}
+			case []interface{}:
+				switch {
+				case step.wildcard:
+					next = append(next, v...)
+				case step.isIndex:
+					i := step.index
+					if i < 0 {
+						i += len(v)
+					}
+					if i >= 0 && i < len(v) {
+						next = append(next, v[i])
+					}
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
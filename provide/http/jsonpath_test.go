@@ -0,0 +1,47 @@
+package http
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONPath(t *testing.T) {
+	var doc interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"status": "ok",
+		"content-type": "json",
+		"data": {"items": [{"name": "a"}, {"name": "b"}, {"name": "c"}]},
+		"checks": {"db": {"status": "up"}}
+	}`), &doc))
+
+	tests := []struct {
+		path string
+		want []interface{}
+	}{
+		{path: "$", want: []interface{}{doc}},
+		{path: "$.status", want: []interface{}{"ok"}},
+		{path: "$['content-type']", want: []interface{}{"json"}},
+		{path: "$.data.items[1].name", want: []interface{}{"b"}},
+		{path: "$.data.items[-1].name", want: []interface{}{"c"}},
+		{path: "$.data.items[*].name", want: []interface{}{"a", "b", "c"}},
+		{path: "$.checks.*.status", want: []interface{}{"up"}},
+		{path: "$.data.items[3]", want: nil},
+		{path: "$.missing.key", want: nil},
+		{path: "$.status[0]", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, err := parseJSONPath(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, path.lookup(doc))
+		})
+	}
+
+	for _, invalid := range []string{"status", "$.", "$[0", "$[x]", "$x"} {
+		_, err := parseJSONPath(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
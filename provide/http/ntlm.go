@@ -0,0 +1,220 @@
+package http
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/Azure/go-ntlmssp"
+	"golang.org/x/crypto/md4"
+)
+
+var (
+	ntlmSignature = []byte("NTLMSSP\x00")
+
+	ntlmNegotiateUnicode uint32 = 0x00000001
+	ntlmNegotiateVersion uint32 = 0x02000000
+	ntlmNegotiateKeyExch uint32 = 0x40000000
+)
+
+// ntlmTransport authenticates the requests with NTLMv2 (MS-NLMP): the
+// NEGOTIATE message carries the domain and workstation, the server answers
+// 401 with a CHALLENGE, and the request is sent again with the AUTHENTICATE
+// message on the same connection.
+type ntlmTransport struct {
+	config    AuthNTLMConfig
+	transport http.RoundTripper
+}
+
+func newNTLMTransport(config AuthNTLMConfig, transport http.RoundTripper) *ntlmTransport {
+	// DOMAIN\user 形式的用户名
+	if domain, user, ok := strings.Cut(config.Username, `\`); ok && config.Domain == "" {
+		config.Domain, config.Username = domain, user
+	}
+	return &ntlmTransport{config: config, transport: transport}
+}
+
+func (t *ntlmTransport) CloseIdleConnections() {
+	closeIdleConnections(t.transport)
+}
+
+func (t *ntlmTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	negotiate, err := ntlmssp.NewNegotiateMessage(t.config.Domain, t.config.Workstation)
+	if err != nil {
+		return nil, err
+	}
+	res, err := t.transport.RoundTrip(withAuthorization(req, body, "NTLM "+base64.StdEncoding.EncodeToString(negotiate)))
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+
+	scheme, challenge := ntlmChallenge(res.Header.Values("Www-Authenticate"))
+	if challenge == nil {
+		// 服务器不支持 NTLM, 交给调用方处理 401
+		return res, nil
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	authenticate, err := t.authenticate(challenge, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("ntlm: %w", err)
+	}
+	return t.transport.RoundTrip(withAuthorization(req, body, scheme+" "+base64.StdEncoding.EncodeToString(authenticate)))
+}
+
+// ntlmChallenge returns the scheme and the decoded CHALLENGE message of a
+// WWW-Authenticate header, nil when there is none.
+func ntlmChallenge(values []string) (string, []byte) {
+	for _, v := range values {
+		scheme, data, _ := strings.Cut(v, " ")
+		if !strings.EqualFold(scheme, "NTLM") && !strings.EqualFold(scheme, "Negotiate") {
+			continue
+		}
+		b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+		if err == nil && len(b) > 0 {
+			return scheme, b
+		}
+	}
+	return "", nil
+}
+
+// authenticate builds the AUTHENTICATE message answering a CHALLENGE message.
+func (t *ntlmTransport) authenticate(challenge []byte, now time.Time) ([]byte, error) {
+	if len(challenge) < 32 || !bytes.Equal(challenge[:8], ntlmSignature) || binary.LittleEndian.Uint32(challenge[8:]) != 2 {
+		return nil, errors.New("invalid challenge message")
+	}
+	flags := binary.LittleEndian.Uint32(challenge[20:])
+	if flags&ntlmNegotiateUnicode == 0 {
+		return nil, errors.New("only unicode is supported")
+	}
+	serverChallenge := challenge[24:32]
+	var targetInfo []byte
+	if len(challenge) >= 48 {
+		var err error
+		if targetInfo, err = ntlmField(challenge, 40); err != nil {
+			return nil, err
+		}
+	}
+
+	clientChallenge := make([]byte, 8)
+	if _, err := rand.Read(clientChallenge); err != nil {
+		return nil, err
+	}
+
+	hash := ntowfv2(t.config.Password, t.config.Username, t.config.Domain)
+	nt := ntlmv2Response(hash, serverChallenge, clientChallenge, filetime(now), targetInfo)
+	// 有 target info 时 LMv2 响应为全 0 (MS-NLMP 3.3.2)
+	lm := make([]byte, 24)
+	if targetInfo == nil {
+		lm = append(hmacMD5(hash, serverChallenge, clientChallenge), clientChallenge...)
+	}
+
+	fields := [][]byte{lm, nt, utf16le(t.config.Domain), utf16le(t.config.Username), utf16le(t.config.Workstation), nil}
+	offset := 64
+	msg := make([]byte, 64)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 3)
+	for i, f := range fields {
+		binary.LittleEndian.PutUint16(msg[12+i*8:], uint16(len(f)))
+		binary.LittleEndian.PutUint16(msg[14+i*8:], uint16(len(f)))
+		binary.LittleEndian.PutUint32(msg[16+i*8:], uint32(offset))
+		offset += len(f)
+	}
+	// 不发送 version 和 session key
+	binary.LittleEndian.PutUint32(msg[60:], flags&^(ntlmNegotiateVersion|ntlmNegotiateKeyExch))
+	for _, f := range fields {
+		msg = append(msg, f...)
+	}
+	return msg, nil
+}
+
+// ntlmField reads the payload of the field whose length/offset header starts at i.
+func ntlmField(msg []byte, i int) ([]byte, error) {
+	length := int(binary.LittleEndian.Uint16(msg[i:]))
+	offset := int(binary.LittleEndian.Uint32(msg[i+4:]))
+	if length == 0 {
+		return nil, nil
+	}
+	if offset+length > len(msg) {
+		return nil, errors.New("invalid challenge message")
+	}
+	return msg[offset : offset+length], nil
+}
+
+// ntowfv2 is the NTLMv2 hash of the password, MS-NLMP 3.3.2.
+func ntowfv2(password, user, domain string) []byte {
+	h := md4.New()
+	h.Write(utf16le(password))
+	return hmacMD5(h.Sum(nil), utf16le(strings.ToUpper(user)+domain))
+}
+
+// ntlmv2Response is NTProofStr followed by the temp blob, MS-NLMP 3.3.2.
+func ntlmv2Response(hash, serverChallenge, clientChallenge, timestamp, targetInfo []byte) []byte {
+	temp := []byte{1, 1, 0, 0, 0, 0, 0, 0}
+	temp = append(temp, timestamp...)
+	temp = append(temp, clientChallenge...)
+	temp = append(temp, 0, 0, 0, 0)
+	temp = append(temp, targetInfo...)
+	temp = append(temp, 0, 0, 0, 0)
+	return append(hmacMD5(hash, serverChallenge, temp), temp...)
+}
+
+// filetime is t in 100ns intervals since 1601-01-01, little endian.
+func filetime(t time.Time) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, uint64(t.UnixNano()/100+116444736000000000))
+	return b
+}
+
+func hmacMD5(key []byte, data ...[]byte) []byte {
+	h := hmac.New(md5.New, key)
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+func utf16le(s string) []byte {
+	u := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(u))
+	for i, v := range u {
+		binary.LittleEndian.PutUint16(b[2*i:], v)
+	}
+	return b
+}
+
+// readBody reads the body of req so that it can be sent more than once.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	return io.ReadAll(req.Body)
+}
+
+// withAuthorization returns a copy of req with body and the Authorization header.
+func withAuthorization(req *http.Request, body []byte, authorization string) *http.Request {
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", authorization)
+	if body != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+	}
+	return r
+}
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MS-NLMP 4.2.4 NTLMv2 Authentication
+func TestNTLMv2(t *testing.T) {
+	hash := ntowfv2("Password", "User", "Domain")
+	assert.Equal(t, "0c868a403bfd7a93a3001ef22ef02e3f", hex.EncodeToString(hash))
+
+	serverChallenge, _ := hex.DecodeString("0123456789abcdef")
+	clientChallenge, _ := hex.DecodeString("aaaaaaaaaaaaaaaa")
+	targetInfo := avPairs(map[uint16]string{2: "Domain", 1: "Server"})
+	response := ntlmv2Response(hash, serverChallenge, clientChallenge, make([]byte, 8), targetInfo)
+	assert.Equal(t, "68cd0ab851e51c96aabc927bebef6a1c", hex.EncodeToString(response[:16]))
+}
+
+// avPairs encodes the AV_PAIRs in the order NbDomainName, NbComputerName, EOL.
+func avPairs(pairs map[uint16]string) []byte {
+	var b []byte
+	for _, id := range []uint16{2, 1} {
+		v, ok := pairs[id]
+		if !ok {
+			continue
+		}
+		value := utf16le(v)
+		b = binary.LittleEndian.AppendUint16(b, id)
+		b = binary.LittleEndian.AppendUint16(b, uint16(len(value)))
+		b = append(b, value...)
+	}
+	return append(b, 0, 0, 0, 0)
+}
+
+func fromUTF16(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(u))
+}
+
+// ntlmServer accepts the password for CORP\neko and records what the client sent.
+type ntlmServer struct {
+	negotiate                   []byte
+	domain, user, workstation   string
+	serverChallenge, targetInfo []byte
+}
+
+func (s *ntlmServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	scheme, data, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	msg, _ := base64.StdEncoding.DecodeString(data)
+	if scheme != "NTLM" || len(msg) < 12 || !bytes.Equal(msg[:8], ntlmSignature) {
+		w.Header().Set("WWW-Authenticate", "NTLM")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch binary.LittleEndian.Uint32(msg[8:]) {
+	case 1:
+		s.negotiate = msg
+		challenge := make([]byte, 48)
+		copy(challenge, ntlmSignature)
+		binary.LittleEndian.PutUint32(challenge[8:], 2)
+		binary.LittleEndian.PutUint32(challenge[20:], ntlmNegotiateUnicode)
+		copy(challenge[24:], s.serverChallenge)
+		binary.LittleEndian.PutUint16(challenge[40:], uint16(len(s.targetInfo)))
+		binary.LittleEndian.PutUint16(challenge[42:], uint16(len(s.targetInfo)))
+		binary.LittleEndian.PutUint32(challenge[44:], 48)
+		challenge = append(challenge, s.targetInfo...)
+		w.Header().Set("WWW-Authenticate", "NTLM "+base64.StdEncoding.EncodeToString(challenge))
+		w.WriteHeader(http.StatusUnauthorized)
+	case 3:
+		field := func(i int) []byte {
+			b, _ := ntlmField(msg, i)
+			return b
+		}
+		nt := field(20)
+		s.domain, s.user, s.workstation = fromUTF16(field(28)), fromUTF16(field(36)), fromUTF16(field(44))
+
+		hash := ntowfv2("secret", s.user, s.domain)
+		proof := hmacMD5(hash, s.serverChallenge, nt[16:])
+		if !bytes.Equal(proof, nt[:16]) || !bytes.Contains(nt[16:], s.targetInfo) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("welcome " + s.domain + `\` + s.user))
+	}
+}
+
+func TestHTTP_CheckWithNTLM(t *testing.T) {
+	s := &ntlmServer{
+		serverChallenge: []byte("8bytes!!"),
+		targetInfo:      avPairs(map[uint16]string{2: "CORP", 1: "WEB01"}),
+	}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	for _, tt := range []struct {
+		name   string
+		config AuthNTLMConfig
+	}{
+		{name: "domain", config: AuthNTLMConfig{Username: "neko", Password: "secret", Domain: "CORP", Workstation: "ws01"}},
+		{name: "domain in username", config: AuthNTLMConfig{Username: `CORP\neko`, Password: "secret", Workstation: "ws01"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewHTTP(Config{
+				URL:        server.URL,
+				Method:     POST,
+				Body:       "payload",
+				AuthType:   AuthTypeNTLM,
+				AuthConfig: tt.config,
+			}).Check(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, `welcome CORP\neko`, string(result.Details.(*Details).Body))
+			assert.Equal(t, "ws01", s.workstation)
+			// NEGOTIATE 中带有 domain 和 workstation
+			assert.True(t, bytes.HasSuffix(s.negotiate, []byte("CORPWS01")))
+		})
+	}
+
+	_, err := NewHTTP(Config{
+		URL:        server.URL,
+		Method:     GET,
+		AuthType:   AuthTypeNTLM,
+		AuthConfig: AuthNTLMConfig{Username: "neko", Password: "wrong", Domain: "CORP"},
+	}).Check(context.Background())
+	assert.ErrorContains(t, err, "status_code: expected 200-299, got 401")
+}
+
+func TestNTLMTransport_InvalidChallenge(t *testing.T) {
+	tr := newNTLMTransport(AuthNTLMConfig{Username: "neko"}, nil)
+	_, err := tr.authenticate([]byte("garbage"), time.Now())
+	assert.Error(t, err)
+}
@@ -0,0 +1,229 @@
+package http
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"golang.org/x/net/proxy"
+)
+
+var (
+	_ proxy.ContextDialer = (*socks4)(nil)
+
+	proxyDialer = &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	// proxySchemes are the schemes proxy_address may have for each proxy_type
+	proxySchemes = map[ProxyType][]string{
+		ProxyTypeHTTP:       {"http"},
+		ProxyTypeHTTPS:      {"https"},
+		ProxyTypeSocks:      {"socks", "socks5"},
+		ProxyTypeSocksV5:    {"socks", "socks5"},
+		ProxyTypeSocksV5DNS: {"socks5h", "socks5"},
+		ProxyTypeSocksV4:    {"socks4", "socks4a"},
+	}
+)
+
+// setProxy makes transport reach the servers through the proxy of config:
+//
+//	HTTP       plain requests are sent to the proxy, https uses CONNECT
+//	HTTPS      the same over TLS to the proxy
+//	SOCKS5     SOCKS (the same) resolves the host locally
+//	SOCKS5DNS  the proxy resolves the host
+//	SOCKS4     resolves the host locally to an IPv4 address, the username is sent as user id;
+//	           with a socks4a:// proxy_address the proxy resolves the host (SOCKS4a)
+//
+// ProxyAuthConfig, or else the userinfo of a proxy_address URL, is sent as
+// Proxy-Authorization to HTTP(S) proxies and as username/password
+// authentication to SOCKS5 proxies.
+func setProxy(transport *http.Transport, config Config) error {
+	if config.ProxyType == ProxyTypeNone {
+		return nil
+	}
+	if config.ProxyAddress == "" {
+		return &status_neko.FieldError{Field: "proxy_address", Message: "is required"}
+	}
+	var auth *ProxyAuthConfig
+	if config.ProxyAuthEnabled && config.ProxyAuthConfig != (ProxyAuthConfig{}) {
+		auth = &config.ProxyAuthConfig
+	}
+	address, scheme := config.ProxyAddress, ""
+	if strings.Contains(address, "://") {
+		u, err := url.Parse(address)
+		if err != nil || u.Host == "" {
+			return &status_neko.FieldError{Field: "proxy_address", Message: "must be host:port or a URL"}
+		}
+		if schemes, ok := proxySchemes[config.ProxyType]; ok && !contains(schemes, u.Scheme) {
+			return &status_neko.FieldError{Field: "proxy_address", Message: fmt.Sprintf("scheme %s does not match proxy_type %s", u.Scheme, config.ProxyType)}
+		}
+		if auth == nil && u.User != nil {
+			password, _ := u.User.Password()
+			auth = &ProxyAuthConfig{Username: u.User.Username(), Password: password}
+		}
+		address, scheme = u.Host, u.Scheme
+	}
+
+	switch config.ProxyType {
+	case ProxyTypeHTTP, ProxyTypeHTTPS:
+		u := &url.URL{Scheme: "http", Host: address}
+		if config.ProxyType == ProxyTypeHTTPS {
+			u.Scheme = "https"
+		}
+		if auth != nil {
+			u.User = url.UserPassword(auth.Username, auth.Password)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	case ProxyTypeSocks, ProxyTypeSocksV5, ProxyTypeSocksV5DNS:
+		var a *proxy.Auth
+		if auth != nil {
+			a = &proxy.Auth{User: auth.Username, Password: auth.Password}
+		}
+		dialer, err := proxy.SOCKS5("tcp", address, a, proxyDialer)
+		if err != nil {
+			return &status_neko.FieldError{Field: "proxy_address", Message: err.Error()}
+		}
+		socks5 := dialer.(proxy.ContextDialer)
+		remoteDNS := config.ProxyType == ProxyTypeSocksV5DNS
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if !remoteDNS {
+				var err error
+				if addr, err = resolve(ctx, addr, false); err != nil {
+					return nil, err
+				}
+			}
+			return socks5.DialContext(ctx, network, addr)
+		}
+	case ProxyTypeSocksV4:
+		s := &socks4{address: address, remoteDNS: scheme == "socks4a"}
+		if auth != nil {
+			s.userID = auth.Username
+		}
+		transport.Proxy = nil
+		transport.DialContext = s.DialContext
+	default:
+		return &status_neko.FieldError{Field: "proxy_type", Message: fmt.Sprintf("unknown proxy type %q", config.ProxyType)}
+	}
+	return nil
+}
+
+// resolve replaces the host of addr by its IP address, preferring IPv4.
+func resolve(ctx context.Context, addr string, ipv4 bool) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if ipv4 && ip.To4() == nil {
+			return "", fmt.Errorf("%s is not an IPv4 address", host)
+		}
+		return addr, nil
+	}
+
+	network := "ip"
+	if ipv4 {
+		network = "ip4"
+	}
+	ips, err := net.DefaultResolver.LookupIP(ctx, network, host)
+	if err != nil {
+		return "", err
+	}
+	ip := ips[0]
+	for _, v := range ips {
+		if v.To4() != nil {
+			ip = v
+			break
+		}
+	}
+	return net.JoinHostPort(ip.String(), port), nil
+}
+
+// socks4 dials through a SOCKS4 proxy, or a SOCKS4a proxy resolving the
+// host names when remoteDNS is set.
+type socks4 struct {
+	address   string
+	userID    string
+	remoteDNS bool
+}
+
+func (s *socks4) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	// SOCKS4a: DSTIP 0.0.0.x 表示主机名跟在 USERID 之后, 由代理解析
+	ip, domain := net.ParseIP(host).To4(), ""
+	if ip == nil {
+		if s.remoteDNS {
+			ip, domain = net.IPv4(0, 0, 0, 1).To4(), host
+		} else {
+			if addr, err = resolve(ctx, addr, true); err != nil {
+				return nil, err
+			}
+			host, _, _ = net.SplitHostPort(addr)
+			ip = net.ParseIP(host).To4()
+		}
+	}
+	p, err := net.LookupPort(network, port)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := proxyDialer.DialContext(ctx, "tcp", s.address)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	// VN=4 CD=1 (CONNECT) DSTPORT DSTIP USERID NULL [DOMAIN NULL]
+	req := []byte{4, 1, 0, 0}
+	binary.BigEndian.PutUint16(req[2:], uint16(p))
+	req = append(req, ip...)
+	req = append(req, s.userID...)
+	req = append(req, 0)
+	if domain != "" {
+		req = append(req, domain...)
+		req = append(req, 0)
+	}
+	if _, err := conn.Write(req); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("socks4 %s: %w", s.address, err)
+	}
+
+	var resp [8]byte
+	if _, err := io.ReadFull(conn, resp[:]); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("socks4 %s: %w", s.address, err)
+	}
+	if resp[1] != 0x5a {
+		conn.Close()
+		return nil, fmt.Errorf("socks4 %s: %w", s.address, socks4Error(resp[1]))
+	}
+	return conn, nil
+}
+
+func socks4Error(code byte) error {
+	switch code {
+	case 0x5b:
+		return errors.New("request rejected or failed")
+	case 0x5c:
+		return errors.New("request rejected, identd unreachable")
+	case 0x5d:
+		return errors.New("request rejected, user id mismatch")
+	}
+	return fmt.Errorf("unknown reply code 0x%x", code)
+}
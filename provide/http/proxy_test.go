@@ -0,0 +1,375 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// proxyLog records what a test proxy was asked for.
+type proxyLog struct {
+	mu           sync.Mutex
+	destinations []string
+	credentials  []string
+}
+
+func (l *proxyLog) add(destination, credential string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.destinations = append(l.destinations, destination)
+	l.credentials = append(l.credentials, credential)
+}
+
+func (l *proxyLog) last() (string, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.destinations) == 0 {
+		return "", ""
+	}
+	return l.destinations[len(l.destinations)-1], l.credentials[len(l.credentials)-1]
+}
+
+func pipe(a, b net.Conn) {
+	go func() {
+		io.Copy(a, b)
+		a.Close()
+	}()
+	io.Copy(b, a)
+	b.Close()
+}
+
+// serveProxy accepts connections on a local listener until the test ends.
+func serveProxy(t *testing.T, handle func(conn net.Conn)) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go handle(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// socks5Proxy connects every request to backend, it requires user:pass
+// when user is not empty.
+func socks5Proxy(t *testing.T, backend, user, pass string, log *proxyLog) string {
+	return serveProxy(t, func(conn net.Conn) {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+
+		var greeting [2]byte
+		if _, err := io.ReadFull(r, greeting[:]); err != nil {
+			return
+		}
+		if _, err := io.CopyN(io.Discard, r, int64(greeting[1])); err != nil {
+			return
+		}
+		credential := ""
+		if user == "" {
+			conn.Write([]byte{5, 0})
+		} else {
+			conn.Write([]byte{5, 2})
+			version, _ := r.ReadByte()
+			n, _ := r.ReadByte()
+			u := make([]byte, n)
+			io.ReadFull(r, u)
+			n, _ = r.ReadByte()
+			p := make([]byte, n)
+			io.ReadFull(r, p)
+			credential = string(u) + ":" + string(p)
+			if version != 1 || string(u) != user || string(p) != pass {
+				conn.Write([]byte{1, 1})
+				return
+			}
+			conn.Write([]byte{1, 0})
+		}
+
+		var req [4]byte
+		if _, err := io.ReadFull(r, req[:]); err != nil {
+			return
+		}
+		var host string
+		switch req[3] {
+		case 1, 4:
+			ip := make([]byte, map[byte]int{1: 4, 4: 16}[req[3]])
+			io.ReadFull(r, ip)
+			host = net.IP(ip).String()
+		case 3:
+			n, _ := r.ReadByte()
+			name := make([]byte, n)
+			io.ReadFull(r, name)
+			host = string(name)
+		}
+		var port [2]byte
+		io.ReadFull(r, port[:])
+		log.add(net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port[:])))), credential)
+
+		upstream, err := net.Dial("tcp", backend)
+		if err != nil {
+			return
+		}
+		conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+		pipe(&bufferedConn{Conn: conn, r: r}, upstream)
+	})
+}
+
+func socks4Proxy(t *testing.T, backend string, log *proxyLog) string {
+	return serveProxy(t, func(conn net.Conn) {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+
+		var req [8]byte
+		if _, err := io.ReadFull(r, req[:]); err != nil || req[0] != 4 || req[1] != 1 {
+			return
+		}
+		userID, err := r.ReadString(0)
+		if err != nil {
+			return
+		}
+		port := binary.BigEndian.Uint16(req[2:4])
+		host := net.IP(req[4:8]).String()
+		if req[4] == 0 && req[5] == 0 && req[6] == 0 && req[7] != 0 {
+			// SOCKS4a, 主机名跟在 USERID 之后
+			domain, err := r.ReadString(0)
+			if err != nil {
+				return
+			}
+			host = strings.TrimSuffix(domain, "\x00")
+		}
+		log.add(net.JoinHostPort(host, strconv.Itoa(int(port))), strings.TrimSuffix(userID, "\x00"))
+
+		upstream, err := net.Dial("tcp", backend)
+		if err != nil {
+			conn.Write([]byte{0, 0x5b, 0, 0, 0, 0, 0, 0})
+			return
+		}
+		conn.Write([]byte{0, 0x5a, 0, 0, 0, 0, 0, 0})
+		pipe(&bufferedConn{Conn: conn, r: r}, upstream)
+	})
+}
+
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// httpProxy answers plain requests itself and tunnels CONNECT to backend.
+func httpProxy(backend string, log *proxyLog) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.add(r.Host, r.Header.Get("Proxy-Authorization"))
+		if r.Method != http.MethodConnect {
+			w.Write([]byte("proxied " + r.URL.String()))
+			return
+		}
+
+		upstream, err := net.Dial("tcp", backend)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		rw.Flush()
+		pipe(&bufferedConn{Conn: conn, r: rw.Reader}, upstream)
+	})
+}
+
+func TestHTTP_CheckProxy(t *testing.T) {
+	// backend 记录收到的 Proxy-Authorization, 它不应该被转发给目标服务器
+	var leaked sync.Map
+	backendHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v := r.Header.Get("Proxy-Authorization"); v != "" {
+			leaked.Store(r.URL.Path, v)
+		}
+		w.Write([]byte("backend " + r.URL.Path))
+	})
+	backend := httptest.NewServer(backendHandler)
+	defer backend.Close()
+	tlsBackend := httptest.NewTLSServer(backendHandler)
+	defer tlsBackend.Close()
+	backendAddr := backend.Listener.Addr().String()
+	_, backendPort, _ := net.SplitHostPort(backendAddr)
+
+	auth := ProxyAuthConfig{Username: "user", Password: "pass"}
+	const basic = "Basic dXNlcjpwYXNz"
+
+	var httpLog, httpsLog, socks5Log, socks5AuthLog, socks4Log proxyLog
+	httpServer := httptest.NewServer(httpProxy(backendAddr, &httpLog))
+	defer httpServer.Close()
+	httpsServer := httptest.NewTLSServer(httpProxy(tlsBackend.Listener.Addr().String(), &httpsLog))
+	defer httpsServer.Close()
+	socks5Addr := socks5Proxy(t, backendAddr, "", "", &socks5Log)
+	socks5AuthAddr := socks5Proxy(t, backendAddr, "user", "pass", &socks5AuthLog)
+	socks4Addr := socks4Proxy(t, backendAddr, &socks4Log)
+
+	tests := []struct {
+		name        string
+		config      Config
+		body        string
+		wantErr     bool
+		log         *proxyLog
+		destination string
+		credential  string
+	}{
+		{
+			name:        "http",
+			config:      Config{URL: "http://upstream.test/a", ProxyType: ProxyTypeHTTP, ProxyAddress: httpServer.URL, ProxyAuthEnabled: true, ProxyAuthConfig: auth},
+			body:        "proxied http://upstream.test/a",
+			log:         &httpLog,
+			destination: "upstream.test",
+			credential:  basic,
+		},
+		{
+			name:        "http auth in url",
+			config:      Config{URL: "http://upstream.test/a2", ProxyType: ProxyTypeHTTP, ProxyAddress: "http://user:pass@" + httpServer.Listener.Addr().String()},
+			body:        "proxied http://upstream.test/a2",
+			log:         &httpLog,
+			destination: "upstream.test",
+			credential:  basic,
+		},
+		{
+			name:        "http without auth",
+			config:      Config{URL: "http://upstream.test/b", ProxyType: ProxyTypeHTTP, ProxyAddress: httpServer.Listener.Addr().String()},
+			body:        "proxied http://upstream.test/b",
+			log:         &httpLog,
+			destination: "upstream.test",
+		},
+		{
+			name:        "https connect",
+			config:      Config{URL: "https://secure.test/c", ProxyType: ProxyTypeHTTPS, ProxyAddress: httpsServer.Listener.Addr().String(), ProxyAuthEnabled: true, ProxyAuthConfig: auth, SkipCertificateExpires: true},
+			body:        "backend /c",
+			log:         &httpsLog,
+			destination: "secure.test:443",
+			credential:  basic,
+		},
+		{
+			name:        "socks5 resolves locally",
+			config:      Config{URL: "http://localhost:" + backendPort + "/d", ProxyType: ProxyTypeSocksV5, ProxyAddress: socks5Addr},
+			body:        "backend /d",
+			log:         &socks5Log,
+			destination: "127.0.0.1:" + backendPort,
+		},
+		{
+			name:        "socks",
+			config:      Config{URL: "http://localhost:" + backendPort + "/e", ProxyType: ProxyTypeSocks, ProxyAddress: "socks5://" + socks5Addr},
+			body:        "backend /e",
+			log:         &socks5Log,
+			destination: "127.0.0.1:" + backendPort,
+		},
+		{
+			name:        "socks5 remote dns",
+			config:      Config{URL: "http://backend.test/f", ProxyType: ProxyTypeSocksV5DNS, ProxyAddress: socks5Addr},
+			body:        "backend /f",
+			log:         &socks5Log,
+			destination: "backend.test:80",
+		},
+		{
+			name:        "socks5 auth",
+			config:      Config{URL: "http://backend.test/g", ProxyType: ProxyTypeSocksV5DNS, ProxyAddress: socks5AuthAddr, ProxyAuthEnabled: true, ProxyAuthConfig: auth},
+			body:        "backend /g",
+			log:         &socks5AuthLog,
+			destination: "backend.test:80",
+			credential:  "user:pass",
+		},
+		{
+			name:        "socks5 auth in url",
+			config:      Config{URL: "http://backend.test/g2", ProxyType: ProxyTypeSocksV5DNS, ProxyAddress: "socks5h://user:pass@" + socks5AuthAddr},
+			body:        "backend /g2",
+			log:         &socks5AuthLog,
+			destination: "backend.test:80",
+			credential:  "user:pass",
+		},
+		{
+			name:    "socks5 wrong password",
+			config:  Config{URL: "http://backend.test/h", ProxyType: ProxyTypeSocksV5DNS, ProxyAddress: socks5AuthAddr, ProxyAuthEnabled: true, ProxyAuthConfig: ProxyAuthConfig{Username: "user", Password: "wrong"}},
+			wantErr: true,
+		},
+		{
+			name:        "socks4",
+			config:      Config{URL: "http://localhost:" + backendPort + "/i", ProxyType: ProxyTypeSocksV4, ProxyAddress: socks4Addr, ProxyAuthEnabled: true, ProxyAuthConfig: auth},
+			body:        "backend /i",
+			log:         &socks4Log,
+			destination: "127.0.0.1:" + backendPort,
+			credential:  "user",
+		},
+		{
+			name:        "socks4a remote dns",
+			config:      Config{URL: "http://backend.test/j", ProxyType: ProxyTypeSocksV4, ProxyAddress: "socks4a://user@" + socks4Addr},
+			body:        "backend /j",
+			log:         &socks4Log,
+			destination: "backend.test:80",
+			credential:  "user",
+		},
+		{
+			name:        "socks4 resolves locally",
+			config:      Config{URL: "http://localhost:" + backendPort + "/k", ProxyType: ProxyTypeSocksV4, ProxyAddress: "socks4://" + socks4Addr},
+			body:        "backend /k",
+			log:         &socks4Log,
+			destination: "127.0.0.1:" + backendPort,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Method = GET
+			result, err := NewHTTP(tt.config).Check(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(result.Details.(*Details).Body))
+
+			destination, credential := tt.log.last()
+			assert.Equal(t, tt.destination, destination)
+			assert.Equal(t, tt.credential, credential)
+		})
+	}
+
+	leaked.Range(func(path, value any) bool {
+		t.Errorf("Proxy-Authorization %s sent to the server for %s", value, path)
+		return true
+	})
+}
+
+func TestConfig_ValidateProxy(t *testing.T) {
+	for _, tt := range []struct {
+		config string
+		field  string
+	}{
+		{config: `"proxy_type": "SOCKS5"`, field: "proxy_address"},
+		{config: `"proxy_type": "FTP", "proxy_address": "127.0.0.1:1080"`, field: "proxy_type"},
+		{config: `"proxy_type": "HTTP", "proxy_address": "http://"`, field: "proxy_address"},
+		{config: `"proxy_type": "HTTP", "proxy_address": "socks5://127.0.0.1:1080"`, field: "proxy_address"},
+	} {
+		_, err := status_neko.Build([]byte(`{"type": "http", "url": "http://example.com", ` + tt.config + `}`))
+		fields := status_neko.Fields(err)
+		require.Len(t, fields, 1, tt.config)
+		assert.Equal(t, tt.field, fields[0].Field)
+	}
+}
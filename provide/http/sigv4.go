@@ -0,0 +1,174 @@
+package http
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat = "20060102T150405Z"
+)
+
+// sigV4Transport signs the requests with AWS Signature Version 4, the host,
+// the content type and the x-amz-* headers are signed.
+type sigV4Transport struct {
+	config    AuthAWSSigV4Config
+	transport http.RoundTripper
+	now       func() time.Time
+}
+
+func (t *sigV4Transport) CloseIdleConnections() {
+	closeIdleConnections(t.transport)
+}
+
+func (t *sigV4Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	r := req.Clone(req.Context())
+	if body != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+	}
+	t.sign(r, body, t.now())
+	return t.transport.RoundTrip(r)
+}
+
+func (t *sigV4Transport) sign(r *http.Request, body []byte, now time.Time) {
+	now = now.UTC()
+	date := now.Format("20060102")
+	r.Header.Set("X-Amz-Date", now.Format(sigV4TimeFormat))
+	if t.config.SessionToken != "" {
+		r.Header.Set("X-Amz-Security-Token", t.config.SessionToken)
+	}
+	payload := sha256Hex(body)
+	// S3 要求 x-amz-content-sha256
+	if t.config.Service == "s3" {
+		r.Header.Set("X-Amz-Content-Sha256", payload)
+	}
+
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range r.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.Join(strings.Fields(strings.Join(values, ",")), " ")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		t.canonicalURI(r.URL),
+		canonicalQuery(r.URL),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payload,
+	}, "\n")
+
+	scope := strings.Join([]string{date, t.config.Region, t.config.Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		now.Format(sigV4TimeFormat),
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+t.config.SecretAccessKey), date)
+	key = hmacSHA256(key, t.config.Region)
+	key = hmacSHA256(key, t.config.Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	r.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, t.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalURI encodes each path segment, twice except for S3.
+func (t *sigV4Transport) canonicalURI(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if v, err := url.PathUnescape(s); err == nil {
+			s = v
+		}
+		s = sigV4Escape(s)
+		if t.config.Service != "s3" {
+			s = sigV4Escape(s)
+		}
+		segments[i] = s
+	}
+	return strings.Join(segments, "/")
+}
+
+func canonicalQuery(u *url.URL) string {
+	type pair struct{ key, value string }
+	var pairs []pair
+	for key, values := range u.Query() {
+		for _, v := range values {
+			pairs = append(pairs, pair{key: sigV4Escape(key), value: sigV4Escape(v)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].key != pairs[j].key {
+			return pairs[i].key < pairs[j].key
+		}
+		return pairs[i].value < pairs[j].value
+	})
+	query := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		query = append(query, p.key+"="+p.value)
+	}
+	return strings.Join(query, "&")
+}
+
+// sigV4Escape percent-encodes everything but the unreserved characters of RFC 3986.
+func sigV4Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test vectors from the AWS Signature Version 4 test suite and documentation.
+func TestSigV4Transport_Sign(t *testing.T) {
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	config := AuthAWSSigV4Config{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:          "us-east-1",
+	}
+
+	tests := []struct {
+		name    string
+		service string
+		url     string
+		header  map[string]string
+		want    string
+	}{
+		{
+			name:    "get-vanilla",
+			service: "service",
+			url:     "https://example.amazonaws.com/",
+			want:    "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:    "iam list users",
+			service: "iam",
+			url:     "https://iam.amazonaws.com/?Version=2010-05-08&Action=ListUsers",
+			header:  map[string]string{"Content-Type": "application/x-www-form-urlencoded; charset=utf-8"},
+			want:    "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config
+			c.Service = tt.service
+			r, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			require.NoError(t, err)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			(&sigV4Transport{config: c}).sign(r, nil, now)
+			assert.Equal(t, "20150830T123600Z", r.Header.Get("X-Amz-Date"))
+			assert.Equal(t, tt.want, r.Header.Get("Authorization"))
+		})
+	}
+}
+
+func TestSigV4_Canonical(t *testing.T) {
+	s3 := &sigV4Transport{config: AuthAWSSigV4Config{Service: "s3"}}
+	api := &sigV4Transport{config: AuthAWSSigV4Config{Service: "execute-api"}}
+	r, _ := http.NewRequest(http.MethodGet, "https://example.com/my%20bucket/a+b?b=2&a=1&a=0&a-b=x&c=%2F", nil)
+
+	assert.Equal(t, "/my%20bucket/a%2Bb", s3.canonicalURI(r.URL))
+	assert.Equal(t, "/my%2520bucket/a%252Bb", api.canonicalURI(r.URL))
+	assert.Equal(t, "a=0&a=1&a-b=x&b=2&c=%2F", canonicalQuery(r.URL))
+	r, _ = http.NewRequest(http.MethodGet, "https://example.com", nil)
+	assert.Equal(t, "/", api.canonicalURI(r.URL))
+}
+
+func TestHTTP_CheckWithSigV4(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	h := NewHTTP(Config{
+		URL:      server.URL + "/bucket/key",
+		Method:   PUT,
+		Body:     "hello",
+		AuthType: AuthTypeAWSSigV4,
+		AuthConfig: AuthAWSSigV4Config{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "secret",
+			SessionToken:    "token",
+			Region:          "us-east-1",
+			Service:         "s3",
+		},
+	})
+	_, err := h.Check(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, "token", header.Get("X-Amz-Security-Token"))
+	// sha256("hello")
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", header.Get("X-Amz-Content-Sha256"))
+	authorization := header.Get("Authorization")
+	assert.True(t, strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"), authorization)
+	assert.Contains(t, authorization, "/us-east-1/s3/aws4_request, SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date;x-amz-security-token, Signature=")
+}
@@ -0,0 +1,130 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing is the breakdown of the last request of a check, redirects and
+// retries each start a new request.
+type Timing struct {
+	DNSLookup    time.Duration `json:"dns_lookup"`
+	Connect      time.Duration `json:"connect"`
+	TLSHandshake time.Duration `json:"tls_handshake"`
+	// FirstByte 是从请求开始 (获取连接) 到收到第一个响应字节的时间
+	FirstByte time.Duration `json:"first_byte"`
+	// ContentTransfer 是从第一个响应字节到读完响应体的时间
+	ContentTransfer time.Duration `json:"content_transfer"`
+	Total           time.Duration `json:"total"`
+
+	RemoteIP string `json:"remote_ip,omitempty"`
+	// Reused 为 true 时连接来自连接池, 没有 DNS、连接和 TLS 握手的耗时
+	Reused bool `json:"reused"`
+}
+
+// tracer collects the httptrace events of a check.
+type tracer struct {
+	mu     sync.Mutex
+	events events
+}
+
+// events are the times of the last request.
+type events struct {
+	start, firstByte          time.Time
+	dnsStart, dnsDone         time.Time
+	connectStart, connectDone time.Time
+	tlsStart, tlsDone         time.Time
+	remoteIP                  string
+	reused                    bool
+}
+
+func (t *tracer) context(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn: func(string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			// 新的请求 (重定向或重试), 只保留最后一次
+			t.events = events{start: time.Now()}
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.set(&t.events.dnsStart)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.set(&t.events.dnsDone)
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			// 多个地址并发拨号时从第一次开始计算
+			if t.events.connectStart.IsZero() {
+				t.events.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				t.set(&t.events.connectDone)
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.set(&t.events.tlsStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.set(&t.events.tlsDone)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.events.reused = info.Reused
+			if addr, ok := info.Conn.RemoteAddr().(*net.TCPAddr); ok {
+				t.events.remoteIP = addr.IP.String()
+			} else if host, _, err := net.SplitHostPort(info.Conn.RemoteAddr().String()); err == nil {
+				t.events.remoteIP = host
+			}
+		},
+		GotFirstResponseByte: func() {
+			t.set(&t.events.firstByte)
+		},
+	})
+}
+
+func (t *tracer) set(v *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*v = time.Now()
+}
+
+// timing returns the breakdown of the last request, the body was read at end.
+func (t *tracer) timing(end time.Time) *Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e := t.events
+	if e.start.IsZero() {
+		return nil
+	}
+	timing := &Timing{
+		DNSLookup:    since(e.dnsStart, e.dnsDone),
+		Connect:      since(e.connectStart, e.connectDone),
+		TLSHandshake: since(e.tlsStart, e.tlsDone),
+		FirstByte:    since(e.start, e.firstByte),
+		Total:        end.Sub(e.start),
+		RemoteIP:     e.remoteIP,
+		Reused:       e.reused,
+	}
+	if !e.firstByte.IsZero() {
+		timing.ContentTransfer = end.Sub(e.firstByte)
+	}
+	return timing
+}
+
+// since returns the time between two events, zero unless both happened.
+func since(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_CheckTiming(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	h := NewHTTP(Config{
+		URL:                    strings.Replace(server.URL, "127.0.0.1", "localhost", 1),
+		Method:                 GET,
+		SkipCertificateExpires: true,
+	}, SetClient(resty.New()))
+
+	result, err := h.Check(context.Background())
+	require.NoError(t, err)
+	timing := result.Details.(*Details).Timing
+	require.NotNil(t, timing)
+	assert.Positive(t, timing.DNSLookup)
+	assert.Positive(t, timing.Connect)
+	assert.Positive(t, timing.TLSHandshake)
+	assert.GreaterOrEqual(t, timing.FirstByte, 10*time.Millisecond)
+	assert.Greater(t, timing.FirstByte, timing.TLSHandshake)
+	assert.GreaterOrEqual(t, timing.Total, timing.FirstByte+timing.ContentTransfer)
+	assert.Contains(t, []string{"127.0.0.1", "::1"}, timing.RemoteIP)
+	assert.False(t, timing.Reused)
+
+	// 第二次检测复用连接池中的连接
+	result, err = h.Check(context.Background())
+	require.NoError(t, err)
+	timing = result.Details.(*Details).Timing
+	assert.True(t, timing.Reused)
+	assert.Zero(t, timing.DNSLookup)
+	assert.Zero(t, timing.Connect)
+	assert.Zero(t, timing.TLSHandshake)
+
+	m := result.Details.(*Details).Measurements()
+	assert.Equal(t, timing.FirstByte.Seconds(), m["first_byte_seconds"])
+	assert.Contains(t, m, "dns_lookup_seconds")
+}
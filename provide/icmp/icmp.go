@@ -2,6 +2,7 @@ package icmp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -11,10 +12,24 @@ import (
 )
 
 var (
-	_                status_neko.Monitor = (*ICMP)(nil)
-	providerIcmpName                     = "icmp"
+	_                status_neko.Monitor  = (*ICMP)(nil)
+	_                status_neko.Measurer = (*Details)(nil)
+	providerIcmpName                      = "icmp"
 )
 
+func init() {
+	status_neko.Register(providerIcmpName, func(raw json.RawMessage) (status_neko.Monitor, error) {
+		var c struct {
+			Config
+			Timeout status_neko.Duration `json:"timeout"`
+		}
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return nil, err
+		}
+		return NewICMP(c.Config, SetTimeout(time.Duration(c.Timeout))), nil
+	})
+}
+
 type ICMP struct {
 	config Config
 	option *option
@@ -25,6 +40,32 @@ type Config struct {
 	Port int    `json:"port"`
 }
 
+func (c *Config) Validate() error {
+	if c.Host == "" {
+		return &status_neko.FieldError{Field: "host", Message: "is required"}
+	}
+	return nil
+}
+
+// Details is the Result.Details of an ICMP check.
+type Details struct {
+	Host        string        `json:"host"`
+	IP          string        `json:"ip"`
+	AvgRtt      time.Duration `json:"avg_rtt"`
+	PacketsSent int           `json:"packets_sent"`
+	PacketsRecv int           `json:"packets_recv"`
+	PacketLoss  float64       `json:"packet_loss"` // 丢包率, 百分比
+}
+
+func (d *Details) Measurements() map[string]float64 {
+	return map[string]float64{
+		"packet_loss_ratio": d.PacketLoss / 100,
+		"rtt_seconds":       d.AvgRtt.Seconds(),
+		"packets_sent":      float64(d.PacketsSent),
+		"packets_received":  float64(d.PacketsRecv),
+	}
+}
+
 type option struct {
 	Timeout time.Duration
 }
@@ -53,10 +94,8 @@ func (i ICMP) Name() string {
 	return providerIcmpName
 }
 
-func (i ICMP) Check(ctx context.Context) (interface{}, error) {
-	if i.option.Timeout == 0 {
-		i.option.Timeout = 5 * time.Second
-	}
+func (i ICMP) Check(ctx context.Context) (*status_neko.Result, error) {
+	start := time.Now()
 
 	pinger, err := ping.NewPinger(i.config.Host)
 	if err != nil {
@@ -67,8 +106,14 @@ func (i ICMP) Check(ctx context.Context) (interface{}, error) {
 	pinger.SetPrivileged(false)
 
 	pinger.Count = 1
-	pinger.Timeout = i.option.Timeout
+	// 默认等到 ctx 的 deadline (没有 deadline 时为 5s), SetTimeout 可以设置更短的超时
+	pinger.Timeout = status_neko.Remaining(ctx)
+	if _, ok := ctx.Deadline(); i.option.Timeout > 0 && (!ok || i.option.Timeout < pinger.Timeout) {
+		pinger.Timeout = i.option.Timeout
+	}
 
+	stop := context.AfterFunc(ctx, pinger.Stop)
+	defer stop()
 	err = pinger.Run()
 	if err != nil {
 		return nil, fmt.Errorf("failed to run pinger: %w", err)
@@ -80,10 +125,14 @@ func (i ICMP) Check(ctx context.Context) (interface{}, error) {
 		return nil, fmt.Errorf("no response from host: %s", i.config.Host)
 	}
 
-	return map[string]interface{}{
-		"host":     i.config.Host,
-		"ip":       stats.IPAddr.String(),
-		"latency":  stats.AvgRtt.String(),
-		"received": stats.PacketsRecv,
-	}, nil
+	result := status_neko.NewResult(status_neko.StatusUp, start, "reply from "+stats.IPAddr.String(), &Details{
+		Host:        i.config.Host,
+		IP:          stats.IPAddr.String(),
+		AvgRtt:      stats.AvgRtt,
+		PacketsSent: stats.PacketsSent,
+		PacketsRecv: stats.PacketsRecv,
+		PacketLoss:  stats.PacketLoss,
+	})
+	result.Latency = stats.AvgRtt
+	return result, nil
 }
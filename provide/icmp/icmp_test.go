@@ -5,7 +5,9 @@ import (
 	"testing"
 	"time"
 
+	status_neko "github.com/songzhibin97/status-neko"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestICMP_Name(t *testing.T) {
@@ -43,13 +45,32 @@ func TestICMP_Check(t *testing.T) {
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
-				assert.NoError(t, err)
-				result, ok := got.(map[string]interface{})
-				assert.True(t, ok)
-				assert.Equal(t, tt.icmp.config.Host, result["host"])
-				assert.IsType(t, "", result["latency"])
-				assert.IsType(t, 0, result["received"])
+				require.NoError(t, err)
+				details, ok := got.Details.(*Details)
+				require.True(t, ok)
+				assert.Equal(t, tt.icmp.config.Host, details.Host)
+				assert.Equal(t, details.AvgRtt, got.Latency)
+				assert.Equal(t, 1, details.PacketsRecv)
 			}
 		})
 	}
 }
+
+func TestICMP_Build(t *testing.T) {
+	monitor, err := status_neko.Build([]byte(`{"type": "icmp", "host": "127.0.0.1", "timeout": "2s"}`))
+	assert.NoError(t, err)
+
+	i := monitor.(*ICMP)
+	assert.Equal(t, "127.0.0.1", i.config.Host)
+	assert.Equal(t, 2*time.Second, i.option.Timeout)
+}
+
+func TestDetails_Measurements(t *testing.T) {
+	d := &Details{AvgRtt: 20 * time.Millisecond, PacketsSent: 4, PacketsRecv: 3, PacketLoss: 25}
+	assert.Equal(t, map[string]float64{
+		"packet_loss_ratio": 0.25,
+		"rtt_seconds":       0.02,
+		"packets_sent":      4,
+		"packets_received":  3,
+	}, d.Measurements())
+}
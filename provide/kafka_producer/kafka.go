@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -26,6 +27,28 @@ var (
 	SASLAuthTypeSha512 SASLAuthType = "sha512"
 )
 
+func init() {
+	status_neko.Register(providerKafkaProducerName, func(raw json.RawMessage) (status_neko.Monitor, error) {
+		var c struct {
+			Config
+			SASLAuthType           SASLAuthType   `json:"sasl_auth_type"`
+			Username               string         `json:"username"`
+			Password               string         `json:"password"`
+			SSL                    bool           `json:"ssl"`
+			SkipCertificateExpires bool           `json:"skip_certificate_expires"`
+			AuthMTLSConfig         AuthMTLSConfig `json:"mtls"`
+		}
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return nil, err
+		}
+		return NewKafkaProducer(c.Config,
+			SetSASLAuthType(c.SASLAuthType),
+			SetUsernameAndPassword(c.Username, c.Password),
+			SetSSL(c.SSL, c.SkipCertificateExpires, c.AuthMTLSConfig),
+		), nil
+	})
+}
+
 type Config struct {
 	Brokers         []string `json:"brokers"`
 	Topic           string   `json:"topic"`
@@ -33,6 +56,17 @@ type Config struct {
 	CreateTopic     bool     `json:"create_topic"` // producer 自动创建 topic
 }
 
+// Details is the Result.Details of a Kafka producer check.
+type Details struct {
+	Brokers         []string     `json:"brokers"`
+	Topic           string       `json:"topic"`
+	Partition       int32        `json:"last_partition"`
+	Offset          int64        `json:"last_offset"`
+	SSLEnabled      bool         `json:"ssl_enabled"`
+	SASLAuthType    SASLAuthType `json:"sasl_auth_type"`
+	ProducerMessage string       `json:"producer_message"`
+}
+
 type AuthMTLSConfig struct {
 	Cert string `json:"cert"` // 证书
 	Key  string `json:"key"`  // 私钥
@@ -46,7 +80,7 @@ type option struct {
 	SSL                    bool `json:"ssl"`
 	SkipCertificateExpires bool `json:"skip_certificate_expires"`
 	AuthMTLSConfig         AuthMTLSConfig
-	Check                  func(ctx context.Context) (interface{}, error)
+	Check                  func(ctx context.Context) (*status_neko.Result, error)
 }
 
 type KafkaProducer struct {
@@ -94,12 +128,15 @@ func (k KafkaProducer) Name() string {
 	return providerKafkaProducerName
 }
 
-func (k KafkaProducer) Check(ctx context.Context) (interface{}, error) {
+func (k KafkaProducer) Check(ctx context.Context) (*status_neko.Result, error) {
+	start := time.Now()
+
 	config := sarama.NewConfig()
 	config.Producer.Return.Successes = true
-	config.Net.DialTimeout = 5 * time.Second
-	config.Net.ReadTimeout = 5 * time.Second
-	config.Net.WriteTimeout = 5 * time.Second
+	timeout := status_neko.Remaining(ctx)
+	config.Net.DialTimeout = timeout
+	config.Net.ReadTimeout = timeout
+	config.Net.WriteTimeout = timeout
 
 	// 配置 SSL
 	if k.option.SSL {
@@ -156,18 +193,15 @@ func (k KafkaProducer) Check(ctx context.Context) (interface{}, error) {
 		return nil, fmt.Errorf("failed to send message: %v", err)
 	}
 
-	status := map[string]interface{}{
-		"connected":        true,
-		"brokers":          k.config.Brokers,
-		"topic":            k.config.Topic,
-		"last_partition":   partition,
-		"last_offset":      offset,
-		"ssl_enabled":      k.option.SSL,
-		"sasl_auth_type":   k.option.SASLAuthType,
-		"producer_message": k.config.ProducerMessage,
-	}
-
-	return status, nil
+	return status_neko.NewResult(status_neko.StatusUp, start, fmt.Sprintf("message sent to partition %d at offset %d", partition, offset), &Details{
+		Brokers:         k.config.Brokers,
+		Topic:           k.config.Topic,
+		Partition:       partition,
+		Offset:          offset,
+		SSLEnabled:      k.option.SSL,
+		SASLAuthType:    k.option.SASLAuthType,
+		ProducerMessage: k.config.ProducerMessage,
+	}), nil
 }
 
 func LoadCertFromByte(clientCrt []byte, childKey []byte, rootCaChain []byte) (*x509.CertPool, []tls.Certificate, error) {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"testing"
 
+	status_neko "github.com/songzhibin97/status-neko"
 	"github.com/songzhibin97/status-neko/provide/kafka_producer"
 	"github.com/stretchr/testify/assert"
 
@@ -102,10 +103,11 @@ func TestKafkaProducer_CheckSuccess(t *testing.T) {
 	assert.NotNil(t, result)
 
 	// 确保返回的结果包含期望的字段
-	status := result.(map[string]interface{})
-	assert.Equal(t, config.Brokers, status["brokers"])
-	assert.Equal(t, config.Topic, status["topic"])
-	assert.Equal(t, config.ProducerMessage, status["producer_message"])
+	details := result.Details.(*kafka_producer.Details)
+	assert.Equal(t, status_neko.StatusUp, result.Status)
+	assert.Equal(t, config.Brokers, details.Brokers)
+	assert.Equal(t, config.Topic, details.Topic)
+	assert.Equal(t, config.ProducerMessage, details.ProducerMessage)
 }
 
 func TestKafkaProducer_CheckFail(t *testing.T) {
@@ -130,3 +132,17 @@ func TestKafkaProducer_CheckFail(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, status)
 }
+
+func TestKafkaProducer_Build(t *testing.T) {
+	monitor, err := status_neko.Build([]byte(`{
+		"type": "kafka_producer",
+		"brokers": ["localhost:9092"],
+		"topic": "test_topic",
+		"sasl_auth_type": "plain",
+		"username": "user",
+		"password": "pass"
+	}`))
+	assert.NoError(t, err)
+	assert.IsType(t, &kafka_producer.KafkaProducer{}, monitor)
+	assert.Equal(t, "kafka_producer", monitor.Name())
+}
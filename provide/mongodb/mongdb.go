@@ -2,6 +2,7 @@ package mongodb
 
 import (
 	"context"
+	"time"
 
 	status_neko "github.com/songzhibin97/status-neko"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -14,6 +15,12 @@ var (
 	providerMongoDBName                     = "mongodb"
 )
 
+func init() {
+	status_neko.Register(providerMongoDBName, status_neko.NewFactory(func(c Config) status_neko.Monitor {
+		return NewMongoDB(c)
+	}))
+}
+
 type Config struct {
 	DSN string `json:"dsn"`
 }
@@ -39,7 +46,9 @@ func (m MongoDB) Name() string {
 	return providerMongoDBName
 }
 
-func (m MongoDB) Check(ctx context.Context) (interface{}, error) {
+func (m MongoDB) Check(ctx context.Context) (*status_neko.Result, error) {
+	start := time.Now()
+
 	if m.client == nil {
 		client, err := mongo.Connect(ctx, options.Client().ApplyURI(m.config.DSN))
 		if err != nil {
@@ -54,5 +63,5 @@ func (m MongoDB) Check(ctx context.Context) (interface{}, error) {
 		return nil, err
 	}
 
-	return map[string]string{"status": "ok"}, nil
+	return status_neko.NewResult(status_neko.StatusUp, start, "ok", nil), nil
 }
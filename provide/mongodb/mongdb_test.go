@@ -5,6 +5,7 @@ import (
 	"errors"
 	"testing"
 
+	status_neko "github.com/songzhibin97/status-neko"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
@@ -45,7 +46,8 @@ func TestMongoDB_Check(t *testing.T) {
 
 	status, err := mongoDB.Check(ctx)
 	assert.NoError(t, err)
-	assert.Equal(t, map[string]string{"status": "ok"}, status)
+	assert.Equal(t, status_neko.StatusUp, status.Status)
+	assert.Equal(t, "ok", status.Message)
 	mockClient.AssertExpectations(t)
 
 	// Case 2: Failed to ping
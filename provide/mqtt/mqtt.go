@@ -15,6 +15,12 @@ var (
 	providerMqttName                     = "mqtt"
 )
 
+func init() {
+	status_neko.Register(providerMqttName, status_neko.NewFactory(func(c Config) status_neko.Monitor {
+		return NewMQTT(c)
+	}))
+}
+
 type Config struct {
 	Host     string `json:"host"`
 	Port     int    `json:"port"`
@@ -23,6 +29,12 @@ type Config struct {
 	Topic    string `json:"topic"`
 }
 
+// Details is the Result.Details of an MQTT check.
+type Details struct {
+	Broker string `json:"broker"`
+	Topic  string `json:"topic"`
+}
+
 type MQTT struct {
 	config Config
 }
@@ -37,7 +49,13 @@ func (m MQTT) Name() string {
 	return providerMqttName
 }
 
-func (m MQTT) Check(ctx context.Context) (interface{}, error) {
+func (m MQTT) Check(ctx context.Context) (*status_neko.Result, error) {
+	start := time.Now()
+
+	// 没有 deadline 时最多等待 5s
+	ctx, cancel := context.WithTimeout(ctx, status_neko.Remaining(ctx))
+	defer cancel()
+
 	opts := mqtt.NewClientOptions()
 	opts.AddBroker(fmt.Sprintf("tcp://%s:%d", m.config.Host, m.config.Port))
 	opts.SetUsername(m.config.Username)
@@ -47,8 +65,14 @@ func (m MQTT) Check(ctx context.Context) (interface{}, error) {
 	client := mqtt.NewClient(opts)
 
 	token := client.Connect()
-	if token.WaitTimeout(5*time.Second) && token.Error() != nil {
-		return nil, fmt.Errorf("failed to connect to MQTT broker: %v", token.Error())
+	select {
+	case <-token.Done():
+		if token.Error() != nil {
+			return nil, fmt.Errorf("failed to connect to MQTT broker: %v", token.Error())
+		}
+	case <-ctx.Done():
+		client.Disconnect(0)
+		return nil, fmt.Errorf("failed to connect to MQTT broker: %w", ctx.Err())
 	}
 
 	if !client.IsConnected() {
@@ -57,11 +81,8 @@ func (m MQTT) Check(ctx context.Context) (interface{}, error) {
 
 	defer client.Disconnect(250)
 
-	status := map[string]interface{}{
-		"connected": true,
-		"broker":    fmt.Sprintf("%s:%d", m.config.Host, m.config.Port),
-		"topic":     m.config.Topic,
-	}
-
-	return status, nil
+	return status_neko.NewResult(status_neko.StatusUp, start, "connected", &Details{
+		Broker: fmt.Sprintf("%s:%d", m.config.Host, m.config.Port),
+		Topic:  m.config.Topic,
+	}), nil
 }
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net"
 	"testing"
+
+	status_neko "github.com/songzhibin97/status-neko"
 )
 
 func TestMQTT_Check(t *testing.T) {
@@ -65,26 +67,23 @@ func TestMQTT_Check(t *testing.T) {
 				if result == nil {
 					t.Errorf("Expected non-nil result, got nil")
 				} else {
-					status, ok := result.(map[string]interface{})
+					details, ok := result.Details.(*Details)
 					if !ok {
-						t.Errorf("Expected result to be map[string]interface{}, got %T", result)
+						t.Errorf("Expected result details to be *Details, got %T", result.Details)
 					} else {
-						t.Logf("Result: %+v", status)
+						t.Logf("Result: %+v", details)
 
-						connected, ok := status["connected"].(bool)
-						if !ok {
-							t.Errorf("Expected 'connected' to be bool, got %T", status["connected"])
-						} else if !connected {
-							t.Errorf("Expected 'connected' to be true, got false")
+						if result.Status != status_neko.StatusUp {
+							t.Errorf("Expected status to be up, got %s", result.Status)
 						}
 
 						expectedBroker := fmt.Sprintf("%s:%d", tc.config.Host, tc.config.Port)
-						if status["broker"] != expectedBroker {
-							t.Errorf("Expected broker to be %s, got %s", expectedBroker, status["broker"])
+						if details.Broker != expectedBroker {
+							t.Errorf("Expected broker to be %s, got %s", expectedBroker, details.Broker)
 						}
 
-						if status["topic"] != tc.config.Topic {
-							t.Errorf("Expected topic to be %s, got %s", tc.config.Topic, status["topic"])
+						if details.Topic != tc.config.Topic {
+							t.Errorf("Expected topic to be %s, got %s", tc.config.Topic, details.Topic)
 						}
 					}
 				}
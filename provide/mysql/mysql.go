@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	status_neko "github.com/songzhibin97/status-neko"
@@ -14,6 +15,12 @@ var (
 	providerMysqlName                     = "mysql"
 )
 
+func init() {
+	status_neko.Register(providerMysqlName, status_neko.NewFactory(func(c Config) status_neko.Monitor {
+		return NewMysql(c)
+	}))
+}
+
 type Config struct {
 	DSN      string `json:"dsn"`
 	QuerySQL string `json:"query_sql"`
@@ -24,6 +31,11 @@ type Mysql struct {
 	db     *sql.DB
 }
 
+// Details is the Result.Details of a database check.
+type Details struct {
+	Result int `json:"result"` // QuerySQL 的查询结果
+}
+
 func NewMysql(config Config) *Mysql {
 	return &Mysql{
 		config: config,
@@ -34,7 +46,9 @@ func (m Mysql) Name() string {
 	return providerMysqlName
 }
 
-func (m Mysql) Check(ctx context.Context) (interface{}, error) {
+func (m Mysql) Check(ctx context.Context) (*status_neko.Result, error) {
+	start := time.Now()
+
 	if m.db == nil {
 		db, err := sql.Open("mysql", m.config.DSN)
 		if err != nil {
@@ -54,8 +68,7 @@ func (m Mysql) Check(ctx context.Context) (interface{}, error) {
 		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
 
-	return map[string]interface{}{
-		"status": "ok",
-		"result": result,
-	}, nil
+	return status_neko.NewResult(status_neko.StatusUp, start, "ok", &Details{
+		Result: result,
+	}), nil
 }
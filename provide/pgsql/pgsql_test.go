@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	status_neko "github.com/songzhibin97/status-neko"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -33,11 +34,8 @@ func TestPgSql_Check(t *testing.T) {
 	assert.NoError(t, err)
 
 	// 5. 验证结果
-	expected := map[string]interface{}{
-		"status": "ok",
-		"result": 42,
-	}
-	assert.Equal(t, expected, result)
+	assert.Equal(t, status_neko.StatusUp, result.Status)
+	assert.Equal(t, &Details{Result: 42}, result.Details)
 
 	// 6. 确认所有预期的调用都已完成
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -0,0 +1,223 @@
+package push
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Signal is what a push reports about the job.
+type Signal string
+
+var (
+	SignalSuccess Signal = "success"
+	SignalStart   Signal = "start"
+	SignalFail    Signal = "fail"
+
+	// ErrUnknownToken is returned for a push to a token no monitor registered.
+	ErrUnknownToken = errors.New("unknown push token")
+
+	// DefaultHub receives the pushes of the monitors built from configuration.
+	DefaultHub = NewHub()
+)
+
+// Ping is a push received from a job.
+type Ping struct {
+	Signal  Signal
+	Message string
+	// Latency is reported by the job, zero when unknown.
+	Latency time.Duration
+}
+
+// beat is the state of a token.
+type beat struct {
+	// owner is the name of the monitor the token belongs to, refs counts
+	// its started monitors, e.g. two while the monitor is replaced
+	owner      string
+	refs       int
+	registered time.Time
+
+	last     time.Time
+	signal   Signal
+	message  string
+	ping     time.Duration
+	started  time.Time
+	duration time.Duration
+	pushes   int
+}
+
+// Hub keeps the pushes of the tokens and serves them over HTTP:
+//
+//	GET|POST /api/push/{token}?status=up&msg=...&ping=...   success, or failure with status=down
+//	GET|POST /api/push/{token}/start                      the job started
+//	GET|POST /api/push/{token}/fail?msg=...               the job failed
+//
+// ping is the latency in milliseconds like Uptime Kuma.
+type Hub struct {
+	mu    sync.Mutex
+	beats map[string]*beat
+	now   func() time.Time
+	mux   *http.ServeMux
+}
+
+func NewHub() *Hub {
+	h := &Hub{
+		beats: make(map[string]*beat),
+		now:   time.Now,
+		mux:   http.NewServeMux(),
+	}
+	h.mux.HandleFunc("/api/push/{token}", h.handle)
+	h.mux.HandleFunc("/api/push/{token}/{signal}", h.handle)
+	return h
+}
+
+// Push records a push for token.
+func (h *Hub) Push(token string, p Ping) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	b, ok := h.beats[token]
+	if !ok {
+		return ErrUnknownToken
+	}
+
+	now := h.now()
+	b.pushes++
+	if p.Signal == SignalStart {
+		b.started = now
+		return nil
+	}
+
+	if !b.started.IsZero() {
+		b.duration = now.Sub(b.started)
+		b.started = time.Time{}
+	}
+	b.last = now
+	b.signal = p.Signal
+	b.message = p.Message
+	b.ping = p.Latency
+	return nil
+}
+
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Hub) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		reply(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	p := Ping{
+		Signal:  SignalSuccess,
+		Message: query.Get("msg"),
+	}
+	switch signal := Signal(r.PathValue("signal")); signal {
+	case "":
+		switch query.Get("status") {
+		case "", "up":
+		case "down":
+			p.Signal = SignalFail
+		default:
+			reply(w, http.StatusBadRequest, "status must be up or down")
+			return
+		}
+	case SignalStart, SignalFail:
+		p.Signal = signal
+	default:
+		reply(w, http.StatusNotFound, "unknown signal "+string(signal))
+		return
+	}
+	if v := query.Get("ping"); v != "" {
+		ms, err := strconv.ParseFloat(v, 64)
+		if err != nil || ms < 0 {
+			reply(w, http.StatusBadRequest, "ping must be a number of milliseconds")
+			return
+		}
+		p.Latency = time.Duration(ms * float64(time.Millisecond))
+	}
+
+	if err := h.Push(r.PathValue("token"), p); err != nil {
+		reply(w, http.StatusNotFound, err.Error())
+		return
+	}
+	reply(w, http.StatusOK, "")
+}
+
+// register makes token of the monitor owner accept pushes. A token
+// registered again by the same monitor keeps its state, e.g. while the
+// monitor is updated. A token registered by another monitor, or by a monitor
+// without name, is rejected.
+func (h *Hub) register(token, owner string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.conflict(token, owner); err != nil {
+		return err
+	}
+	b, ok := h.beats[token]
+	if !ok {
+		b = &beat{owner: owner, registered: h.now()}
+		h.beats[token] = b
+	}
+	b.refs++
+	return nil
+}
+
+// release undoes a register, the token is forgotten once no monitor uses it.
+func (h *Hub) release(token, owner string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	b, ok := h.beats[token]
+	if !ok || b.owner != owner {
+		return
+	}
+	if b.refs--; b.refs <= 0 {
+		delete(h.beats, token)
+	}
+}
+
+// available reports whether owner could register token.
+func (h *Hub) available(token, owner string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.conflict(token, owner)
+}
+
+// conflict returns an error when token is used by a monitor other than
+// owner, the caller must hold h.mu.
+func (h *Hub) conflict(token, owner string) error {
+	if b, ok := h.beats[token]; ok && (owner == "" || b.owner != owner) {
+		return fmt.Errorf("token is already used by monitor %q", b.owner)
+	}
+	return nil
+}
+
+func (h *Hub) beat(token string) (beat, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	b, ok := h.beats[token]
+	if !ok {
+		return beat{}, false
+	}
+	return *b, true
+}
+
+func reply(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(struct {
+		OK  bool   `json:"ok"`
+		Msg string `json:"msg,omitempty"`
+	}{OK: code == http.StatusOK, Msg: msg})
+}
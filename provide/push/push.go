@@ -0,0 +1,208 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+var (
+	_ status_neko.Monitor  = (*Push)(nil)
+	_ status_neko.Starter  = (*Push)(nil)
+	_ status_neko.Measurer = (*Details)(nil)
+
+	providerPushName = "push"
+
+	defaultPeriod = time.Hour
+	defaultGrace  = time.Minute
+)
+
+func init() {
+	newPush := status_neko.NewFactory(func(c Config) status_neko.Monitor {
+		return NewPush(c)
+	})
+	status_neko.Register(providerPushName, func(raw json.RawMessage) (status_neko.Monitor, error) {
+		m, err := newPush(raw)
+		if err != nil {
+			return nil, err
+		}
+		// 构建时只检查 token 是否被占用, 加入调度器时才注册
+		if err := m.(*Push).conflict(); err != nil {
+			return nil, err
+		}
+		return m, nil
+	})
+}
+
+// Config is a passive monitor fed by the jobs themselves calling
+// /api/push/<token>, it is DOWN when no push arrived within period plus
+// grace, when the job failed, or when a started job did not finish within grace.
+type Config struct {
+	// Name 是监控项的名称, 从监控项定义中读取, 同一 token 只能属于一个监控项
+	Name string `json:"name"`
+	// Token 是推送地址中的唯一标识, 建议使用随机字符串
+	Token string `json:"token"`
+	// Period 是两次推送之间的预期间隔, 默认 1h
+	Period status_neko.Duration `json:"period"`
+	// Grace 是允许的延迟, 也是任务开始后允许运行的时间, 默认 1m
+	Grace status_neko.Duration `json:"grace"`
+}
+
+func (c *Config) Validate() error {
+	var fields status_neko.FieldErrors
+	if c.Token == "" {
+		fields = append(fields, &status_neko.FieldError{Field: "token", Message: "is required"})
+	}
+	if c.Period < 0 {
+		fields = append(fields, &status_neko.FieldError{Field: "period", Message: "must not be negative"})
+	}
+	if c.Grace < 0 {
+		fields = append(fields, &status_neko.FieldError{Field: "grace", Message: "must not be negative"})
+	}
+	if len(fields) > 0 {
+		return fields
+	}
+	return nil
+}
+
+// Details is the Result.Details of a push check.
+type Details struct {
+	LastPush time.Time `json:"last_push,omitempty"`
+	Signal   Signal    `json:"signal,omitempty"`
+	Message  string    `json:"message,omitempty"`
+	// Ping is the latency the job reported with the ping parameter.
+	Ping time.Duration `json:"ping,omitempty"`
+	// Started is set while a job is running, Duration is the run time of
+	// the last job that reported its start.
+	Started  time.Time     `json:"started,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+	Pushes   int           `json:"pushes"`
+}
+
+func (d *Details) Measurements() map[string]float64 {
+	m := map[string]float64{
+		"pushes": float64(d.Pushes),
+	}
+	if !d.LastPush.IsZero() {
+		m["last_push_timestamp_seconds"] = float64(d.LastPush.UnixNano()) / 1e9
+	}
+	if d.Duration > 0 {
+		m["job_duration_seconds"] = d.Duration.Seconds()
+	}
+	return m
+}
+
+type option struct {
+	hub *Hub
+}
+
+// SetHub receives the pushes on another hub than DefaultHub.
+func SetHub(h *Hub) status_neko.Option[*option] {
+	return func(o *option) {
+		o.hub = h
+	}
+}
+
+type Push struct {
+	config Config
+	hub    *Hub
+}
+
+// NewPush receives the pushes for the token on the hub once it is started,
+// see Start.
+func NewPush(config Config, opts ...status_neko.Option[*option]) *Push {
+	o := &option{
+		hub: DefaultHub,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if config.Period <= 0 {
+		config.Period = status_neko.Duration(defaultPeriod)
+	}
+	if config.Grace <= 0 {
+		config.Grace = status_neko.Duration(defaultGrace)
+	}
+
+	return &Push{
+		config: config,
+		hub:    o.hub,
+	}
+}
+
+// Start registers the token on the hub, pushes for it are accepted until
+// Stop. The scheduler starts a monitor when it is added, a monitor updated
+// with the same token keeps the state of the pushes.
+func (p *Push) Start() error {
+	if err := p.hub.register(p.config.Token, p.config.Name); err != nil {
+		return &status_neko.FieldError{Field: "token", Message: err.Error()}
+	}
+	return nil
+}
+
+// Stop releases the token, pushes for it are rejected once no started
+// monitor uses it.
+func (p *Push) Stop() {
+	p.hub.release(p.config.Token, p.config.Name)
+}
+
+// conflict reports a token already used by another monitor.
+func (p *Push) conflict() error {
+	if err := p.hub.available(p.config.Token, p.config.Name); err != nil {
+		return &status_neko.FieldError{Field: "token", Message: err.Error()}
+	}
+	return nil
+}
+
+func (p *Push) Name() string {
+	return providerPushName
+}
+
+func (p *Push) Check(ctx context.Context) (*status_neko.Result, error) {
+	start := time.Now()
+	b, ok := p.hub.beat(p.config.Token)
+	if !ok {
+		return nil, fmt.Errorf("token %s is not registered", p.config.Token)
+	}
+
+	now := p.hub.now()
+	period, grace := time.Duration(p.config.Period), time.Duration(p.config.Grace)
+	details := &Details{
+		LastPush: b.last,
+		Signal:   b.signal,
+		Message:  b.message,
+		Ping:     b.ping,
+		Started:  b.started,
+		Duration: b.duration,
+		Pushes:   b.pushes,
+	}
+	result := func(status status_neko.Status, message string) *status_neko.Result {
+		r := status_neko.NewResult(status, start, message, details)
+		if b.ping > 0 {
+			r.Latency = b.ping
+		}
+		return r
+	}
+
+	switch {
+	case !b.started.IsZero() && now.Sub(b.started) > grace:
+		return result(status_neko.StatusDown, ""), fmt.Errorf("job started at %s did not finish within %s", b.started.Format(time.DateTime), grace)
+	case b.signal == SignalFail:
+		message := b.message
+		if message == "" {
+			message = "job failed"
+		}
+		return result(status_neko.StatusDown, ""), errors.New(message)
+	case b.last.IsZero() && now.Sub(b.registered) > period+grace:
+		return result(status_neko.StatusDown, ""), fmt.Errorf("no push received within %s", period+grace)
+	case b.last.IsZero():
+		return result(status_neko.StatusUp, "waiting for the first push"), nil
+	case now.Sub(b.last) > period+grace:
+		return result(status_neko.StatusDown, ""), fmt.Errorf("no push since %s", b.last.Format(time.DateTime))
+	}
+	return result(status_neko.StatusUp, b.message), nil
+}
@@ -0,0 +1,213 @@
+package push
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func (c *clock) add(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func newTestPush(t *testing.T) (*Push, *Hub, *clock) {
+	t.Helper()
+	c := &clock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	h := NewHub()
+	h.now = c.now
+	p := NewPush(Config{
+		Token:  "backup",
+		Period: status_neko.Duration(time.Hour),
+		Grace:  status_neko.Duration(5 * time.Minute),
+	}, SetHub(h))
+	require.NoError(t, p.Start())
+	return p, h, c
+}
+
+func push(t *testing.T, h *Hub, method, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestPush_Check(t *testing.T) {
+	p, h, c := newTestPush(t)
+
+	result, err := p.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, status_neko.StatusUp, result.Status)
+	assert.Equal(t, "waiting for the first push", result.Message)
+
+	c.add(time.Hour + 6*time.Minute)
+	_, err = p.Check(context.Background())
+	assert.ErrorContains(t, err, "no push received within 1h5m0s")
+
+	w := push(t, h, http.MethodGet, "/api/push/backup?status=up&msg=OK&ping=12.5")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"ok":true}`, w.Body.String())
+
+	result, err = p.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, status_neko.StatusUp, result.Status)
+	assert.Equal(t, "OK", result.Message)
+	assert.Equal(t, 12500*time.Microsecond, result.Latency)
+	details := result.Details.(*Details)
+	assert.Equal(t, c.t, details.LastPush)
+	assert.Equal(t, 1, details.Pushes)
+
+	// 在 period + grace 内仍为 UP
+	c.add(time.Hour + 4*time.Minute)
+	_, err = p.Check(context.Background())
+	require.NoError(t, err)
+
+	c.add(2 * time.Minute)
+	result, err = p.Check(context.Background())
+	assert.ErrorContains(t, err, "no push since 2024-01-01 01:06:00")
+	assert.Equal(t, status_neko.StatusDown, result.Status)
+
+	w = push(t, h, http.MethodPost, "/api/push/backup?status=down&msg=disk+full")
+	assert.Equal(t, http.StatusOK, w.Code)
+	_, err = p.Check(context.Background())
+	assert.EqualError(t, err, "disk full")
+
+	push(t, h, http.MethodPost, "/api/push/backup")
+	_, err = p.Check(context.Background())
+	require.NoError(t, err)
+}
+
+func TestPush_StartFail(t *testing.T) {
+	p, h, c := newTestPush(t)
+
+	assert.Equal(t, http.StatusOK, push(t, h, http.MethodPost, "/api/push/backup/start").Code)
+	c.add(3 * time.Minute)
+	result, err := p.Check(context.Background())
+	require.NoError(t, err)
+	assert.False(t, result.Details.(*Details).Started.IsZero())
+
+	push(t, h, http.MethodPost, "/api/push/backup")
+	result, err = p.Check(context.Background())
+	require.NoError(t, err)
+	details := result.Details.(*Details)
+	assert.Equal(t, 3*time.Minute, details.Duration)
+	assert.True(t, details.Started.IsZero())
+	assert.Equal(t, 180.0, details.Measurements()["job_duration_seconds"])
+
+	// 开始后超过 grace 仍未结束
+	push(t, h, http.MethodPost, "/api/push/backup/start")
+	c.add(6 * time.Minute)
+	_, err = p.Check(context.Background())
+	assert.ErrorContains(t, err, "did not finish within 5m0s")
+
+	push(t, h, http.MethodPost, "/api/push/backup/fail")
+	result, err = p.Check(context.Background())
+	assert.EqualError(t, err, "job failed")
+	assert.Equal(t, 6*time.Minute, result.Details.(*Details).Duration)
+}
+
+func TestHub_ServeHTTP(t *testing.T) {
+	_, h, _ := newTestPush(t)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		code   int
+	}{
+		{name: "unknown token", method: http.MethodGet, target: "/api/push/nope", code: http.StatusNotFound},
+		{name: "unknown signal", method: http.MethodGet, target: "/api/push/backup/done", code: http.StatusNotFound},
+		{name: "invalid status", method: http.MethodGet, target: "/api/push/backup?status=maybe", code: http.StatusBadRequest},
+		{name: "invalid ping", method: http.MethodGet, target: "/api/push/backup?ping=fast", code: http.StatusBadRequest},
+		{name: "method", method: http.MethodDelete, target: "/api/push/backup", code: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := push(t, h, tt.method, tt.target)
+			assert.Equal(t, tt.code, w.Code)
+			assert.Contains(t, w.Body.String(), `"ok":false`)
+		})
+	}
+}
+
+func TestHub_Register(t *testing.T) {
+	h := NewHub()
+	start := func(name, token string) (*Push, error) {
+		p := NewPush(Config{Name: name, Token: token}, SetHub(h))
+		return p, p.Start()
+	}
+
+	// 启动前不接收推送
+	backup := NewPush(Config{Name: "backup", Token: "nightly"}, SetHub(h))
+	assert.ErrorIs(t, h.Push("nightly", Ping{}), ErrUnknownToken)
+	require.NoError(t, backup.Start())
+	require.NoError(t, h.Push("nightly", Ping{Signal: SignalSuccess}))
+
+	// 同一 token 不能被另一个监控项使用
+	other := NewPush(Config{Name: "other", Token: "nightly"}, SetHub(h))
+	conflict := &status_neko.FieldError{Field: "token", Message: `token is already used by monitor "backup"`}
+	assert.Equal(t, conflict, other.conflict())
+	assert.Equal(t, conflict, other.Start())
+	other.Stop()
+
+	// 更新监控项时先启动新的再停止旧的, 保留推送状态
+	updated, err := start("backup", "nightly")
+	require.NoError(t, err)
+	backup.Stop()
+	result, err := updated.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Details.(*Details).Pushes)
+
+	// 更换 token 后旧的推送地址失效
+	daily, err := start("backup", "daily")
+	require.NoError(t, err)
+	updated.Stop()
+	assert.ErrorIs(t, h.Push("nightly", Ping{}), ErrUnknownToken)
+	_, err = updated.Check(context.Background())
+	assert.ErrorContains(t, err, "token nightly is not registered")
+
+	// 停止后推送地址失效, token 可以给其他监控项使用
+	daily.Stop()
+	assert.ErrorIs(t, h.Push("daily", Ping{}), ErrUnknownToken)
+	assert.Equal(t, http.StatusNotFound, push(t, h, http.MethodGet, "/api/push/daily").Code)
+	_, err = start("other", "daily")
+	require.NoError(t, err)
+}
+
+func TestBuild_DuplicateToken(t *testing.T) {
+	m, err := status_neko.Build([]byte(`{"type": "push", "name": "a", "token": "duplicate"}`))
+	require.NoError(t, err)
+	// 只构建不启动的监控项不占用 token
+	_, err = status_neko.Build([]byte(`{"type": "push", "name": "b", "token": "duplicate"}`))
+	require.NoError(t, err)
+
+	require.NoError(t, status_neko.Start(m))
+	t.Cleanup(func() {
+		status_neko.Stop(m)
+	})
+	_, err = status_neko.Build([]byte(`{"type": "push", "name": "b", "token": "duplicate"}`))
+	assert.Equal(t, status_neko.FieldErrors{
+		{Field: "token", Message: `token is already used by monitor "a"`},
+	}, status_neko.Fields(err))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	_, err := status_neko.Build([]byte(`{"type": "push", "period": "-1m"}`))
+	assert.Equal(t, status_neko.FieldErrors{
+		{Field: "token", Message: "is required"},
+		{Field: "period", Message: "must not be negative"},
+	}, status_neko.Fields(err))
+}
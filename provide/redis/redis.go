@@ -3,6 +3,7 @@ package redis
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 
@@ -14,10 +15,21 @@ var (
 	providerRedisName                     = "redis"
 )
 
+func init() {
+	status_neko.Register(providerRedisName, status_neko.NewFactory(func(c Config) status_neko.Monitor {
+		return NewRedis(c)
+	}))
+}
+
 type Config struct {
 	DSN string `json:"dsn"`
 }
 
+// Details is the Result.Details of a Redis check.
+type Details struct {
+	Reply string `json:"reply"` // PING 的返回值
+}
+
 type Redis struct {
 	config Config
 	client *redis.Client
@@ -33,7 +45,9 @@ func (r *Redis) Name() string {
 	return providerRedisName
 }
 
-func (r *Redis) Check(ctx context.Context) (interface{}, error) {
+func (r *Redis) Check(ctx context.Context) (*status_neko.Result, error) {
+	start := time.Now()
+
 	if r.client == nil {
 		client := redis.NewClient(&redis.Options{
 			Addr: r.config.DSN,
@@ -47,8 +61,7 @@ func (r *Redis) Check(ctx context.Context) (interface{}, error) {
 		return nil, fmt.Errorf("failed to ping Redis: %w", err)
 	}
 
-	return map[string]interface{}{
-		"status": "ok",
-		"result": result,
-	}, nil
+	return status_neko.NewResult(status_neko.StatusUp, start, "ok", &Details{
+		Reply: result,
+	}), nil
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/go-redis/redis/v8"
 
 	"github.com/go-redis/redismock/v8"
+	status_neko "github.com/songzhibin97/status-neko"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -27,11 +28,8 @@ func TestRedis_Check(t *testing.T) {
 	assert.NoError(t, err)
 
 	// 4. 验证结果
-	expected := map[string]interface{}{
-		"status": "ok",
-		"result": "PONG",
-	}
-	assert.Equal(t, expected, result)
+	assert.Equal(t, status_neko.StatusUp, result.Status)
+	assert.Equal(t, &Details{Reply: "PONG"}, result.Details)
 
 	// 5. 确认所有预期的调用都已完成
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -15,6 +15,12 @@ var (
 	providerTcpName                     = "tcp"
 )
 
+func init() {
+	status_neko.Register(providerTcpName, status_neko.NewFactory(func(c Config) status_neko.Monitor {
+		return NewTCP(c)
+	}))
+}
+
 type TCP struct {
 	config Config
 }
@@ -24,6 +30,25 @@ type Config struct {
 	Port int    `json:"port"`
 }
 
+func (c *Config) Validate() error {
+	var fields status_neko.FieldErrors
+	if c.Host == "" {
+		fields = append(fields, &status_neko.FieldError{Field: "host", Message: "is required"})
+	}
+	if c.Port <= 0 || c.Port > 65535 {
+		fields = append(fields, &status_neko.FieldError{Field: "port", Message: "must be between 1 and 65535"})
+	}
+	if len(fields) > 0 {
+		return fields
+	}
+	return nil
+}
+
+// Details is the Result.Details of a TCP check.
+type Details struct {
+	Address string `json:"address"`
+}
+
 func NewTCP(config Config) *TCP {
 	return &TCP{
 		config: config,
@@ -34,11 +59,13 @@ func (t TCP) Name() string {
 	return providerTcpName
 }
 
-func (t TCP) Check(ctx context.Context) (interface{}, error) {
+func (t TCP) Check(ctx context.Context) (*status_neko.Result, error) {
+	start := time.Now()
 	address := net.JoinHostPort(t.config.Host, strconv.Itoa(t.config.Port))
 
+	// 超时由 ctx 控制, 没有 deadline 时为 5s
 	dialer := net.Dialer{
-		Timeout: 5 * time.Second,
+		Timeout: status_neko.Remaining(ctx),
 	}
 	conn, err := dialer.DialContext(ctx, "tcp", address)
 	if err != nil {
@@ -47,5 +74,7 @@ func (t TCP) Check(ctx context.Context) (interface{}, error) {
 	defer conn.Close()
 
 	// 如果成功建立连接，返回一个简单的状态信息
-	return address, nil
+	return status_neko.NewResult(status_neko.StatusUp, start, "connected to "+address, &Details{
+		Address: address,
+	}), nil
 }
@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"testing"
 	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
 )
 
 func TestTCP_Name(t *testing.T) {
@@ -48,8 +50,8 @@ func TestTCP_Check(t *testing.T) {
 				t.Errorf("TCP.Check() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !tt.wantErr && got != net.JoinHostPort(tt.tcp.config.Host, strconv.Itoa(tt.tcp.config.Port)) {
-				t.Errorf("TCP.Check() = %v, want %v", got, net.JoinHostPort(tt.tcp.config.Host, strconv.Itoa(tt.tcp.config.Port)))
+			if !tt.wantErr && got.Details.(*Details).Address != net.JoinHostPort(tt.tcp.config.Host, strconv.Itoa(tt.tcp.config.Port)) {
+				t.Errorf("TCP.Check() = %v, want %v", got.Details, net.JoinHostPort(tt.tcp.config.Host, strconv.Itoa(tt.tcp.config.Port)))
 			}
 		})
 	}
@@ -91,7 +93,22 @@ func TestTCP_CheckWithMockServer(t *testing.T) {
 	}
 
 	expected := net.JoinHostPort(tcp.config.Host, strconv.Itoa(tcp.config.Port))
-	if got != expected {
-		t.Errorf("TCP.Check() = %v, want %v", got, expected)
+	if got.Status != status_neko.StatusUp {
+		t.Errorf("TCP.Check() status = %v, want %v", got.Status, status_neko.StatusUp)
+	}
+	details, ok := got.Details.(*Details)
+	if !ok || details.Address != expected {
+		t.Errorf("TCP.Check() = %v, want %v", got.Details, expected)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	if err := (&Config{Host: "example.com", Port: 80}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	fields := status_neko.Fields((&Config{Port: 70000}).Validate())
+	if len(fields) != 2 || fields[0].Field != "host" || fields[1].Field != "port" {
+		t.Errorf("Validate() fields = %v, want host and port", fields)
 	}
 }
@@ -0,0 +1,222 @@
+package status_neko
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Factory builds a Monitor from its JSON configuration.
+type Factory func(raw json.RawMessage) (Monitor, error)
+
+// NotifierFactory builds a Notifier from its JSON configuration.
+type NotifierFactory func(raw json.RawMessage) (Notifier, error)
+
+// StoreFactory builds a Store from its JSON configuration.
+type StoreFactory func(raw json.RawMessage) (Store, error)
+
+var (
+	providers = &registry[Factory]{kind: "monitor"}
+	notifiers = &registry[NotifierFactory]{kind: "notifier"}
+	stores    = &registry[StoreFactory]{kind: "store"}
+)
+
+// registry maps type names to factories.
+type registry[F any] struct {
+	kind string
+
+	mu sync.RWMutex
+	m  map[string]F
+}
+
+func (r *registry[F]) register(name string, factory F) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.m == nil {
+		r.m = make(map[string]F)
+	}
+	if _, ok := r.m[name]; ok {
+		panic("status_neko: Register called twice for " + r.kind + " " + name)
+	}
+	r.m[name] = factory
+}
+
+func (r *registry[F]) names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.m))
+	for name := range r.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *registry[F]) has(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.m[name]
+	return ok
+}
+
+// lookup finds the factory named by the "type" field of raw.
+func (r *registry[F]) lookup(raw json.RawMessage) (string, F, error) {
+	var zero F
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return "", zero, fmt.Errorf("invalid %s config: %w", r.kind, err)
+	}
+	if header.Type == "" {
+		return "", zero, fmt.Errorf("%s config has no type", r.kind)
+	}
+
+	r.mu.RLock()
+	factory, ok := r.m[header.Type]
+	r.mu.RUnlock()
+	if !ok {
+		return "", zero, fmt.Errorf("unknown %s type %q", r.kind, header.Type)
+	}
+	return header.Type, factory, nil
+}
+
+// Register makes a provider available to Build under the given type name.
+// Providers call it from init, registering the same name twice panics.
+func Register(name string, factory Factory) {
+	if factory == nil {
+		panic("status_neko: Register factory is nil")
+	}
+	providers.register(name, factory)
+}
+
+// Providers returns the sorted names of the registered providers.
+func Providers() []string {
+	return providers.names()
+}
+
+// NewFactory returns a Factory that decodes the JSON configuration into C
+// and hands it to newMonitor, after validating it when C is a Validator.
+func NewFactory[C any](newMonitor func(C) Monitor) Factory {
+	return func(raw json.RawMessage) (Monitor, error) {
+		var config C
+		if err := json.Unmarshal(raw, &config); err != nil {
+			return nil, err
+		}
+		if err := validate(&config); err != nil {
+			return nil, err
+		}
+		return newMonitor(config), nil
+	}
+}
+
+// Build creates a Monitor from a JSON object whose "type" field names a
+// registered provider, the whole object is passed on to its Factory, e.g.
+//
+//	{"type": "dns", "host": "example.com", "resource_type": "A"}
+func Build(raw json.RawMessage) (Monitor, error) {
+	name, factory, err := providers.lookup(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := factory(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s config: %w", name, err)
+	}
+	return m, nil
+}
+
+// RegisterNotifier makes a notifier available to BuildNotifier, see Register.
+func RegisterNotifier(name string, factory NotifierFactory) {
+	if factory == nil {
+		panic("status_neko: RegisterNotifier factory is nil")
+	}
+	notifiers.register(name, factory)
+}
+
+// Notifiers returns the sorted names of the registered notifiers.
+func Notifiers() []string {
+	return notifiers.names()
+}
+
+// NewNotifierFactory returns a NotifierFactory that decodes the JSON
+// configuration into C and hands it to newNotifier.
+func NewNotifierFactory[C any](newNotifier func(C) (Notifier, error)) NotifierFactory {
+	return func(raw json.RawMessage) (Notifier, error) {
+		var config C
+		if err := json.Unmarshal(raw, &config); err != nil {
+			return nil, err
+		}
+		if err := validate(&config); err != nil {
+			return nil, err
+		}
+		return newNotifier(config)
+	}
+}
+
+// BuildNotifier creates a Notifier from a JSON object whose "type" field
+// names a registered notifier, e.g.
+//
+//	{"type": "webhook", "url": "https://example.com/hook"}
+func BuildNotifier(raw json.RawMessage) (Notifier, error) {
+	name, factory, err := notifiers.lookup(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := factory(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s config: %w", name, err)
+	}
+	return n, nil
+}
+
+// RegisterStore makes a store available to BuildStore, see Register.
+func RegisterStore(name string, factory StoreFactory) {
+	if factory == nil {
+		panic("status_neko: RegisterStore factory is nil")
+	}
+	stores.register(name, factory)
+}
+
+// Stores returns the sorted names of the registered stores.
+func Stores() []string {
+	return stores.names()
+}
+
+// NewStoreFactory returns a StoreFactory that decodes the JSON
+// configuration into C and hands it to newStore.
+func NewStoreFactory[C any](newStore func(C) (Store, error)) StoreFactory {
+	return func(raw json.RawMessage) (Store, error) {
+		var config C
+		if err := json.Unmarshal(raw, &config); err != nil {
+			return nil, err
+		}
+		if err := validate(&config); err != nil {
+			return nil, err
+		}
+		return newStore(config)
+	}
+}
+
+// BuildStore creates a Store from a JSON object whose "type" field names a
+// registered store, e.g.
+//
+//	{"type": "file", "path": "data"}
+func BuildStore(raw json.RawMessage) (Store, error) {
+	name, factory, err := stores.lookup(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := factory(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s config: %w", name, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,91 @@
+package status_neko
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type echoConfig struct {
+	Message string `json:"message"`
+}
+
+type echoMonitor struct {
+	config echoConfig
+}
+
+func (e echoMonitor) Name() string {
+	return "echo"
+}
+
+func (e echoMonitor) Check(ctx context.Context) (*Result, error) {
+	return NewResult(StatusUp, time.Now(), e.config.Message, nil), nil
+}
+
+func init() {
+	Register("echo", NewFactory(func(c echoConfig) Monitor {
+		return echoMonitor{config: c}
+	}))
+}
+
+func TestBuild(t *testing.T) {
+	m, err := Build(json.RawMessage(`{"type": "echo", "message": "hello"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "echo", m.Name())
+
+	result, err := m.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "hello", result.Message)
+
+	assert.Contains(t, Providers(), "echo")
+}
+
+func TestBuild_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"invalid json", `{`, "invalid monitor config"},
+		{"missing type", `{"message": "hello"}`, "monitor config has no type"},
+		{"unknown type", `{"type": "nope"}`, `unknown monitor type "nope"`},
+		{"invalid config", `{"type": "echo", "message": 1}`, "invalid echo config"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Build(json.RawMessage(tt.raw))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestRegister_Duplicate(t *testing.T) {
+	assert.Panics(t, func() {
+		Register("echo", NewFactory(func(c echoConfig) Monitor {
+			return echoMonitor{config: c}
+		}))
+	})
+}
+
+func TestDuration(t *testing.T) {
+	var v struct {
+		A Duration `json:"a"`
+		B Duration `json:"b"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"a": "1m30s", "b": 1000}`), &v))
+	assert.Equal(t, Duration(90*time.Second), v.A)
+	assert.Equal(t, Duration(time.Microsecond), v.B)
+
+	b, err := json.Marshal(v.A)
+	require.NoError(t, err)
+	assert.Equal(t, `"1m30s"`, string(b))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"a": "soon"}`), &v))
+	assert.Error(t, json.Unmarshal([]byte(`{"a": true}`), &v))
+}
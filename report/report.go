@@ -0,0 +1,334 @@
+// Package report computes availability reports from the history kept by a
+// status_neko.Store.
+package report
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+// defaultLookback is how far before a window the status at its start is
+// looked up when no max gap is set.
+var defaultLookback = 24 * time.Hour
+
+// Window is the time range [From, To) a report covers.
+type Window struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// Last returns the window of duration d ending at now, e.g. Last(now, 24*time.Hour).
+func Last(now time.Time, d time.Duration) Window {
+	return Window{From: now.Add(-d), To: now}
+}
+
+// Month returns the calendar month containing t in the location of t.
+func Month(t time.Time) Window {
+	from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return Window{From: from, To: from.AddDate(0, 1, 0)}
+}
+
+// Incident is a period during which a monitor was failing.
+type Incident struct {
+	Monitor string    `json:"monitor"`
+	Start   time.Time `json:"start"`
+	// End is zero while the incident is still ongoing at the end of the window.
+	End time.Time `json:"end,omitempty"`
+	// Downtime excludes the excluded windows the incident overlaps.
+	Downtime time.Duration `json:"downtime"`
+}
+
+// Budget is the error budget of an SLO over the window.
+type Budget struct {
+	// Target is the SLO in percent, e.g. 99.9.
+	Target    float64       `json:"target"`
+	Allowed   time.Duration `json:"allowed"`
+	Consumed  time.Duration `json:"consumed"`
+	Remaining time.Duration `json:"remaining"`
+	// Burned is Consumed/Allowed, above 1 the SLO is violated.
+	Burned float64 `json:"burned"`
+}
+
+// Report is the availability of a monitor or a group over a window.
+type Report struct {
+	Name   string `json:"name"`
+	Window Window `json:"window"`
+
+	// Monitored is the time the status was known, excluded windows,
+	// maintenance and periods without data do not count.
+	Monitored time.Duration `json:"monitored"`
+	Downtime  time.Duration `json:"downtime"`
+	// Uptime is the percentage of Monitored that was not down.
+	Uptime    float64    `json:"uptime"`
+	Incidents []Incident `json:"incidents"`
+	// MTTR is the mean downtime of an incident, MTBF the mean uptime
+	// between incidents, both are zero without incidents.
+	MTTR time.Duration `json:"mttr"`
+	MTBF time.Duration `json:"mtbf"`
+
+	Budget *Budget `json:"budget,omitempty"`
+
+	// Monitors are the reports of the members of a group.
+	Monitors []*Report `json:"monitors,omitempty"`
+}
+
+type option struct {
+	slo     float64
+	exclude []Window
+	maxGap  time.Duration
+	now     func() time.Time
+}
+
+// SetSLO sets the availability target in percent used for the error budget,
+// zero reports no budget.
+func SetSLO(target float64) status_neko.Option[*option] {
+	return func(o *option) {
+		o.slo = target
+	}
+}
+
+// SetExclude excludes windows, e.g. planned maintenance, from the reports.
+func SetExclude(windows ...Window) status_neko.Option[*option] {
+	return func(o *option) {
+		o.exclude = append(o.exclude, windows...)
+	}
+}
+
+// SetMaxGap limits how long a result is assumed to hold, beyond it the
+// status is unknown, e.g. while status-neko itself was not running.
+// Zero keeps every result until the next one, the status at the start of a
+// window is then looked up at most a day before it.
+func SetMaxGap(d time.Duration) status_neko.Option[*option] {
+	return func(o *option) {
+		o.maxGap = d
+	}
+}
+
+// Reporter computes reports from stored results. A result holds from its
+// timestamp until the next one, where the raw results were already
+// downsampled the hourly rollups are used and a bucket counts as down for
+// the share of failed checks.
+type Reporter struct {
+	store  status_neko.Store
+	option *option
+}
+
+func NewReporter(store status_neko.Store, opts ...status_neko.Option[*option]) *Reporter {
+	o := &option{
+		now: time.Now,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &Reporter{
+		store:  store,
+		option: o,
+	}
+}
+
+// Monitor reports the availability of a monitor.
+func (r *Reporter) Monitor(ctx context.Context, monitor string, w Window) (*Report, error) {
+	w = r.clip(w)
+	segments, err := r.segments(ctx, monitor, w)
+	if err != nil {
+		return nil, fmt.Errorf("monitor %s: %w", monitor, err)
+	}
+
+	report := &Report{
+		Name:      monitor,
+		Window:    w,
+		Incidents: []Incident{},
+	}
+	var incident *Incident
+	for _, s := range segments {
+		d := s.end.Sub(s.start)
+		down := time.Duration(float64(d) * s.down)
+		report.Monitored += d
+		report.Downtime += down
+
+		switch {
+		case s.down > 0 && incident == nil:
+			report.Incidents = append(report.Incidents, Incident{Monitor: monitor, Start: s.start})
+			incident = &report.Incidents[len(report.Incidents)-1]
+			incident.Downtime = down
+		case s.down > 0:
+			incident.Downtime += down
+		case incident != nil:
+			incident.End = s.start
+			incident = nil
+		}
+	}
+	r.summarize(report)
+	return report, nil
+}
+
+// Group reports the combined availability of several monitors, their
+// monitored time and downtime add up.
+func (r *Reporter) Group(ctx context.Context, name string, monitors []string, w Window) (*Report, error) {
+	w = r.clip(w)
+	report := &Report{
+		Name:      name,
+		Window:    w,
+		Incidents: []Incident{},
+	}
+	for _, monitor := range monitors {
+		m, err := r.Monitor(ctx, monitor, w)
+		if err != nil {
+			return nil, err
+		}
+		report.Monitors = append(report.Monitors, m)
+		report.Monitored += m.Monitored
+		report.Downtime += m.Downtime
+		report.Incidents = append(report.Incidents, m.Incidents...)
+	}
+	sort.SliceStable(report.Incidents, func(i, j int) bool {
+		return report.Incidents[i].Start.Before(report.Incidents[j].Start)
+	})
+	r.summarize(report)
+	return report, nil
+}
+
+// clip keeps the window from reaching into the future.
+func (r *Reporter) clip(w Window) Window {
+	if now := r.option.now(); w.To.IsZero() || w.To.After(now) {
+		w.To = now
+	}
+	return w
+}
+
+func (r *Reporter) summarize(report *Report) {
+	uptime := report.Monitored - report.Downtime
+	if report.Monitored > 0 {
+		report.Uptime = 100 * float64(uptime) / float64(report.Monitored)
+	}
+	if n := len(report.Incidents); n > 0 {
+		report.MTTR = report.Downtime / time.Duration(n)
+		report.MTBF = uptime / time.Duration(n)
+	}
+
+	if r.option.slo > 0 {
+		allowed := time.Duration(float64(report.Monitored) * (1 - r.option.slo/100))
+		budget := &Budget{
+			Target:    r.option.slo,
+			Allowed:   allowed,
+			Consumed:  report.Downtime,
+			Remaining: allowed - report.Downtime,
+		}
+		if allowed > 0 {
+			budget.Burned = float64(report.Downtime) / float64(allowed)
+		}
+		report.Budget = budget
+	}
+}
+
+// segment is a period of known status, down is the share of it that was down.
+type segment struct {
+	start time.Time
+	end   time.Time
+	down  float64
+}
+
+func (r *Reporter) segments(ctx context.Context, monitor string, w Window) ([]segment, error) {
+	if !w.From.Before(w.To) {
+		return nil, nil
+	}
+
+	records, err := r.store.Query(ctx, status_neko.Query{Monitor: monitor, From: w.From, To: w.To})
+	if err != nil {
+		return nil, err
+	}
+	// 窗口开始前的最后一个结果决定开始时的状态, 只向前查找 maxGap, 不读取全部历史
+	lookback := r.option.maxGap
+	if lookback <= 0 {
+		lookback = defaultLookback
+	}
+	previous, err := r.store.Query(ctx, status_neko.Query{Monitor: monitor, From: w.From.Add(-lookback), To: w.From, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	records = append(previous, records...)
+
+	var segments []segment
+
+	// 原始记录之前的部分使用小时汇总
+	rawStart := w.To
+	if len(records) > 0 {
+		rawStart = records[0].Timestamp
+	}
+	if rawStart.After(w.From) {
+		rollups, err := r.store.Rollups(ctx, status_neko.Query{
+			Monitor: monitor,
+			From:    status_neko.ResolutionHour.Truncate(w.From),
+			To:      rawStart,
+		}, status_neko.ResolutionHour)
+		if err != nil {
+			return nil, err
+		}
+		for _, rollup := range rollups {
+			end := rollup.Start.Add(time.Hour)
+			if end.After(rawStart) || rollup.Count == 0 {
+				continue
+			}
+			segments = append(segments, segment{
+				start: later(rollup.Start, w.From),
+				end:   end,
+				down:  float64(rollup.Failures) / float64(rollup.Count),
+			})
+		}
+	}
+
+	for i, record := range records {
+		end := w.To
+		if i+1 < len(records) {
+			end = records[i+1].Timestamp
+		}
+		if r.option.maxGap > 0 && end.Sub(record.Timestamp) > r.option.maxGap {
+			end = record.Timestamp.Add(r.option.maxGap)
+		}
+		if record.Maintenance {
+			continue
+		}
+		s := segment{start: later(record.Timestamp, w.From), end: end}
+		if record.Status == status_neko.StatusDown {
+			s.down = 1
+		}
+		if s.end.After(s.start) {
+			segments = append(segments, s)
+		}
+	}
+
+	return exclude(segments, r.option.exclude), nil
+}
+
+// exclude cuts the excluded windows out of segments.
+func exclude(segments []segment, windows []Window) []segment {
+	for _, w := range windows {
+		var kept []segment
+		for _, s := range segments {
+			if !s.start.Before(w.To) || !s.end.After(w.From) {
+				kept = append(kept, s)
+				continue
+			}
+			if s.start.Before(w.From) {
+				kept = append(kept, segment{start: s.start, end: w.From, down: s.down})
+			}
+			if s.end.After(w.To) {
+				kept = append(kept, segment{start: w.To, end: s.end, down: s.down})
+			}
+		}
+		segments = kept
+	}
+	return segments
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,219 @@
+package report
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/songzhibin97/status-neko/store/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var base = time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+
+// history stores a check every 10 minutes for 6 hours, down within
+// [1h, 1h30m) and [3h, 3h10m).
+func history(t *testing.T, store status_neko.Store, monitor string, failing bool) {
+	for i := 0; i < 36; i++ {
+		at := time.Duration(i) * 10 * time.Minute
+		status := status_neko.StatusUp
+		if failing && (at >= time.Hour && at < 90*time.Minute || at == 3*time.Hour) {
+			status = status_neko.StatusDown
+		}
+		require.NoError(t, store.Append(context.Background(), status_neko.Record{
+			Monitor:   monitor,
+			Status:    status,
+			Timestamp: base.Add(at),
+		}))
+	}
+}
+
+func newReporter(store status_neko.Store, opts ...status_neko.Option[*option]) *Reporter {
+	r := NewReporter(store, opts...)
+	r.option.now = func() time.Time { return base.Add(6 * time.Hour) }
+	return r
+}
+
+func TestReporter_Monitor(t *testing.T) {
+	store := memory.NewMemory(memory.Config{})
+	history(t, store, "api", true)
+
+	r := newReporter(store, SetSLO(99))
+	report, err := r.Monitor(context.Background(), "api", Window{From: base, To: base.Add(24 * time.Hour)})
+	require.NoError(t, err)
+
+	// 窗口不超过当前时间
+	assert.Equal(t, base.Add(6*time.Hour), report.Window.To)
+	assert.Equal(t, 6*time.Hour, report.Monitored)
+	assert.Equal(t, 40*time.Minute, report.Downtime)
+	assert.InDelta(t, 100*320.0/360, report.Uptime, 1e-9)
+	assert.Equal(t, []Incident{
+		{Monitor: "api", Start: base.Add(time.Hour), End: base.Add(90 * time.Minute), Downtime: 30 * time.Minute},
+		{Monitor: "api", Start: base.Add(3 * time.Hour), End: base.Add(190 * time.Minute), Downtime: 10 * time.Minute},
+	}, report.Incidents)
+	assert.Equal(t, 20*time.Minute, report.MTTR)
+	assert.Equal(t, 160*time.Minute, report.MTBF)
+
+	require.NotNil(t, report.Budget)
+	assert.Equal(t, 99.0, report.Budget.Target)
+	assert.InDelta(t, float64(216*time.Second), float64(report.Budget.Allowed), float64(time.Millisecond))
+	assert.Equal(t, 40*time.Minute, report.Budget.Consumed)
+	assert.InDelta(t, 2400.0/216, report.Budget.Burned, 1e-6)
+	assert.Less(t, report.Budget.Remaining, time.Duration(0))
+
+	// 窗口内的状态由窗口开始前的最后一个结果决定
+	report, err = r.Monitor(context.Background(), "api", Window{From: base.Add(75 * time.Minute), To: base.Add(2 * time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, 15*time.Minute, report.Downtime)
+	require.Len(t, report.Incidents, 1)
+	assert.Equal(t, base.Add(75*time.Minute), report.Incidents[0].Start)
+}
+
+func TestReporter_Exclude(t *testing.T) {
+	store := memory.NewMemory(memory.Config{})
+	history(t, store, "api", true)
+
+	r := newReporter(store, SetExclude(Window{From: base.Add(time.Hour), To: base.Add(80 * time.Minute)}))
+	report, err := r.Monitor(context.Background(), "api", Window{From: base, To: base.Add(6 * time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, 340*time.Minute, report.Monitored)
+	assert.Equal(t, 20*time.Minute, report.Downtime)
+	require.Len(t, report.Incidents, 2)
+	assert.Equal(t, base.Add(80*time.Minute), report.Incidents[0].Start)
+	assert.Equal(t, 10*time.Minute, report.Incidents[0].Downtime)
+}
+
+func TestReporter_Maintenance(t *testing.T) {
+	store := memory.NewMemory(memory.Config{})
+	// 维护期间 [1h, 1h30m) 的失败不计入
+	for i := 0; i < 36; i++ {
+		at := time.Duration(i) * 10 * time.Minute
+		maintenance := at >= time.Hour && at < 90*time.Minute
+		status := status_neko.StatusUp
+		if maintenance {
+			status = status_neko.StatusDown
+		}
+		require.NoError(t, store.Append(context.Background(), status_neko.Record{
+			Monitor:     "db",
+			Status:      status,
+			Timestamp:   base.Add(at),
+			Maintenance: maintenance,
+		}))
+	}
+
+	report, err := newReporter(store).Monitor(context.Background(), "db", Window{From: base, To: base.Add(6 * time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, 330*time.Minute, report.Monitored)
+	assert.Zero(t, report.Downtime)
+	assert.Equal(t, 100.0, report.Uptime)
+	assert.Empty(t, report.Incidents)
+}
+
+func TestReporter_MaxGap(t *testing.T) {
+	store := memory.NewMemory(memory.Config{})
+	ctx := context.Background()
+	require.NoError(t, store.Append(ctx,
+		status_neko.Record{Monitor: "api", Status: status_neko.StatusUp, Timestamp: base},
+		status_neko.Record{Monitor: "api", Status: status_neko.StatusDown, Timestamp: base.Add(5 * time.Hour)},
+	))
+
+	report, err := newReporter(store, SetMaxGap(time.Hour)).Monitor(ctx, "api", Window{From: base, To: base.Add(6 * time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Hour, report.Monitored)
+	assert.Equal(t, time.Hour, report.Downtime)
+	assert.Equal(t, 50.0, report.Uptime)
+	require.Len(t, report.Incidents, 1)
+	assert.True(t, report.Incidents[0].End.IsZero())
+
+	// 没有数据时可用性未知
+	report, err = newReporter(store).Monitor(ctx, "missing", Window{From: base, To: base.Add(6 * time.Hour)})
+	require.NoError(t, err)
+	assert.Zero(t, report.Monitored)
+	assert.Zero(t, report.Uptime)
+	assert.Empty(t, report.Incidents)
+}
+
+// queries records the queries sent to a store.
+type queries struct {
+	status_neko.Store
+	queries []status_neko.Query
+}
+
+func (q *queries) Query(ctx context.Context, query status_neko.Query) ([]status_neko.Record, error) {
+	q.queries = append(q.queries, query)
+	return q.Store.Query(ctx, query)
+}
+
+func TestReporter_Previous(t *testing.T) {
+	store := &queries{Store: memory.NewMemory(memory.Config{})}
+	ctx := context.Background()
+	require.NoError(t, store.Append(ctx,
+		status_neko.Record{Monitor: "api", Status: status_neko.StatusDown, Timestamp: base.Add(-48 * time.Hour)},
+		status_neko.Record{Monitor: "api", Status: status_neko.StatusDown, Timestamp: base.Add(-30 * time.Minute)},
+		status_neko.Record{Monitor: "api", Status: status_neko.StatusUp, Timestamp: base.Add(time.Hour)},
+	))
+
+	// 窗口开始时的状态取自之前最近的结果
+	window := Window{From: base, To: base.Add(2 * time.Hour)}
+	report, err := newReporter(store).Monitor(ctx, "api", window)
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Hour, report.Monitored)
+	assert.Equal(t, time.Hour, report.Downtime)
+
+	// 只向前查找 maxGap, 默认一天, 不读取全部历史
+	for _, q := range store.queries {
+		assert.False(t, q.From.IsZero())
+	}
+	assert.Equal(t, base.Add(-defaultLookback), store.queries[1].From)
+
+	store.queries = nil
+	_, err = newReporter(store, SetMaxGap(time.Hour)).Monitor(ctx, "api", window)
+	require.NoError(t, err)
+	assert.Equal(t, base.Add(-time.Hour), store.queries[1].From)
+}
+
+func TestReporter_Rollups(t *testing.T) {
+	store := memory.NewMemory(memory.Config{Retention: &status_neko.Retention{Raw: status_neko.Duration(24 * time.Hour)}})
+	history(t, store, "api", true)
+	require.NoError(t, store.Compact(context.Background(), base.Add(72*time.Hour)))
+	records, err := store.Query(context.Background(), status_neko.Query{})
+	require.NoError(t, err)
+	require.Empty(t, records)
+
+	// 降采样后按失败比例估算
+	report, err := newReporter(store).Monitor(context.Background(), "api", Window{From: base, To: base.Add(6 * time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, 6*time.Hour, report.Monitored)
+	assert.Equal(t, 40*time.Minute, report.Downtime)
+	assert.Len(t, report.Incidents, 2)
+}
+
+func TestReporter_Group(t *testing.T) {
+	store := memory.NewMemory(memory.Config{})
+	history(t, store, "api", true)
+	history(t, store, "web", false)
+
+	report, err := newReporter(store, SetSLO(99.9)).Group(context.Background(), "shop", []string{"api", "web"}, Window{From: base, To: base.Add(6 * time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, "shop", report.Name)
+	assert.Equal(t, 12*time.Hour, report.Monitored)
+	assert.Equal(t, 40*time.Minute, report.Downtime)
+	assert.Len(t, report.Incidents, 2)
+	require.Len(t, report.Monitors, 2)
+	assert.Equal(t, 100.0, report.Monitors[1].Uptime)
+	assert.Equal(t, 340*time.Minute, report.MTBF)
+	assert.Greater(t, report.Budget.Burned, 1.0)
+}
+
+func TestWindow(t *testing.T) {
+	cst := time.FixedZone("CST", 8*3600)
+	assert.Equal(t, Window{
+		From: time.Date(2024, 10, 1, 0, 0, 0, 0, cst),
+		To:   time.Date(2024, 11, 1, 0, 0, 0, 0, cst),
+	}, Month(time.Date(2024, 10, 15, 12, 0, 0, 0, cst)))
+
+	now := time.Date(2024, 10, 15, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, Window{From: now.Add(-7 * 24 * time.Hour), To: now}, Last(now, 7*24*time.Hour))
+}
@@ -0,0 +1,93 @@
+package status_neko
+
+import (
+	"context"
+	"time"
+)
+
+// Status is the health reported by a single check.
+type Status string
+
+var (
+	StatusUp       Status = "up"
+	StatusDown     Status = "down"
+	StatusDegraded Status = "degraded"
+)
+
+// Result is the outcome of Monitor.Check shared by all providers.
+type Result struct {
+	Status    Status        `json:"status"`
+	Latency   time.Duration `json:"latency"`
+	Message   string        `json:"message,omitempty"`
+	Timestamp time.Time     `json:"timestamp"`
+
+	// Details is the provider specific payload, each provider documents its
+	// own type, e.g. *tcp.Details or *http.Details.
+	Details interface{} `json:"details,omitempty"`
+}
+
+// Measurer is implemented by result details carrying numeric values worth
+// exporting, e.g. to Prometheus. Keys are snake_case and include the unit,
+// such as "days_left" or "packet_loss_ratio".
+type Measurer interface {
+	Measurements() map[string]float64
+}
+
+// NewResult builds the result of a check started at start.
+func NewResult(status Status, start time.Time, message string, details interface{}) *Result {
+	return &Result{
+		Status:    status,
+		Latency:   time.Since(start),
+		Message:   message,
+		Timestamp: start,
+		Details:   details,
+	}
+}
+
+// Complete normalizes what Check returned: a failed check always yields a
+// StatusDown result carrying the error message, even when the monitor
+// returned no result at all.
+func Complete(r *Result, err error, start time.Time) *Result {
+	if r == nil {
+		if err == nil {
+			return NewResult(StatusUp, start, "", nil)
+		}
+		return NewResult(StatusDown, start, err.Error(), nil)
+	}
+
+	if r.Timestamp.IsZero() {
+		r.Timestamp = start
+	}
+	if err != nil {
+		r.Status = StatusDown
+		if r.Message == "" {
+			r.Message = err.Error()
+		}
+	}
+	return r
+}
+
+// LegacyMonitor is the Monitor interface from before Check returned a *Result.
+type LegacyMonitor interface {
+	Name() string
+	Check(ctx context.Context) (interface{}, error)
+}
+
+// Adapt turns a LegacyMonitor into a Monitor, the value returned by the
+// legacy Check becomes Result.Details.
+func Adapt(m LegacyMonitor) Monitor {
+	return legacyMonitor{m}
+}
+
+type legacyMonitor struct {
+	LegacyMonitor
+}
+
+func (l legacyMonitor) Check(ctx context.Context) (*Result, error) {
+	start := time.Now()
+	details, err := l.LegacyMonitor.Check(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewResult(StatusUp, start, "", details), nil
+}
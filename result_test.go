@@ -0,0 +1,60 @@
+package status_neko
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockLegacyMonitor struct {
+	value interface{}
+	err   error
+}
+
+func (m mockLegacyMonitor) Name() string {
+	return "legacy"
+}
+
+func (m mockLegacyMonitor) Check(ctx context.Context) (interface{}, error) {
+	return m.value, m.err
+}
+
+func TestAdapt(t *testing.T) {
+	m := Adapt(mockLegacyMonitor{value: map[string]string{"status": "ok"}})
+	assert.Equal(t, "legacy", m.Name())
+
+	result, err := m.Check(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, StatusUp, result.Status)
+	assert.Equal(t, map[string]string{"status": "ok"}, result.Details)
+	assert.False(t, result.Timestamp.IsZero())
+
+	m = Adapt(mockLegacyMonitor{err: errors.New("boom")})
+	result, err = m.Check(context.Background())
+	assert.EqualError(t, err, "boom")
+	assert.Nil(t, result)
+}
+
+func TestComplete(t *testing.T) {
+	start := time.Now()
+
+	r := Complete(nil, errors.New("boom"), start)
+	assert.Equal(t, StatusDown, r.Status)
+	assert.Equal(t, "boom", r.Message)
+	assert.Equal(t, start, r.Timestamp)
+
+	r = Complete(&Result{Status: StatusUp, Details: 1}, errors.New("boom"), start)
+	assert.Equal(t, StatusDown, r.Status)
+	assert.Equal(t, "boom", r.Message)
+	assert.Equal(t, 1, r.Details)
+
+	r = Complete(&Result{Status: StatusDegraded, Message: "slow"}, nil, start)
+	assert.Equal(t, StatusDegraded, r.Status)
+	assert.Equal(t, "slow", r.Message)
+
+	r = Complete(nil, nil, start)
+	assert.Equal(t, StatusUp, r.Status)
+}
@@ -0,0 +1,440 @@
+package status_neko
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrSchedulerRunning is returned by Run when the scheduler is already running.
+	ErrSchedulerRunning = errors.New("scheduler is already running")
+	// ErrMonitorNotFound is returned for a name that was not added to the scheduler.
+	ErrMonitorNotFound = errors.New("monitor not found")
+
+	defaultWorkers  = 16
+	defaultInterval = 60 * time.Second
+)
+
+// Report is the outcome of a single scheduled check.
+type Report struct {
+	// Name is the name the monitor was added to the scheduler with.
+	Name    string
+	Monitor Monitor
+	// Tags are the user defined labels of the monitor, see SetTags.
+	Tags map[string]string
+
+	// Result is never nil, a failed check is reported as a StatusDown
+	// result next to the error Monitor.Check returned.
+	Result *Result
+	Err    error
+
+	Start    time.Time
+	Duration time.Duration
+	// Interval is the interval the monitor is checked at.
+	Interval time.Duration
+}
+
+type schedulerOption struct {
+	workers int
+}
+
+// SetWorkers limits how many checks may run at the same time,
+// zero or less keeps the default.
+func SetWorkers(n int) Option[*schedulerOption] {
+	return func(o *schedulerOption) {
+		o.workers = n
+	}
+}
+
+type schedule struct {
+	interval time.Duration
+	timeout  time.Duration
+	jitter   time.Duration
+	tags     map[string]string
+	paused   bool
+}
+
+// SetInterval sets how often the monitor is checked.
+func SetInterval(interval time.Duration) Option[*schedule] {
+	return func(o *schedule) {
+		o.interval = interval
+	}
+}
+
+// SetTimeout sets the deadline of a single check, it defaults to the interval.
+func SetTimeout(timeout time.Duration) Option[*schedule] {
+	return func(o *schedule) {
+		o.timeout = timeout
+	}
+}
+
+// SetJitter delays the first check by a random duration in [0, jitter),
+// so that monitors added together do not all fire at the same moment.
+func SetJitter(jitter time.Duration) Option[*schedule] {
+	return func(o *schedule) {
+		o.jitter = jitter
+	}
+}
+
+// SetTags attaches user defined labels to the reports of the monitor,
+// e.g. {"env": "prod", "team": "payment"}.
+func SetTags(tags map[string]string) Option[*schedule] {
+	return func(o *schedule) {
+		o.tags = tags
+	}
+}
+
+// SetPaused adds the monitor without checking it until Resume is called.
+func SetPaused(paused bool) Option[*schedule] {
+	return func(o *schedule) {
+		o.paused = paused
+	}
+}
+
+type job struct {
+	name     string
+	monitor  Monitor
+	schedule *schedule
+
+	// mu is held for the duration of a check so that the same monitor
+	// never runs twice at once.
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	// paused is guarded by Scheduler.mu.
+	paused bool
+}
+
+// Scheduler runs monitors periodically on a bounded worker pool and
+// streams the reports to its subscribers.
+type Scheduler struct {
+	option *schedulerOption
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	running bool
+	// ctx is non-nil while Run accepts new jobs.
+	ctx context.Context
+	sem chan struct{}
+	wg  sync.WaitGroup
+
+	reports broadcaster[Report]
+}
+
+func NewScheduler(opts ...Option[*schedulerOption]) *Scheduler {
+	o := &schedulerOption{
+		workers: defaultWorkers,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.workers <= 0 {
+		o.workers = defaultWorkers
+	}
+
+	return &Scheduler{
+		option: o,
+		jobs:   make(map[string]*job),
+	}
+}
+
+// Add registers a monitor under a unique name and starts it, see Starter.
+// If the scheduler is already running the monitor is checked right away.
+func (s *Scheduler) Add(name string, m Monitor, opts ...Option[*schedule]) error {
+	j, err := newJob(name, m, opts)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("monitor %s already exists", name)
+	}
+	if err := Start(m); err != nil {
+		return fmt.Errorf("monitor %s: %w", name, err)
+	}
+
+	s.jobs[name] = j
+	if s.ctx != nil && !j.paused {
+		s.start(j)
+	}
+	return nil
+}
+
+// Replace swaps the named monitor and its schedule at once, a check of the
+// old monitor in flight is cancelled. On error the old monitor keeps running.
+func (s *Scheduler) Replace(name string, m Monitor, opts ...Option[*schedule]) error {
+	j, err := newJob(name, m, opts)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.jobs[name]
+	if !ok {
+		return ErrMonitorNotFound
+	}
+	// 先启动新的监控项再停止旧的, 两者共用的资源 (例如推送的 token) 不会中断
+	if err := Start(m); err != nil {
+		return fmt.Errorf("monitor %s: %w", name, err)
+	}
+	if old.cancel != nil {
+		old.cancel()
+	}
+	Stop(old.monitor)
+
+	s.jobs[name] = j
+	if s.ctx != nil && !j.paused {
+		s.start(j)
+	}
+	return nil
+}
+
+// Remove stops and forgets the named monitor, a check in flight is cancelled.
+func (s *Scheduler) Remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[name]
+	if !ok {
+		return false
+	}
+	if j.cancel != nil {
+		j.cancel()
+	}
+	Stop(j.monitor)
+	delete(s.jobs, name)
+	return true
+}
+
+// Names returns the names of all registered monitors.
+func (s *Scheduler) Names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.jobs))
+	for name := range s.jobs {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Pause stops checking the named monitor until Resume, a check in flight
+// is cancelled.
+func (s *Scheduler) Pause(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[name]
+	if !ok {
+		return ErrMonitorNotFound
+	}
+	j.paused = true
+	if j.cancel != nil {
+		j.cancel()
+		j.cancel = nil
+	}
+	return nil
+}
+
+// Resume checks a paused monitor again, right away if the scheduler is running.
+func (s *Scheduler) Resume(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[name]
+	if !ok {
+		return ErrMonitorNotFound
+	}
+	if !j.paused {
+		return nil
+	}
+	j.paused = false
+	if s.ctx != nil {
+		s.start(j)
+	}
+	return nil
+}
+
+// Paused reports whether the named monitor is paused.
+func (s *Scheduler) Paused(name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[name]
+	if !ok {
+		return false, ErrMonitorNotFound
+	}
+	return j.paused, nil
+}
+
+// Check runs the named monitor once, outside of its schedule and of the
+// worker limit, and returns the report. Paused monitors can be checked too.
+// While the scheduler is running the report is also sent to the subscribers.
+func (s *Scheduler) Check(ctx context.Context, name string) (Report, error) {
+	s.mu.Lock()
+	j, ok := s.jobs[name]
+	running := s.ctx != nil
+	if running {
+		// Run 会等待这次检测的结果发布完成
+		s.wg.Add(1)
+		defer s.wg.Done()
+	}
+	s.mu.Unlock()
+	if !ok {
+		return Report{}, ErrMonitorNotFound
+	}
+
+	r := s.run(ctx, j)
+	if running && ctx.Err() == nil {
+		s.reports.publish(ctx, r)
+	}
+	return r, nil
+}
+
+// Subscribe returns a channel receiving every report. A slow subscriber
+// applies back pressure to the workers, so buffer accordingly.
+// The channel is closed by the returned cancel function or when Run returns.
+func (s *Scheduler) Subscribe(buffer int) (<-chan Report, func()) {
+	return s.reports.subscribe(buffer)
+}
+
+// Run checks every monitor on its schedule until ctx is cancelled, then
+// waits for the checks in flight and closes all subscriptions.
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return ErrSchedulerRunning
+	}
+	s.running = true
+	s.ctx = ctx
+	s.sem = make(chan struct{}, s.option.workers)
+	for _, j := range s.jobs {
+		if !j.paused {
+			s.start(j)
+		}
+	}
+	s.mu.Unlock()
+
+	<-ctx.Done()
+
+	s.mu.Lock()
+	s.ctx = nil
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	s.reports.closeAll()
+
+	s.mu.Lock()
+	s.running = false
+	s.mu.Unlock()
+	return ctx.Err()
+}
+
+func newJob(name string, m Monitor, opts []Option[*schedule]) (*job, error) {
+	sc := &schedule{
+		interval: defaultInterval,
+	}
+	for _, opt := range opts {
+		opt(sc)
+	}
+	if sc.interval <= 0 {
+		return nil, fmt.Errorf("monitor %s: interval must be positive", name)
+	}
+	if sc.timeout <= 0 {
+		sc.timeout = sc.interval
+	}
+
+	return &job{
+		name:     name,
+		monitor:  m,
+		schedule: sc,
+		paused:   sc.paused,
+	}, nil
+}
+
+// start launches the loop of j, the caller must hold s.mu.
+func (s *Scheduler) start(j *job) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	j.cancel = cancel
+
+	s.wg.Add(1)
+	go s.loop(ctx, j)
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	defer s.wg.Done()
+
+	if j.schedule.jitter > 0 {
+		timer := time.NewTimer(time.Duration(rand.Int63n(int64(j.schedule.jitter))))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+
+	ticker := time.NewTicker(j.schedule.interval)
+	defer ticker.Stop()
+
+	for {
+		s.check(ctx, j)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) check(ctx context.Context, j *job) {
+	select {
+	case s.sem <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+
+	r := s.run(ctx, j)
+
+	<-s.sem
+
+	if ctx.Err() != nil {
+		// 调度已停止, 结果不再有意义
+		return
+	}
+
+	s.reports.publish(ctx, r)
+}
+
+// run checks j once, never running the same monitor twice at once.
+func (s *Scheduler) run(ctx context.Context, j *job) Report {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	checkCtx, cancel := context.WithTimeout(ctx, j.schedule.timeout)
+	defer cancel()
+	start := time.Now()
+	result, err := j.monitor.Check(checkCtx)
+	duration := time.Since(start)
+
+	return Report{
+		Name:     j.name,
+		Monitor:  j.monitor,
+		Tags:     j.schedule.tags,
+		Result:   Complete(result, err, start),
+		Err:      err,
+		Start:    start,
+		Duration: duration,
+		Interval: j.schedule.interval,
+	}
+}
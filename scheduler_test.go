@@ -0,0 +1,277 @@
+package status_neko
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockMonitor struct {
+	delay time.Duration
+	err   error
+
+	calls    atomic.Int32
+	inflight atomic.Int32
+	maxSeen  atomic.Int32
+	shared   *atomic.Int32
+	sharedHi *atomic.Int32
+}
+
+func (m *mockMonitor) Name() string {
+	return "mock"
+}
+
+func (m *mockMonitor) Check(ctx context.Context) (*Result, error) {
+	start := time.Now()
+	m.calls.Add(1)
+	n := m.inflight.Add(1)
+	defer m.inflight.Add(-1)
+	storeMax(&m.maxSeen, n)
+
+	if m.shared != nil {
+		sn := m.shared.Add(1)
+		defer m.shared.Add(-1)
+		storeMax(m.sharedHi, sn)
+	}
+
+	select {
+	case <-time.After(m.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	return NewResult(StatusUp, start, "ok", nil), nil
+}
+
+func storeMax(v *atomic.Int32, n int32) {
+	for {
+		cur := v.Load()
+		if n <= cur || v.CompareAndSwap(cur, n) {
+			return
+		}
+	}
+}
+
+func TestScheduler_Run(t *testing.T) {
+	s := NewScheduler()
+	up := &mockMonitor{}
+	down := &mockMonitor{err: errors.New("boom")}
+	require.NoError(t, s.Add("up", up, SetInterval(10*time.Millisecond)))
+	require.NoError(t, s.Add("down", down, SetInterval(10*time.Millisecond)))
+	assert.Error(t, s.Add("up", up))
+
+	reports, _ := s.Subscribe(16)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+
+	seen := map[string]int{}
+	for r := range reports {
+		seen[r.Name]++
+		switch r.Name {
+		case "up":
+			assert.NoError(t, r.Err)
+			assert.Equal(t, StatusUp, r.Result.Status)
+			assert.Equal(t, "ok", r.Result.Message)
+		case "down":
+			assert.EqualError(t, r.Err, "boom")
+			assert.Equal(t, StatusDown, r.Result.Status)
+			assert.Equal(t, "boom", r.Result.Message)
+		}
+		if seen["up"] >= 3 && seen["down"] >= 3 {
+			cancel()
+			break
+		}
+	}
+
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	// Run 返回后订阅被关闭
+	for range reports {
+	}
+}
+
+func TestScheduler_NoOverlap(t *testing.T) {
+	s := NewScheduler()
+	m := &mockMonitor{delay: 30 * time.Millisecond}
+	require.NoError(t, s.Add("slow", m, SetInterval(time.Millisecond), SetTimeout(time.Second)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_ = s.Run(ctx)
+
+	assert.Greater(t, m.calls.Load(), int32(1))
+	assert.Equal(t, int32(1), m.maxSeen.Load())
+}
+
+func TestScheduler_Workers(t *testing.T) {
+	s := NewScheduler(SetWorkers(2))
+
+	var shared, hi atomic.Int32
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		m := &mockMonitor{delay: 20 * time.Millisecond, shared: &shared, sharedHi: &hi}
+		require.NoError(t, s.Add(name, m, SetInterval(5*time.Millisecond)))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	_ = s.Run(ctx)
+
+	assert.Equal(t, int32(2), hi.Load())
+}
+
+func TestScheduler_Timeout(t *testing.T) {
+	s := NewScheduler()
+	m := &mockMonitor{delay: time.Second}
+	require.NoError(t, s.Add("hang", m, SetInterval(time.Second), SetTimeout(10*time.Millisecond)))
+
+	reports, _ := s.Subscribe(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Run(ctx)
+	defer cancel()
+
+	r := <-reports
+	assert.ErrorIs(t, r.Err, context.DeadlineExceeded)
+	assert.Less(t, r.Duration, 500*time.Millisecond)
+}
+
+func TestScheduler_AddRemoveWhileRunning(t *testing.T) {
+	s := NewScheduler()
+	reports, unsubscribe := s.Subscribe(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_ = s.Run(ctx)
+	}()
+
+	m := &mockMonitor{}
+	require.NoError(t, s.Add("late", m, SetInterval(5*time.Millisecond), SetJitter(5*time.Millisecond)))
+
+	r := <-reports
+	assert.Equal(t, "late", r.Name)
+
+	assert.True(t, s.Remove("late"))
+	assert.False(t, s.Remove("late"))
+	assert.Empty(t, s.Names())
+
+	unsubscribe()
+	_, ok := <-reports
+	assert.False(t, ok)
+
+	cancel()
+	wg.Wait()
+	assert.ErrorIs(t, s.Run(ctx), context.Canceled)
+}
+
+func TestScheduler_Replace(t *testing.T) {
+	s := NewScheduler()
+	old, m := &mockMonitor{}, &mockMonitor{}
+	require.NoError(t, s.Add("replaced", old, SetInterval(5*time.Millisecond)))
+	assert.ErrorIs(t, s.Replace("missing", m), ErrMonitorNotFound)
+
+	reports, _ := s.Subscribe(16)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+	<-reports
+
+	// 替换失败时旧的监控项继续运行
+	assert.Error(t, s.Replace("replaced", m, SetInterval(-time.Second)))
+	r := <-reports
+	assert.Same(t, old, r.Monitor)
+
+	require.NoError(t, s.Replace("replaced", m, SetInterval(5*time.Millisecond)))
+	for r.Monitor != Monitor(m) {
+		r = <-reports
+	}
+	calls := old.calls.Load()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, calls, old.calls.Load())
+	assert.Equal(t, []string{"replaced"}, s.Names())
+}
+
+func TestScheduler_PauseResume(t *testing.T) {
+	s := NewScheduler()
+	m := &mockMonitor{}
+	require.NoError(t, s.Add("paused", m, SetInterval(5*time.Millisecond), SetPaused(true)))
+	assert.ErrorIs(t, s.Pause("missing"), ErrMonitorNotFound)
+	assert.ErrorIs(t, s.Resume("missing"), ErrMonitorNotFound)
+
+	reports, _ := s.Subscribe(16)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, int32(0), m.calls.Load())
+	paused, err := s.Paused("paused")
+	require.NoError(t, err)
+	assert.True(t, paused)
+
+	require.NoError(t, s.Resume("paused"))
+	r := <-reports
+	assert.Equal(t, "paused", r.Name)
+
+	require.NoError(t, s.Pause("paused"))
+	// 等待进行中的检测结束
+	time.Sleep(20 * time.Millisecond)
+	for len(reports) > 0 {
+		<-reports
+	}
+	calls := m.calls.Load()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, calls, m.calls.Load())
+}
+
+func TestScheduler_Check(t *testing.T) {
+	s := NewScheduler()
+	m := &mockMonitor{err: errors.New("boom")}
+	require.NoError(t, s.Add("manual", m, SetInterval(time.Hour), SetPaused(true)))
+
+	// 未运行时也可以检测
+	r, err := s.Check(context.Background(), "manual")
+	require.NoError(t, err)
+	assert.Equal(t, StatusDown, r.Result.Status)
+	assert.EqualError(t, r.Err, "boom")
+
+	_, err = s.Check(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrMonitorNotFound)
+
+	reports, _ := s.Subscribe(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = s.Run(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.ctx != nil
+	}, time.Second, time.Millisecond)
+
+	r, err = s.Check(context.Background(), "manual")
+	require.NoError(t, err)
+	published := <-reports
+	assert.Equal(t, r.Start, published.Start)
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,454 @@
+package status_neko
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// State is the alerting state of a monitor derived from its recent results.
+type State string
+
+var (
+	StatePending     State = "PENDING"
+	StateUp          State = "UP"
+	StateDown        State = "DOWN"
+	StateMaintenance State = "MAINTENANCE"
+	// StateUnreachable replaces DOWN while a monitor the failing one
+	// depends on is DOWN or UNREACHABLE itself.
+	StateUnreachable State = "UNREACHABLE"
+)
+
+// Transition is emitted by a Tracker when a monitor changes state.
+type Transition struct {
+	Name     string `json:"name"`
+	Provider string `json:"provider"`
+	From     State  `json:"from"`
+	To       State  `json:"to"`
+
+	At time.Time `json:"at"`
+	// Result is the result that caused the transition, nil when the
+	// transition was caused by SetMaintenance.
+	Result *Result `json:"result,omitempty"`
+
+	// Flapping is set while the monitor changes state too often, alerts for
+	// such transitions should be suppressed. Once it settles a transition
+	// with From equal to To and Flapping unset reports the final state.
+	Flapping bool `json:"flapping"`
+}
+
+// MonitorState is the current state of a monitor kept by a Tracker.
+type MonitorState struct {
+	Name     string    `json:"name"`
+	Provider string    `json:"provider"`
+	State    State     `json:"state"`
+	Since    time.Time `json:"since"`
+	Flapping bool      `json:"flapping"`
+
+	ConsecutiveFailures  int `json:"consecutive_failures"`
+	ConsecutiveSuccesses int `json:"consecutive_successes"`
+
+	LastResult *Result `json:"last_result,omitempty"`
+
+	// maintenance is set between SetMaintenance(true) and SetMaintenance(false),
+	// scheduled while the last report was within a maintenance window.
+	maintenance bool
+	scheduled   bool
+	transitions []time.Time
+
+	// interval and observed are the check interval and the time of the last
+	// result, failingSince the time of the first of the consecutive failures.
+	// held is set while a DOWN transition waits for a parent, see dependency.
+	interval     time.Duration
+	observed     time.Time
+	failingSince time.Time
+	held         bool
+}
+
+type trackerOption struct {
+	failureThreshold  int
+	recoveryThreshold int
+	flapWindow        time.Duration
+	flapThreshold     int
+	maintenance       *Maintenance
+	dependencies      *Dependencies
+}
+
+// SetFailureThreshold sets how many consecutive failures turn a monitor DOWN.
+func SetFailureThreshold(n int) Option[*trackerOption] {
+	return func(o *trackerOption) {
+		o.failureThreshold = n
+	}
+}
+
+// SetRecoveryThreshold sets how many consecutive successes bring a DOWN
+// monitor back UP.
+func SetRecoveryThreshold(n int) Option[*trackerOption] {
+	return func(o *trackerOption) {
+		o.recoveryThreshold = n
+	}
+}
+
+// SetFlapDetection marks a monitor as flapping once it changed state at
+// least threshold times within window. A zero window disables it.
+func SetFlapDetection(window time.Duration, threshold int) Option[*trackerOption] {
+	return func(o *trackerOption) {
+		o.flapWindow = window
+		o.flapThreshold = threshold
+	}
+}
+
+// SetMaintenanceWindows puts monitors into MAINTENANCE while a report is
+// observed within one of their maintenance windows, and out of it with the
+// first report after the window.
+func SetMaintenanceWindows(m *Maintenance) Option[*trackerOption] {
+	return func(o *trackerOption) {
+		o.maintenance = m
+	}
+}
+
+// SetDependencies makes a monitor UNREACHABLE instead of DOWN while one of
+// its parents is DOWN or UNREACHABLE, so that only the root cause alerts.
+func SetDependencies(d *Dependencies) Option[*trackerOption] {
+	return func(o *trackerOption) {
+		o.dependencies = d
+	}
+}
+
+// Tracker turns check reports into per-monitor states:
+//
+//	PENDING --success--> UP
+//	PENDING/UP --N consecutive failures--> DOWN
+//	DOWN --M consecutive successes--> UP
+//	PENDING/UP --failures while a parent is DOWN--> UNREACHABLE --parent recovered--> DOWN
+//	PENDING/UP --failures before a parent was checked again--> held for up to one parent interval
+//	any --SetMaintenance--> MAINTENANCE --end--> PENDING
+//	any --maintenance window--> MAINTENANCE --end--> UP/DOWN
+type Tracker struct {
+	option *trackerOption
+
+	mu       sync.Mutex
+	monitors map[string]*MonitorState
+
+	transitions broadcaster[Transition]
+}
+
+func NewTracker(opts ...Option[*trackerOption]) *Tracker {
+	o := &trackerOption{
+		failureThreshold:  1,
+		recoveryThreshold: 1,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.failureThreshold <= 0 {
+		o.failureThreshold = 1
+	}
+	if o.recoveryThreshold <= 0 {
+		o.recoveryThreshold = 1
+	}
+
+	return &Tracker{
+		option:   o,
+		monitors: make(map[string]*MonitorState),
+	}
+}
+
+// Subscribe returns a channel receiving every transition, see Scheduler.Subscribe.
+func (t *Tracker) Subscribe(buffer int) (<-chan Transition, func()) {
+	return t.transitions.subscribe(buffer)
+}
+
+// Run observes reports until the channel is closed or ctx is done.
+func (t *Tracker) Run(ctx context.Context, reports <-chan Report) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case r, ok := <-reports:
+			if !ok {
+				return
+			}
+			t.observe(ctx, r)
+		}
+	}
+}
+
+// Observe feeds a report into the state machine and returns the transition
+// it caused, if any. The transition is also sent to the subscribers.
+func (t *Tracker) Observe(r Report) (Transition, bool) {
+	return t.observe(context.Background(), r)
+}
+
+func (t *Tracker) observe(ctx context.Context, r Report) (Transition, bool) {
+	provider := ""
+	if r.Monitor != nil {
+		provider = r.Monitor.Name()
+	}
+
+	at := r.Result.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	scheduled := t.option.maintenance.Active(r.Name, r.Tags, at)
+
+	t.mu.Lock()
+	s := t.get(r.Name, provider)
+	s.LastResult = r.Result
+	s.observed = at
+	if r.Interval > 0 {
+		s.interval = r.Interval
+	}
+
+	// current is the state the result is applied to
+	current := s.State
+	if scheduled != s.scheduled {
+		s.scheduled = scheduled
+		if !scheduled && !s.maintenance {
+			// 维护结束, 和 SetMaintenance(false) 一样从 PENDING 重新开始
+			current = StatePending
+			s.ConsecutiveFailures, s.ConsecutiveSuccesses = 0, 0
+		}
+	}
+
+	if r.Result.Status == StatusDown {
+		if s.ConsecutiveFailures == 0 {
+			s.failingSince = at
+		}
+		s.ConsecutiveFailures++
+		s.ConsecutiveSuccesses = 0
+	} else {
+		s.ConsecutiveSuccesses++
+		s.ConsecutiveFailures = 0
+	}
+
+	next := current
+	switch current {
+	case StatePending:
+		if s.ConsecutiveSuccesses > 0 {
+			next = StateUp
+		} else if s.ConsecutiveFailures >= t.option.failureThreshold {
+			next = StateDown
+		}
+	case StateUp:
+		if s.ConsecutiveFailures >= t.option.failureThreshold {
+			next = StateDown
+		}
+	case StateDown:
+		if s.ConsecutiveSuccesses >= t.option.recoveryThreshold {
+			next = StateUp
+		}
+	case StateUnreachable:
+		if s.ConsecutiveSuccesses > 0 {
+			next = StateUp
+		} else if s.ConsecutiveFailures >= t.option.failureThreshold {
+			next = StateDown
+		}
+	}
+	s.held = false
+	if next == StateDown && current != StateDown {
+		var wait bool
+		if next, wait = t.dependency(s, at); wait {
+			next, s.held = current, true
+		}
+	}
+
+	tr, changed := t.transition(s, next, at, r.Result)
+	if !changed && s.Flapping && !t.flapping(s, at) {
+		s.Flapping = false
+		tr, changed = Transition{
+			Name:     s.Name,
+			Provider: s.Provider,
+			From:     s.State,
+			To:       s.State,
+			At:       at,
+			Result:   r.Result,
+		}, true
+	}
+	// 子监控项等待的是这次的结果
+	released := t.release(s.Name, at)
+	t.mu.Unlock()
+
+	if changed {
+		t.transitions.publish(ctx, tr)
+	}
+	for _, tr := range released {
+		t.transitions.publish(ctx, tr)
+	}
+	return tr, changed
+}
+
+// SetMaintenance puts a monitor into or out of MAINTENANCE, results observed
+// meanwhile still update the counters but cause no transition.
+func (t *Tracker) SetMaintenance(name string, on bool) (Transition, bool) {
+	t.mu.Lock()
+	s := t.get(name, "")
+	if s.maintenance == on {
+		t.mu.Unlock()
+		return Transition{}, false
+	}
+	s.maintenance = on
+
+	next := StatePending
+	if on {
+		next = StateMaintenance
+	}
+	s.ConsecutiveFailures, s.ConsecutiveSuccesses = 0, 0
+	tr, changed := t.transition(s, next, time.Now(), nil)
+	t.mu.Unlock()
+
+	if changed {
+		t.transitions.publish(context.Background(), tr)
+	}
+	return tr, changed
+}
+
+// State returns the current state of the named monitor.
+func (t *Tracker) State(name string) (MonitorState, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.monitors[name]
+	if !ok {
+		return MonitorState{}, false
+	}
+	return *s, true
+}
+
+// Remove forgets the state of a monitor that is no longer checked.
+func (t *Tracker) Remove(name string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, ok := t.monitors[name]
+	delete(t.monitors, name)
+	return ok
+}
+
+// States returns the current state of every monitor seen so far.
+func (t *Tracker) States() []MonitorState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	states := make([]MonitorState, 0, len(t.monitors))
+	for _, s := range t.monitors {
+		states = append(states, *s)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Name < states[j].Name
+	})
+	return states
+}
+
+// dependency returns the state of s failing at at: UNREACHABLE while a
+// parent is DOWN or UNREACHABLE, otherwise DOWN. Since the monitors are
+// checked independently a child may fail before its failing parent, so wait
+// is set while a parent has not been checked since the child started
+// failing, or waits itself, for up to one interval of the parent. The
+// caller must hold t.mu.
+func (t *Tracker) dependency(s *MonitorState, at time.Time) (state State, wait bool) {
+	for _, parent := range t.option.dependencies.Parents(s.Name) {
+		p, ok := t.monitors[parent]
+		if !ok {
+			continue
+		}
+		if p.State == StateDown || p.State == StateUnreachable {
+			return StateUnreachable, false
+		}
+		if (p.held || p.observed.Before(s.failingSince)) && at.Sub(s.failingSince) < p.interval {
+			wait = true
+		}
+	}
+	return StateDown, wait
+}
+
+// release decides the held children of name once it was observed, and their
+// own children in turn. The caller must hold t.mu.
+func (t *Tracker) release(name string, at time.Time) []Transition {
+	var transitions []Transition
+	for queue := []string{name}; len(queue) > 0; queue = queue[1:] {
+		for _, s := range t.monitors {
+			if !s.held || !contains(t.option.dependencies.Parents(s.Name), queue[0]) {
+				continue
+			}
+			next, wait := t.dependency(s, at)
+			if wait {
+				continue
+			}
+			s.held = false
+			if tr, changed := t.transition(s, next, at, s.LastResult); changed {
+				transitions = append(transitions, tr)
+			}
+			queue = append(queue, s.Name)
+		}
+	}
+	return transitions
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// get returns the state of name, the caller must hold t.mu.
+func (t *Tracker) get(name, provider string) *MonitorState {
+	s, ok := t.monitors[name]
+	if !ok {
+		s = &MonitorState{
+			Name:  name,
+			State: StatePending,
+			Since: time.Now(),
+		}
+		t.monitors[name] = s
+	}
+	if provider != "" {
+		s.Provider = provider
+	}
+	return s
+}
+
+// transition moves s to next, the caller must hold t.mu.
+func (t *Tracker) transition(s *MonitorState, next State, at time.Time, result *Result) (Transition, bool) {
+	if (s.maintenance || s.scheduled) && next != StateMaintenance {
+		next = StateMaintenance
+	}
+	if next == s.State {
+		return Transition{}, false
+	}
+
+	tr := Transition{
+		Name:     s.Name,
+		Provider: s.Provider,
+		From:     s.State,
+		To:       next,
+		At:       at,
+		Result:   result,
+	}
+	s.State = next
+	s.Since = at
+
+	if t.option.flapWindow > 0 && t.option.flapThreshold > 0 {
+		s.transitions = append(s.transitions, at)
+		s.Flapping = t.flapping(s, at)
+	}
+	tr.Flapping = s.Flapping
+	return tr, true
+}
+
+// flapping drops the transitions of s that left the window and reports
+// whether enough remain, the caller must hold t.mu.
+func (t *Tracker) flapping(s *MonitorState, at time.Time) bool {
+	recent := s.transitions[:0]
+	for _, ts := range s.transitions {
+		if at.Sub(ts) < t.option.flapWindow {
+			recent = append(recent, ts)
+		}
+	}
+	s.transitions = recent
+	return len(recent) >= t.option.flapThreshold
+}
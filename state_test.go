@@ -0,0 +1,307 @@
+package status_neko
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func report(name string, up bool, at time.Time) Report {
+	var err error
+	if !up {
+		err = errors.New("boom")
+	}
+	return Report{
+		Name:    name,
+		Monitor: &mockMonitor{},
+		Result:  Complete(nil, err, at),
+		Err:     err,
+	}
+}
+
+func TestTracker_Thresholds(t *testing.T) {
+	tracker := NewTracker(SetFailureThreshold(3), SetRecoveryThreshold(2))
+	now := time.Now()
+
+	steps := []struct {
+		up      bool
+		changed bool
+		state   State
+	}{
+		{false, false, StatePending},
+		{true, true, StateUp},
+		{false, false, StateUp},
+		{false, false, StateUp},
+		{true, false, StateUp}, // 失败计数被重置
+		{false, false, StateUp},
+		{false, false, StateUp},
+		{false, true, StateDown},
+		{true, false, StateDown},
+		{false, false, StateDown},
+		{true, false, StateDown},
+		{true, true, StateUp},
+	}
+
+	for i, step := range steps {
+		tr, changed := tracker.Observe(report("db", step.up, now.Add(time.Duration(i)*time.Minute)))
+		assert.Equal(t, step.changed, changed, "step %d", i)
+		if changed {
+			assert.Equal(t, step.state, tr.To, "step %d", i)
+			assert.Equal(t, "mock", tr.Provider)
+		}
+
+		s, ok := tracker.State("db")
+		require.True(t, ok)
+		assert.Equal(t, step.state, s.State, "step %d", i)
+	}
+}
+
+func TestTracker_Flapping(t *testing.T) {
+	tracker := NewTracker(SetFlapDetection(10*time.Minute, 3))
+	now := time.Now()
+
+	var transitions []Transition
+	for i := 0; i < 6; i++ {
+		tr, changed := tracker.Observe(report("web", i%2 == 0, now.Add(time.Duration(i)*time.Minute)))
+		require.True(t, changed)
+		transitions = append(transitions, tr)
+	}
+
+	assert.False(t, transitions[0].Flapping)
+	assert.False(t, transitions[1].Flapping)
+	for _, tr := range transitions[2:] {
+		assert.True(t, tr.Flapping)
+	}
+
+	// 在窗口内保持稳定, 仍视为抖动
+	_, changed := tracker.Observe(report("web", false, now.Add(7*time.Minute)))
+	assert.False(t, changed)
+
+	// 窗口过后发出一次 From == To 的事件报告最终状态
+	tr, changed := tracker.Observe(report("web", false, now.Add(30*time.Minute)))
+	require.True(t, changed)
+	assert.False(t, tr.Flapping)
+	assert.Equal(t, StateDown, tr.From)
+	assert.Equal(t, StateDown, tr.To)
+
+	_, changed = tracker.Observe(report("web", false, now.Add(31*time.Minute)))
+	assert.False(t, changed)
+}
+
+func TestTracker_Maintenance(t *testing.T) {
+	tracker := NewTracker()
+	now := time.Now()
+
+	tracker.Observe(report("api", true, now))
+
+	tr, changed := tracker.SetMaintenance("api", true)
+	require.True(t, changed)
+	assert.Equal(t, StateUp, tr.From)
+	assert.Equal(t, StateMaintenance, tr.To)
+
+	_, changed = tracker.Observe(report("api", false, now.Add(time.Minute)))
+	assert.False(t, changed)
+	s, _ := tracker.State("api")
+	assert.Equal(t, StateMaintenance, s.State)
+	assert.Equal(t, 1, s.ConsecutiveFailures)
+
+	tr, changed = tracker.SetMaintenance("api", false)
+	require.True(t, changed)
+	assert.Equal(t, StatePending, tr.To)
+
+	tr, changed = tracker.Observe(report("api", false, now.Add(2*time.Minute)))
+	require.True(t, changed)
+	assert.Equal(t, StateDown, tr.To)
+}
+
+func TestTracker_MaintenanceWindows(t *testing.T) {
+	base := time.Date(2024, 10, 1, 2, 0, 0, 0, time.UTC)
+	maintenance, err := NewMaintenance(MaintenanceWindow{
+		Tags:  map[string]string{"team": "dba"},
+		Start: base,
+		End:   base.Add(time.Hour),
+	})
+	require.NoError(t, err)
+	tracker := NewTracker(SetMaintenanceWindows(maintenance))
+
+	db := func(up bool, at time.Time) Report {
+		r := report("db", up, at)
+		r.Tags = map[string]string{"team": "dba", "env": "prod"}
+		return r
+	}
+
+	_, changed := tracker.Observe(db(true, base.Add(-time.Minute)))
+	require.True(t, changed)
+
+	tr, changed := tracker.Observe(db(false, base))
+	require.True(t, changed)
+	assert.Equal(t, StateUp, tr.From)
+	assert.Equal(t, StateMaintenance, tr.To)
+	assert.False(t, DefaultFilter(tr))
+
+	_, changed = tracker.Observe(db(false, base.Add(30*time.Minute)))
+	assert.False(t, changed)
+
+	// 窗口结束后的第一个结果决定状态
+	tr, changed = tracker.Observe(db(false, base.Add(time.Hour)))
+	require.True(t, changed)
+	assert.Equal(t, StateMaintenance, tr.From)
+	assert.Equal(t, StateDown, tr.To)
+	assert.True(t, DefaultFilter(tr))
+
+	// 不匹配的监控项不受影响
+	tr, changed = tracker.Observe(report("api", false, base))
+	require.True(t, changed)
+	assert.Equal(t, StateDown, tr.To)
+}
+
+func TestTracker_Dependencies(t *testing.T) {
+	deps, err := NewDependencies(
+		Definition{Name: "switch"},
+		Definition{Name: "tcp", DependsOn: []string{"switch"}},
+		Definition{Name: "mysql", DependsOn: []string{"tcp"}},
+	)
+	require.NoError(t, err)
+	tracker := NewTracker(SetDependencies(deps))
+	now := time.Now()
+
+	for _, name := range []string{"switch", "tcp", "mysql"} {
+		tracker.Observe(report(name, true, now))
+	}
+
+	tr, _ := tracker.Observe(report("switch", false, now.Add(time.Minute)))
+	assert.Equal(t, StateDown, tr.To)
+	assert.True(t, DefaultFilter(tr))
+
+	// 父监控项 DOWN 时子监控项的失败不告警, 并继续向下传递
+	tr, _ = tracker.Observe(report("tcp", false, now.Add(time.Minute)))
+	assert.Equal(t, StateUnreachable, tr.To)
+	assert.False(t, DefaultFilter(tr))
+	tr, _ = tracker.Observe(report("mysql", false, now.Add(time.Minute)))
+	assert.Equal(t, StateUnreachable, tr.To)
+
+	_, changed := tracker.Observe(report("tcp", false, now.Add(2*time.Minute)))
+	assert.False(t, changed)
+
+	// 父监控项恢复后仍然失败的子监控项才告警
+	tracker.Observe(report("switch", true, now.Add(3*time.Minute)))
+	tr, _ = tracker.Observe(report("tcp", false, now.Add(3*time.Minute)))
+	assert.Equal(t, StateUnreachable, tr.From)
+	assert.Equal(t, StateDown, tr.To)
+	assert.True(t, DefaultFilter(tr))
+
+	tr, _ = tracker.Observe(report("mysql", true, now.Add(3*time.Minute)))
+	assert.Equal(t, StateUnreachable, tr.From)
+	assert.Equal(t, StateUp, tr.To)
+	assert.False(t, DefaultFilter(tr))
+}
+
+// TestTracker_DependenciesChildFirst: the child fails before the parent is
+// checked again, its DOWN waits for the parent.
+func TestTracker_DependenciesChildFirst(t *testing.T) {
+	deps, err := NewDependencies(
+		Definition{Name: "switch"},
+		Definition{Name: "tcp", DependsOn: []string{"switch"}},
+		Definition{Name: "mysql", DependsOn: []string{"tcp"}},
+	)
+	require.NoError(t, err)
+	now := time.Now()
+	observe := func(tracker *Tracker, name string, up bool, at time.Duration) (Transition, bool) {
+		r := report(name, up, now.Add(at))
+		r.Interval = time.Minute
+		return tracker.Observe(r)
+	}
+	state := func(tracker *Tracker, name string) State {
+		s, _ := tracker.State(name)
+		return s.State
+	}
+	newTracker := func() *Tracker {
+		tracker := NewTracker(SetDependencies(deps))
+		for _, name := range []string{"switch", "tcp", "mysql"} {
+			observe(tracker, name, true, 0)
+		}
+		return tracker
+	}
+
+	t.Run("parent down", func(t *testing.T) {
+		tracker := newTracker()
+		transitions, cancel := tracker.Subscribe(10)
+		defer cancel()
+
+		_, changed := observe(tracker, "mysql", false, 10*time.Second)
+		assert.False(t, changed)
+		_, changed = observe(tracker, "tcp", false, 20*time.Second)
+		assert.False(t, changed)
+		assert.Equal(t, StateUp, state(tracker, "tcp"))
+
+		tr, _ := observe(tracker, "switch", false, 30*time.Second)
+		assert.Equal(t, StateDown, tr.To)
+		assert.Equal(t, StateUnreachable, state(tracker, "tcp"))
+		assert.Equal(t, StateUnreachable, state(tracker, "mysql"))
+
+		var alerts []string
+		for len(transitions) > 0 {
+			if tr := <-transitions; DefaultFilter(tr) {
+				alerts = append(alerts, tr.Name)
+			}
+		}
+		assert.Equal(t, []string{"switch"}, alerts)
+	})
+
+	t.Run("parent up", func(t *testing.T) {
+		tracker := newTracker()
+		_, changed := observe(tracker, "tcp", false, 20*time.Second)
+		assert.False(t, changed)
+
+		observe(tracker, "switch", true, 30*time.Second)
+		assert.Equal(t, StateDown, state(tracker, "tcp"))
+	})
+
+	t.Run("parent not checked", func(t *testing.T) {
+		tracker := newTracker()
+		_, changed := observe(tracker, "tcp", false, 20*time.Second)
+		assert.False(t, changed)
+
+		tr, _ := observe(tracker, "tcp", false, 90*time.Second)
+		assert.Equal(t, StateUp, tr.From)
+		assert.Equal(t, StateDown, tr.To)
+	})
+
+	t.Run("recovered while held", func(t *testing.T) {
+		tracker := newTracker()
+		observe(tracker, "tcp", false, 20*time.Second)
+		_, changed := observe(tracker, "tcp", true, 40*time.Second)
+		assert.False(t, changed)
+
+		observe(tracker, "switch", true, 50*time.Second)
+		assert.Equal(t, StateUp, state(tracker, "tcp"))
+	})
+}
+
+func TestTracker_Run(t *testing.T) {
+	s := NewScheduler()
+	require.NoError(t, s.Add("down", &mockMonitor{err: errors.New("boom")}, SetInterval(5*time.Millisecond)))
+
+	tracker := NewTracker(SetFailureThreshold(2))
+	transitions, _ := tracker.Subscribe(1)
+	reports, _ := s.Subscribe(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+	go tracker.Run(ctx, reports)
+
+	tr := <-transitions
+	assert.Equal(t, "down", tr.Name)
+	assert.Equal(t, StatePending, tr.From)
+	assert.Equal(t, StateDown, tr.To)
+	assert.Equal(t, "boom", tr.Result.Message)
+
+	states := tracker.States()
+	require.Len(t, states, 1)
+	assert.GreaterOrEqual(t, states[0].ConsecutiveFailures, 2)
+}
@@ -0,0 +1,558 @@
+// Package statuspage serves public status pages: the current state of
+// groups of monitors, their daily uptime and posted incident notes, as
+// HTML and as JSON.
+package statuspage
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+var (
+	_ http.Handler = (*Server)(nil)
+
+	//go:embed templates/*.html
+	templates embed.FS
+
+	defaultDays = 90
+)
+
+type Config struct {
+	Pages []Page `json:"pages"`
+	// Days 是状态条显示的天数, 默认 90
+	Days int `json:"days"`
+	// APIKey 用于发布和解决事件, 为空时不能通过 HTTP 发布
+	APIKey string `json:"api_key"`
+	// IncidentsFile 保存事件的 JSON 文件, 为空时只保存在内存中
+	IncidentsFile string `json:"incidents_file"`
+}
+
+// Page is a status page served at /status/<slug>.
+type Page struct {
+	Slug        string  `json:"slug"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Logo        string  `json:"logo"` // 图片地址
+	Groups      []Group `json:"groups"`
+}
+
+// Group is a service shown on a page, made of some monitors.
+type Group struct {
+	Name     string   `json:"name"`
+	Monitors []string `json:"monitors"`
+}
+
+// Severity is how an incident is highlighted.
+type Severity string
+
+var (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityDanger  Severity = "danger"
+)
+
+// Incident is a note posted on a page, e.g. about an outage.
+type Incident struct {
+	ID       string    `json:"id"`
+	Page     string    `json:"page"`
+	Title    string    `json:"title"`
+	Body     string    `json:"body"`
+	Severity Severity  `json:"severity"`
+	Created  time.Time `json:"created"`
+	Updated  time.Time `json:"updated"`
+	// Resolved is zero while the incident is open.
+	Resolved time.Time `json:"resolved,omitempty"`
+}
+
+// States gives the current state of monitors, it is implemented by *status_neko.Tracker.
+type States interface {
+	State(name string) (status_neko.MonitorState, bool)
+}
+
+// Overall summarizes the state of a page or a group.
+type Overall string
+
+var (
+	OverallOperational   Overall = "operational"
+	OverallPartialOutage Overall = "partial_outage"
+	OverallMajorOutage   Overall = "major_outage"
+	OverallMaintenance   Overall = "maintenance"
+	OverallUnknown       Overall = "unknown"
+)
+
+// Day is the uptime of a monitor or a group on one UTC day.
+type Day struct {
+	Date     string `json:"date"`
+	Count    int    `json:"count"`
+	Failures int    `json:"failures"`
+	// Uptime is in percent, it is meaningless when Count is zero.
+	Uptime float64 `json:"uptime"`
+}
+
+// MonitorStatus is a monitor as shown on a page.
+type MonitorStatus struct {
+	Name   string            `json:"name"`
+	State  status_neko.State `json:"state"`
+	Since  time.Time         `json:"since,omitempty"`
+	Uptime float64           `json:"uptime"`
+	Days   []Day             `json:"days"`
+}
+
+// GroupStatus is a group as shown on a page.
+type GroupStatus struct {
+	Name     string          `json:"name"`
+	Overall  Overall         `json:"overall"`
+	Uptime   float64         `json:"uptime"`
+	Monitors []MonitorStatus `json:"monitors"`
+}
+
+// PageStatus is the data of a page, it is served as JSON and rendered as HTML.
+type PageStatus struct {
+	Slug        string        `json:"slug"`
+	Title       string        `json:"title"`
+	Description string        `json:"description,omitempty"`
+	Logo        string        `json:"logo,omitempty"`
+	Overall     Overall       `json:"overall"`
+	Groups      []GroupStatus `json:"groups"`
+	Incidents   []Incident    `json:"incidents"`
+	Updated     time.Time     `json:"updated"`
+}
+
+// Server serves the pages:
+//
+//	GET  /                                   list of pages
+//	GET  /status/{slug}                      HTML page
+//	GET  /api/status/{slug}                  PageStatus as JSON
+//	POST /api/status/{slug}/incidents        post an incident, needs the API key
+//	POST /api/status/{slug}/incidents/{id}/resolve
+type Server struct {
+	config Config
+	store  status_neko.Store
+	states States
+	pages  map[string]Page
+	tmpl   *template.Template
+	mux    *http.ServeMux
+	now    func() time.Time
+
+	mu        sync.Mutex
+	incidents []Incident
+}
+
+func NewServer(config Config, store status_neko.Store, states States) (*Server, error) {
+	if config.Days <= 0 {
+		config.Days = defaultDays
+	}
+
+	pages := make(map[string]Page, len(config.Pages))
+	for i, page := range config.Pages {
+		if page.Slug == "" {
+			return nil, fmt.Errorf("pages[%d] has no slug", i)
+		}
+		if _, ok := pages[page.Slug]; ok {
+			return nil, fmt.Errorf("duplicate page slug %s", page.Slug)
+		}
+		if page.Title == "" {
+			page.Title = page.Slug
+		}
+		pages[page.Slug] = page
+	}
+
+	tmpl, err := template.New("").Funcs(template.FuncMap{
+		"percent": func(v float64) string {
+			return fmt.Sprintf("%.2f%%", v)
+		},
+		"datetime": func(t time.Time) string {
+			return t.Format(time.DateTime)
+		},
+		"barClass": barClass,
+	}).ParseFS(templates, "templates/*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		config: config,
+		store:  store,
+		states: states,
+		pages:  pages,
+		tmpl:   tmpl,
+		mux:    http.NewServeMux(),
+		now:    time.Now,
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	s.mux.HandleFunc("GET /{$}", s.index)
+	s.mux.HandleFunc("GET /status/{slug}", s.page)
+	s.mux.HandleFunc("GET /api/status/{slug}", s.pageJSON)
+	s.mux.HandleFunc("POST /api/status/{slug}/incidents", s.authorized(s.postIncident))
+	s.mux.HandleFunc("POST /api/status/{slug}/incidents/{id}/resolve", s.authorized(s.resolveIncident))
+	return s, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Status computes the data of the page with the given slug.
+func (s *Server) Status(ctx context.Context, slug string) (*PageStatus, bool, error) {
+	page, ok := s.pages[slug]
+	if !ok {
+		return nil, false, nil
+	}
+
+	now := s.now()
+	today := status_neko.ResolutionDay.Truncate(now)
+	from := today.AddDate(0, 0, 1-s.config.Days)
+
+	status := &PageStatus{
+		Slug:        page.Slug,
+		Title:       page.Title,
+		Description: page.Description,
+		Logo:        page.Logo,
+		Groups:      make([]GroupStatus, 0, len(page.Groups)),
+		Incidents:   s.Incidents(slug),
+		Updated:     now,
+	}
+
+	var states []status_neko.State
+	for _, group := range page.Groups {
+		gs := GroupStatus{
+			Name:     group.Name,
+			Monitors: make([]MonitorStatus, 0, len(group.Monitors)),
+		}
+		var groupStates []status_neko.State
+		var count, failures int
+		for _, name := range group.Monitors {
+			rollups, err := s.store.Rollups(ctx, status_neko.Query{Monitor: name, From: from}, status_neko.ResolutionDay)
+			if err != nil {
+				return nil, false, err
+			}
+
+			ms := MonitorStatus{
+				Name:  name,
+				State: status_neko.StatePending,
+				Days:  days(rollups, from, s.config.Days),
+			}
+			if state, ok := s.states.State(name); ok {
+				ms.State, ms.Since = state.State, state.Since
+			}
+			c, f := total(ms.Days)
+			ms.Uptime = uptime(c, f)
+			count += c
+			failures += f
+
+			groupStates = append(groupStates, ms.State)
+			gs.Monitors = append(gs.Monitors, ms)
+		}
+		gs.Overall = overall(groupStates)
+		gs.Uptime = uptime(count, failures)
+		states = append(states, groupStates...)
+		status.Groups = append(status.Groups, gs)
+	}
+	status.Overall = overall(states)
+	return status, true, nil
+}
+
+// PostIncident adds an incident to a page and returns it with its ID set.
+func (s *Server) PostIncident(incident Incident) (Incident, error) {
+	if _, ok := s.pages[incident.Page]; !ok {
+		return Incident{}, fmt.Errorf("unknown page %q", incident.Page)
+	}
+	if incident.Title == "" {
+		return Incident{}, errors.New("incident has no title")
+	}
+	switch incident.Severity {
+	case "":
+		incident.Severity = SeverityInfo
+	case SeverityInfo, SeverityWarning, SeverityDanger:
+	default:
+		return Incident{}, fmt.Errorf("unknown severity %q", incident.Severity)
+	}
+
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	incident.ID = hex.EncodeToString(b)
+	now := s.now()
+	incident.Created, incident.Updated, incident.Resolved = now, now, time.Time{}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.incidents = append(s.incidents, incident)
+	return incident, s.save()
+}
+
+// ResolveIncident marks an incident of a page as resolved.
+func (s *Server) ResolveIncident(page, id string) (Incident, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.incidents {
+		incident := &s.incidents[i]
+		if incident.ID != id || incident.Page != page {
+			continue
+		}
+		if incident.Resolved.IsZero() {
+			now := s.now()
+			incident.Resolved, incident.Updated = now, now
+		}
+		return *incident, true, s.save()
+	}
+	return Incident{}, false, nil
+}
+
+// Incidents returns the incidents of a page, open ones first, newest first.
+func (s *Server) Incidents(page string) []Incident {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	incidents := []Incident{}
+	for _, incident := range s.incidents {
+		if incident.Page == page {
+			incidents = append(incidents, incident)
+		}
+	}
+	sort.SliceStable(incidents, func(i, j int) bool {
+		oi, oj := incidents[i].Resolved.IsZero(), incidents[j].Resolved.IsZero()
+		if oi != oj {
+			return oi
+		}
+		return incidents[i].Created.After(incidents[j].Created)
+	})
+	return incidents
+}
+
+func (s *Server) index(w http.ResponseWriter, r *http.Request) {
+	pages := make([]Page, 0, len(s.pages))
+	for _, page := range s.config.Pages {
+		pages = append(pages, s.pages[page.Slug])
+	}
+	s.render(w, "index.html", pages)
+}
+
+func (s *Server) page(w http.ResponseWriter, r *http.Request) {
+	status, ok, err := s.Status(r.Context(), r.PathValue("slug"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	s.render(w, "page.html", status)
+}
+
+func (s *Server) pageJSON(w http.ResponseWriter, r *http.Request) {
+	status, ok, err := s.Status(r.Context(), r.PathValue("slug"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("page not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) postIncident(w http.ResponseWriter, r *http.Request) {
+	var incident Incident
+	if err := json.NewDecoder(r.Body).Decode(&incident); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	incident.Page = r.PathValue("slug")
+	if _, ok := s.pages[incident.Page]; !ok {
+		writeError(w, http.StatusNotFound, errors.New("page not found"))
+		return
+	}
+
+	incident, err := s.PostIncident(incident)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, incident)
+}
+
+func (s *Server) resolveIncident(w http.ResponseWriter, r *http.Request) {
+	incident, ok, err := s.ResolveIncident(r.PathValue("slug"), r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("incident not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, incident)
+}
+
+// authorized requires the API key as a bearer token or X-API-Key header.
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.config.APIKey == "" {
+			writeError(w, http.StatusForbidden, errors.New("posting incidents is disabled without an api key"))
+			return
+		}
+		key := r.Header.Get("X-API-Key")
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			key = bearer
+		}
+		if subtle.ConstantTimeCompare([]byte(key), []byte(s.config.APIKey)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("invalid api key"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) render(w http.ResponseWriter, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.tmpl.ExecuteTemplate(w, name, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// load reads the incidents file, a missing file has no incidents.
+func (s *Server) load() error {
+	if s.config.IncidentsFile == "" {
+		return nil
+	}
+	b, err := os.ReadFile(s.config.IncidentsFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &s.incidents); err != nil {
+		return fmt.Errorf("%s: %w", s.config.IncidentsFile, err)
+	}
+	return nil
+}
+
+// save writes the incidents file, the caller must hold s.mu.
+func (s *Server) save() error {
+	if s.config.IncidentsFile == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(s.incidents, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.config.IncidentsFile + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.config.IncidentsFile)
+}
+
+// days turns daily rollups into n days starting at from, days without data
+// have a zero Count.
+func days(rollups []status_neko.Rollup, from time.Time, n int) []Day {
+	byDate := make(map[string]status_neko.Rollup, len(rollups))
+	for _, r := range rollups {
+		byDate[r.Start.Format(time.DateOnly)] = r
+	}
+
+	result := make([]Day, n)
+	for i := range result {
+		date := from.AddDate(0, 0, i).Format(time.DateOnly)
+		r := byDate[date]
+		result[i] = Day{
+			Date:     date,
+			Count:    r.Count,
+			Failures: r.Failures,
+			Uptime:   uptime(r.Count, r.Failures),
+		}
+	}
+	return result
+}
+
+func total(days []Day) (count, failures int) {
+	for _, d := range days {
+		count += d.Count
+		failures += d.Failures
+	}
+	return count, failures
+}
+
+func uptime(count, failures int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return 100 * float64(count-failures) / float64(count)
+}
+
+func overall(states []status_neko.State) Overall {
+	var up, down, maintenance int
+	for _, state := range states {
+		switch state {
+		case status_neko.StateUp:
+			up++
+		case status_neko.StateDown, status_neko.StateUnreachable:
+			down++
+		case status_neko.StateMaintenance:
+			maintenance++
+		}
+	}
+	switch {
+	case len(states) == 0:
+		return OverallUnknown
+	case down == len(states):
+		return OverallMajorOutage
+	case down > 0:
+		return OverallPartialOutage
+	case maintenance > 0:
+		return OverallMaintenance
+	case up == len(states):
+		return OverallOperational
+	default:
+		return OverallUnknown
+	}
+}
+
+// barClass is the CSS class of a day in the uptime bar.
+func barClass(d Day) string {
+	switch {
+	case d.Count == 0:
+		return "none"
+	case d.Failures == 0:
+		return "up"
+	case d.Uptime >= 99:
+		return "minor"
+	case d.Uptime >= 95:
+		return "degraded"
+	default:
+		return "down"
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
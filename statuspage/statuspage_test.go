@@ -0,0 +1,193 @@
+package statuspage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/songzhibin97/status-neko/store/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2024, 10, 10, 12, 0, 0, 0, time.UTC)
+
+type states map[string]status_neko.State
+
+func (s states) State(name string) (status_neko.MonitorState, bool) {
+	state, ok := s[name]
+	return status_neko.MonitorState{Name: name, State: state}, ok
+}
+
+func newServer(t *testing.T, config Config) *Server {
+	store := memory.NewMemory(memory.Config{})
+	// api: 昨天 4 次检查失败 1 次, 今天全部成功
+	for i := 0; i < 4; i++ {
+		status := status_neko.StatusUp
+		if i == 0 {
+			status = status_neko.StatusDown
+		}
+		require.NoError(t, store.Append(context.Background(),
+			status_neko.Record{Monitor: "api", Status: status, Timestamp: now.Add(-24*time.Hour + time.Duration(i)*time.Minute)},
+			status_neko.Record{Monitor: "api", Status: status_neko.StatusUp, Timestamp: now.Add(time.Duration(-i) * time.Minute)},
+		))
+	}
+	require.NoError(t, store.Append(context.Background(),
+		status_neko.Record{Monitor: "db", Status: status_neko.StatusDown, Timestamp: now},
+	))
+
+	if config.Pages == nil {
+		config.Pages = []Page{{
+			Slug:   "main",
+			Title:  "Example",
+			Logo:   "https://example.com/logo.png",
+			Groups: []Group{{Name: "API", Monitors: []string{"api"}}, {Name: "Database", Monitors: []string{"db"}}},
+		}}
+	}
+	s, err := NewServer(config, store, states{"api": status_neko.StateUp, "db": status_neko.StateDown})
+	require.NoError(t, err)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestNewServer_Error(t *testing.T) {
+	_, err := NewServer(Config{Pages: []Page{{Title: "x"}}}, memory.NewMemory(memory.Config{}), states{})
+	assert.Error(t, err)
+	_, err = NewServer(Config{Pages: []Page{{Slug: "a"}, {Slug: "a"}}}, memory.NewMemory(memory.Config{}), states{})
+	assert.Error(t, err)
+}
+
+func TestServer_Status(t *testing.T) {
+	s := newServer(t, Config{Days: 7})
+
+	status, ok, err := s.Status(context.Background(), "main")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, OverallPartialOutage, status.Overall)
+	require.Len(t, status.Groups, 2)
+
+	api := status.Groups[0]
+	assert.Equal(t, OverallOperational, api.Overall)
+	require.Len(t, api.Monitors, 1)
+	assert.Equal(t, status_neko.StateUp, api.Monitors[0].State)
+	days := api.Monitors[0].Days
+	require.Len(t, days, 7)
+	assert.Equal(t, "2024-10-04", days[0].Date)
+	assert.Equal(t, 0, days[0].Count)
+	assert.Equal(t, Day{Date: "2024-10-09", Count: 4, Failures: 1, Uptime: 75}, days[5])
+	assert.Equal(t, Day{Date: "2024-10-10", Count: 4, Uptime: 100}, days[6])
+	assert.InDelta(t, 87.5, api.Monitors[0].Uptime, 1e-9)
+
+	assert.Equal(t, OverallMajorOutage, status.Groups[1].Overall)
+
+	_, ok, err = s.Status(context.Background(), "missing")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestServer_Incidents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "incidents.json")
+	s := newServer(t, Config{APIKey: "secret", IncidentsFile: path})
+
+	post := func(key, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/status/main/incidents", strings.NewReader(body))
+		if key != "" {
+			r.Header.Set("Authorization", "Bearer "+key)
+		}
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, post("", `{"title":"x"}`).Code)
+	assert.Equal(t, http.StatusUnauthorized, post("wrong", `{"title":"x"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post("secret", `{"body":"no title"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post("secret", `{"title":"x","severity":"bad"}`).Code)
+
+	w := post("secret", `{"title":"Database outage","body":"Investigating","severity":"danger"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var first Incident
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+	assert.NotEmpty(t, first.ID)
+	assert.Equal(t, "main", first.Page)
+	assert.Equal(t, SeverityDanger, first.Severity)
+
+	s.now = func() time.Time { return now.Add(time.Hour) }
+	second, err := s.PostIncident(Incident{Page: "main", Title: "Slow API"})
+	require.NoError(t, err)
+	assert.Equal(t, SeverityInfo, second.Severity)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/status/main/incidents/"+first.ID+"/resolve", nil)
+	r.Header.Set("X-API-Key", "secret")
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	r = httptest.NewRequest(http.MethodPost, "/api/status/main/incidents/missing/resolve", nil)
+	r.Header.Set("X-API-Key", "secret")
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 未解决的在前
+	incidents := s.Incidents("main")
+	require.Len(t, incidents, 2)
+	assert.Equal(t, second.ID, incidents[0].ID)
+	assert.Equal(t, first.ID, incidents[1].ID)
+	assert.Equal(t, now.Add(time.Hour), incidents[1].Resolved)
+
+	// 重新加载后事件仍在
+	reloaded := newServer(t, Config{IncidentsFile: path})
+	assert.Len(t, reloaded.Incidents("main"), 2)
+
+	// 没有 api key 时不能发布
+	assert.Equal(t, http.StatusForbidden, func() int {
+		w := httptest.NewRecorder()
+		reloaded.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/status/main/incidents", strings.NewReader(`{"title":"x"}`)))
+		return w.Code
+	}())
+}
+
+func TestServer_ServeHTTP(t *testing.T) {
+	s := newServer(t, Config{})
+	_, err := s.PostIncident(Incident{Page: "main", Title: "Scheduled <upgrade>"})
+	require.NoError(t, err)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `href="status/main"`)
+
+	w = get("/status/main")
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, body, "<title>Example</title>")
+	assert.Contains(t, body, `src="https://example.com/logo.png"`)
+	assert.Contains(t, body, "Partial outage")
+	assert.Contains(t, body, "Scheduled &lt;upgrade&gt;")
+	// 两个监控各 90 天
+	assert.Equal(t, 180, strings.Count(body, `" title="2024-`))
+
+	w = get("/api/status/main")
+	require.Equal(t, http.StatusOK, w.Code)
+	var status PageStatus
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, "Example", status.Title)
+	assert.Len(t, status.Groups[0].Monitors[0].Days, 90)
+	assert.Len(t, status.Incidents, 1)
+
+	assert.Equal(t, http.StatusNotFound, get("/status/missing").Code)
+	assert.Equal(t, http.StatusNotFound, get("/api/status/missing").Code)
+}
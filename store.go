@@ -0,0 +1,238 @@
+package status_neko
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+)
+
+// Record is a check result kept by a Store.
+type Record struct {
+	Monitor   string        `json:"monitor"`
+	Provider  string        `json:"provider,omitempty"`
+	Status    Status        `json:"status"`
+	Latency   time.Duration `json:"latency"`
+	Message   string        `json:"message,omitempty"`
+	Timestamp time.Time     `json:"timestamp"`
+	// Maintenance is set for checks within a maintenance window, they are
+	// left out of rollups and availability reports.
+	Maintenance bool `json:"maintenance,omitempty"`
+}
+
+// NewRecord builds the record of a report, the result details are not kept.
+func NewRecord(r Report) Record {
+	record := Record{
+		Monitor:   r.Name,
+		Timestamp: r.Start,
+	}
+	if r.Monitor != nil {
+		record.Provider = r.Monitor.Name()
+	}
+	if r.Result != nil {
+		record.Status = r.Result.Status
+		record.Latency = r.Result.Latency
+		record.Message = r.Result.Message
+		if !r.Result.Timestamp.IsZero() {
+			record.Timestamp = r.Result.Timestamp
+		}
+	}
+	return record
+}
+
+// Resolution is the bucket size of a Rollup.
+type Resolution string
+
+var (
+	ResolutionHour Resolution = "hour"
+	ResolutionDay  Resolution = "day"
+)
+
+// Truncate returns the start of the bucket containing t, buckets are aligned in UTC.
+func (r Resolution) Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	if r == ResolutionDay {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return t.Truncate(time.Hour)
+}
+
+// Rollup summarizes the records of a monitor within one bucket.
+type Rollup struct {
+	Monitor    string        `json:"monitor"`
+	Resolution Resolution    `json:"resolution"`
+	Start      time.Time     `json:"start"`
+	Count      int           `json:"count"`
+	Failures   int           `json:"failures"`
+	AvgLatency time.Duration `json:"avg_latency"`
+	P95Latency time.Duration `json:"p95_latency"`
+}
+
+// Query selects records of a monitor within [From, To), zero values match everything.
+type Query struct {
+	// Monitor 为空时匹配所有监控项
+	Monitor string
+	From    time.Time
+	To      time.Time
+	// Limit keeps only the most recent records, zero means no limit.
+	Limit int
+}
+
+// Match reports whether a record of monitor at t is selected by q.
+func (q Query) Match(monitor string, t time.Time) bool {
+	if q.Monitor != "" && q.Monitor != monitor {
+		return false
+	}
+	if !q.From.IsZero() && t.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !t.Before(q.To) {
+		return false
+	}
+	return true
+}
+
+// Retention says how long a Store keeps data, zero keeps it forever.
+// Raw records older than Raw are downsampled into hourly and daily rollups
+// a whole UTC day at a time, so they may stay up to a day longer.
+type Retention struct {
+	Raw    Duration `json:"raw"`
+	Hourly Duration `json:"hourly"`
+	Daily  Duration `json:"daily"`
+}
+
+// DefaultRetention keeps raw records for a week, hourly rollups for 90 days
+// and daily rollups forever.
+var DefaultRetention = Retention{
+	Raw:    Duration(7 * 24 * time.Hour),
+	Hourly: Duration(90 * 24 * time.Hour),
+}
+
+// Store keeps the history of check results.
+type Store interface {
+	// Append stores records, they are expected in roughly chronological order.
+	Append(ctx context.Context, records ...Record) error
+
+	// Query returns the raw records selected by q sorted by time.
+	Query(ctx context.Context, q Query) ([]Record, error)
+
+	// Latest returns the most recent record of a monitor.
+	Latest(ctx context.Context, monitor string) (Record, bool, error)
+
+	// Rollups returns the buckets starting within q sorted by monitor and
+	// time, both downsampled ones and ones computed from raw records.
+	// Buckets at the edges of q only summarize the records inside it.
+	Rollups(ctx context.Context, q Query, resolution Resolution) ([]Rollup, error)
+
+	// Compact applies the retention policy relative to now.
+	Compact(ctx context.Context, now time.Time) error
+
+	Close() error
+}
+
+// Downsample summarizes records into rollups sorted by monitor and time,
+// records within maintenance are left out.
+func Downsample(records []Record, resolution Resolution) []Rollup {
+	type key struct {
+		monitor string
+		start   time.Time
+	}
+	buckets := make(map[key][]Record)
+	for _, r := range records {
+		if r.Maintenance {
+			continue
+		}
+		k := key{monitor: r.Monitor, start: resolution.Truncate(r.Timestamp)}
+		buckets[k] = append(buckets[k], r)
+	}
+
+	rollups := make([]Rollup, 0, len(buckets))
+	for k, bucket := range buckets {
+		rollup := Rollup{
+			Monitor:    k.monitor,
+			Resolution: resolution,
+			Start:      k.start,
+			Count:      len(bucket),
+		}
+		latencies := make([]time.Duration, 0, len(bucket))
+		var total time.Duration
+		for _, r := range bucket {
+			if r.Status == StatusDown {
+				rollup.Failures++
+			}
+			total += r.Latency
+			latencies = append(latencies, r.Latency)
+		}
+		rollup.AvgLatency = total / time.Duration(len(bucket))
+		rollup.P95Latency = percentile(latencies, 0.95)
+		rollups = append(rollups, rollup)
+	}
+	SortRollups(rollups)
+	return rollups
+}
+
+// Add combines two rollups of the same bucket summarizing different records.
+// The average latency is weighted by the counts, the p95 latency is the
+// larger one as the records are no longer available.
+func (r Rollup) Add(o Rollup) Rollup {
+	if r.Count == 0 {
+		return o
+	}
+	if o.Count == 0 {
+		return r
+	}
+	count := r.Count + o.Count
+	r.AvgLatency = (r.AvgLatency*time.Duration(r.Count) + o.AvgLatency*time.Duration(o.Count)) / time.Duration(count)
+	r.P95Latency = max(r.P95Latency, o.P95Latency)
+	r.Count = count
+	r.Failures += o.Failures
+	return r
+}
+
+// MergeRollups combines the rollups of the same bucket, see Rollup.Add, and
+// sorts them by monitor and time.
+func MergeRollups(rollups []Rollup) []Rollup {
+	type key struct {
+		monitor    string
+		resolution Resolution
+		start      int64
+	}
+	index := make(map[key]int, len(rollups))
+	merged := rollups[:0:0]
+	for _, r := range rollups {
+		k := key{monitor: r.Monitor, resolution: r.Resolution, start: r.Start.UnixNano()}
+		if i, ok := index[k]; ok {
+			merged[i] = merged[i].Add(r)
+			continue
+		}
+		index[k] = len(merged)
+		merged = append(merged, r)
+	}
+	SortRollups(merged)
+	return merged
+}
+
+// SortRollups sorts rollups by monitor and time.
+func SortRollups(rollups []Rollup) {
+	sort.Slice(rollups, func(i, j int) bool {
+		if rollups[i].Monitor != rollups[j].Monitor {
+			return rollups[i].Monitor < rollups[j].Monitor
+		}
+		return rollups[i].Start.Before(rollups[j].Start)
+	})
+}
+
+// percentile returns the nearest-rank percentile p of latencies, it sorts them.
+func percentile(latencies []time.Duration, p float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+	rank := int(math.Ceil(p*float64(len(latencies)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return latencies[rank]
+}
@@ -0,0 +1,516 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+var (
+	_             status_neko.Store = (*File)(nil)
+	storeFileName                   = "file"
+
+	dayLayout = "2006-01-02"
+)
+
+func init() {
+	status_neko.RegisterStore(storeFileName, status_neko.NewStoreFactory(func(c Config) (status_neko.Store, error) {
+		return NewFile(c)
+	}))
+}
+
+type Config struct {
+	// Path 数据目录, 不存在时自动创建
+	Path string `json:"path"`
+	// Retention 为零值时使用 status_neko.DefaultRetention
+	Retention *status_neko.Retention `json:"retention"`
+}
+
+// File keeps records as JSON lines in a directory:
+//
+//	records/<monitor>/2006-01-02.jsonl  raw records of one UTC day
+//	rollups/<monitor>/hour.jsonl        downsampled buckets
+//	rollups/<monitor>/day.jsonl
+//
+// Retention removes whole day files, so a query only reads the days it covers.
+type File struct {
+	config    Config
+	retention status_neko.Retention
+
+	mu     sync.Mutex
+	files  map[string]*dayFile
+	latest map[string]status_neko.Record
+}
+
+// dayFile is the open append handle of the current day of a monitor.
+type dayFile struct {
+	day  string
+	file *os.File
+}
+
+func NewFile(config Config) (*File, error) {
+	if config.Path == "" {
+		return nil, errors.New("file store path is required")
+	}
+	for _, dir := range []string{"records", "rollups"} {
+		if err := os.MkdirAll(filepath.Join(config.Path, dir), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	retention := status_neko.DefaultRetention
+	if config.Retention != nil {
+		retention = *config.Retention
+	}
+
+	return &File{
+		config:    config,
+		retention: retention,
+		files:     make(map[string]*dayFile),
+		latest:    make(map[string]status_neko.Record),
+	}, nil
+}
+
+func (f *File) Append(ctx context.Context, records ...status_neko.Record) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, r := range records {
+		if r.Monitor == "" {
+			return errors.New("record has no monitor")
+		}
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+
+		file, err := f.open(r.Monitor, r.Timestamp.UTC().Format(dayLayout))
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write(append(b, '\n')); err != nil {
+			return err
+		}
+
+		if latest, ok := f.latest[r.Monitor]; !ok || !r.Timestamp.Before(latest.Timestamp) {
+			f.latest[r.Monitor] = r
+		}
+	}
+	return nil
+}
+
+func (f *File) Query(ctx context.Context, q status_neko.Query) ([]status_neko.Record, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.query(q)
+}
+
+func (f *File) Latest(ctx context.Context, monitor string) (status_neko.Record, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r, ok := f.latest[monitor]; ok {
+		return r, true, nil
+	}
+
+	days, err := f.days(monitor)
+	if err != nil || len(days) == 0 {
+		return status_neko.Record{}, false, err
+	}
+	// 最新的一天可能只有损坏的行, 依次向前查找
+	for i := len(days) - 1; i >= 0; i-- {
+		records, err := readLines[status_neko.Record](f.recordPath(monitor, days[i]))
+		if err != nil {
+			return status_neko.Record{}, false, err
+		}
+		if len(records) > 0 {
+			latest := records[len(records)-1]
+			f.latest[monitor] = latest
+			return latest, true, nil
+		}
+	}
+	return status_neko.Record{}, false, nil
+}
+
+func (f *File) Rollups(ctx context.Context, q status_neko.Query, resolution status_neko.Resolution) ([]status_neko.Rollup, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	records, err := f.query(status_neko.Query{Monitor: q.Monitor, From: q.From, To: q.To})
+	if err != nil {
+		return nil, err
+	}
+	monitors, err := f.monitors("rollups", q.Monitor)
+	if err != nil {
+		return nil, err
+	}
+
+	var rollups []status_neko.Rollup
+	for _, monitor := range monitors {
+		stored, err := readLines[status_neko.Rollup](f.rollupPath(monitor, resolution))
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range stored {
+			if q.Match(r.Monitor, r.Start) {
+				rollups = append(rollups, r)
+			}
+		}
+	}
+
+	rollups = append(rollups, status_neko.Downsample(records, resolution)...)
+	status_neko.SortRollups(rollups)
+	return rollups, nil
+}
+
+func (f *File) Compact(ctx context.Context, now time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.retention.Raw > 0 {
+		cutoff := status_neko.ResolutionDay.Truncate(now.Add(-time.Duration(f.retention.Raw)))
+		monitors, err := f.monitors("records", "")
+		if err != nil {
+			return err
+		}
+		for _, monitor := range monitors {
+			if err := f.downsample(monitor, cutoff.Format(dayLayout)); err != nil {
+				return err
+			}
+		}
+	}
+
+	monitors, err := f.monitors("rollups", "")
+	if err != nil {
+		return err
+	}
+	for resolution, keep := range map[status_neko.Resolution]status_neko.Duration{
+		status_neko.ResolutionHour: f.retention.Hourly,
+		status_neko.ResolutionDay:  f.retention.Daily,
+	} {
+		if keep <= 0 {
+			continue
+		}
+		cutoff := now.Add(-time.Duration(keep))
+		for _, monitor := range monitors {
+			if err := f.expire(f.rollupPath(monitor, resolution), cutoff); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var errs []error
+	for monitor, df := range f.files {
+		errs = append(errs, df.file.Close())
+		delete(f.files, monitor)
+	}
+	return errors.Join(errs...)
+}
+
+// downsample turns the day files of monitor before cutoff into rollups,
+// the caller must hold f.mu.
+func (f *File) downsample(monitor, cutoff string) error {
+	days, err := f.days(monitor)
+	if err != nil {
+		return err
+	}
+
+	for _, day := range days {
+		if day >= cutoff {
+			break
+		}
+		path := f.recordPath(monitor, day)
+		records, err := readLines[status_neko.Record](path)
+		if err != nil {
+			return err
+		}
+		start, err := time.Parse(dayLayout, day)
+		if err != nil {
+			continue
+		}
+		// 替换而不是追加这一天的汇总, 删除原始记录前崩溃时重复降采样不会产生重复的汇总
+		for _, resolution := range []status_neko.Resolution{status_neko.ResolutionHour, status_neko.ResolutionDay} {
+			if err := f.replace(f.rollupPath(monitor, resolution), start, start.Add(24*time.Hour), status_neko.Downsample(records, resolution)); err != nil {
+				return err
+			}
+		}
+
+		if df, ok := f.files[monitor]; ok && df.day == day {
+			df.file.Close()
+			delete(f.files, monitor)
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+
+	if len(days) > 0 && days[len(days)-1] < cutoff {
+		delete(f.latest, monitor)
+		_ = os.Remove(filepath.Join(f.config.Path, "records", escape(monitor)))
+	}
+	return nil
+}
+
+// replace rewrites a rollup file with rollups instead of the buckets
+// within [from, to).
+func (f *File) replace(path string, from, to time.Time, rollups []status_neko.Rollup) error {
+	stored, err := readLines[status_neko.Rollup](path)
+	if err != nil {
+		return err
+	}
+
+	kept := stored[:0]
+	for _, r := range stored {
+		if r.Start.Before(from) || !r.Start.Before(to) {
+			kept = append(kept, r)
+		}
+	}
+	if len(kept) == len(stored) && len(rollups) > 0 {
+		// 通常的情况, 这一天还没有汇总
+		return appendLines(path, rollups)
+	}
+	kept = append(kept, rollups...)
+	status_neko.SortRollups(kept)
+	return rewrite(path, kept)
+}
+
+// expire rewrites a rollup file without the buckets before cutoff.
+func (f *File) expire(path string, cutoff time.Time) error {
+	rollups, err := readLines[status_neko.Rollup](path)
+	if err != nil || len(rollups) == 0 {
+		return err
+	}
+
+	kept := rollups[:0]
+	for _, r := range rollups {
+		if !r.Start.Before(cutoff) {
+			kept = append(kept, r)
+		}
+	}
+	if len(kept) == len(rollups) {
+		return nil
+	}
+	return rewrite(path, kept)
+}
+
+// query reads the day files covered by q, the caller must hold f.mu.
+func (f *File) query(q status_neko.Query) ([]status_neko.Record, error) {
+	monitors, err := f.monitors("records", q.Monitor)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []status_neko.Record
+	for _, monitor := range monitors {
+		days, err := f.days(monitor)
+		if err != nil {
+			return nil, err
+		}
+		for _, day := range days {
+			start, err := time.Parse(dayLayout, day)
+			if err != nil {
+				continue
+			}
+			if !q.To.IsZero() && !start.Before(q.To) {
+				continue
+			}
+			if !q.From.IsZero() && !start.Add(24*time.Hour).After(q.From) {
+				continue
+			}
+
+			lines, err := readLines[status_neko.Record](f.recordPath(monitor, day))
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range lines {
+				if q.Match(r.Monitor, r.Timestamp) {
+					records = append(records, r)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+	if q.Limit > 0 && len(records) > q.Limit {
+		records = records[len(records)-q.Limit:]
+	}
+	return records, nil
+}
+
+// open returns the append handle of a day file, the caller must hold f.mu.
+func (f *File) open(monitor, day string) (*os.File, error) {
+	if df, ok := f.files[monitor]; ok {
+		if df.day == day {
+			return df.file, nil
+		}
+		df.file.Close()
+		delete(f.files, monitor)
+	}
+
+	path := f.recordPath(monitor, day)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	f.files[monitor] = &dayFile{day: day, file: file}
+	return file, nil
+}
+
+// monitors lists the monitors that have a directory under dir, or only
+// the given one.
+func (f *File) monitors(dir, only string) ([]string, error) {
+	if only != "" {
+		return []string{only}, nil
+	}
+
+	entries, err := os.ReadDir(filepath.Join(f.config.Path, dir))
+	if err != nil {
+		return nil, err
+	}
+	var monitors []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		name, err := url.PathUnescape(e.Name())
+		if err != nil {
+			continue
+		}
+		monitors = append(monitors, name)
+	}
+	return monitors, nil
+}
+
+// days lists the sorted days that have a record file for monitor.
+func (f *File) days(monitor string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(f.config.Path, "records", escape(monitor)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var days []string
+	for _, e := range entries {
+		if day, ok := strings.CutSuffix(e.Name(), ".jsonl"); ok && !e.IsDir() {
+			days = append(days, day)
+		}
+	}
+	sort.Strings(days)
+	return days, nil
+}
+
+func (f *File) recordPath(monitor, day string) string {
+	return filepath.Join(f.config.Path, "records", escape(monitor), day+".jsonl")
+}
+
+func (f *File) rollupPath(monitor string, resolution status_neko.Resolution) string {
+	return filepath.Join(f.config.Path, "rollups", escape(monitor), string(resolution)+".jsonl")
+}
+
+// escape turns a monitor name into a safe directory name.
+func escape(monitor string) string {
+	s := url.PathEscape(monitor)
+	if strings.HasPrefix(s, ".") {
+		s = "%2E" + s[1:]
+	}
+	return s
+}
+
+// readLines decodes a JSON lines file, a missing file is empty and lines
+// that fail to decode, e.g. torn by a crash, are skipped.
+func readLines[T any](path string) ([]T, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var values []T
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var v T
+		if err := json.Unmarshal(scanner.Bytes(), &v); err != nil {
+			continue
+		}
+		values = append(values, v)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// rewrite replaces a JSON lines file atomically.
+func rewrite[T any](path string, values []T) error {
+	tmp := path + ".tmp"
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := appendLines(tmp, values); err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return os.Rename(tmp, path)
+}
+
+func appendLines[T any](path string, values []T) error {
+	if len(values) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(file)
+	for _, v := range values {
+		b, err := json.Marshal(v)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		w.Write(b)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
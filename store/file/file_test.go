@@ -0,0 +1,178 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var base = time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+
+func record(monitor string, at time.Duration, up bool, latency time.Duration) status_neko.Record {
+	status := status_neko.StatusUp
+	if !up {
+		status = status_neko.StatusDown
+	}
+	return status_neko.Record{Monitor: monitor, Status: status, Latency: latency, Timestamp: base.Add(at)}
+}
+
+func TestFile_Query(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	f, err := NewFile(Config{Path: dir})
+	require.NoError(t, err)
+
+	require.NoError(t, f.Append(ctx,
+		record("a/b", 0, true, time.Millisecond),
+		record(".hidden", time.Minute, false, time.Millisecond),
+		record("a/b", 25*time.Hour, false, time.Millisecond),
+		record("a/b", 49*time.Hour, true, time.Millisecond),
+	))
+	assert.Error(t, f.Append(ctx, status_neko.Record{}))
+
+	// 每个监控项每天一个文件
+	assert.FileExists(t, filepath.Join(dir, "records", "a%2Fb", "2024-10-02.jsonl"))
+	assert.FileExists(t, filepath.Join(dir, "records", "%2Ehidden", "2024-10-01.jsonl"))
+
+	records, err := f.Query(ctx, status_neko.Query{Monitor: "a/b"})
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	records, err = f.Query(ctx, status_neko.Query{From: base.Add(time.Minute), To: base.Add(26 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, ".hidden", records[0].Monitor)
+	assert.Equal(t, base.Add(25*time.Hour), records[1].Timestamp)
+
+	records, err = f.Query(ctx, status_neko.Query{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, base.Add(49*time.Hour), records[0].Timestamp)
+	require.NoError(t, f.Close())
+
+	// 重新打开后数据仍在, 损坏的行被跳过
+	path := filepath.Join(dir, "records", "a%2Fb", "2024-10-03.jsonl")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"monitor": "a/b", "sta`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	f, err = NewFile(Config{Path: dir})
+	require.NoError(t, err)
+	defer f.Close()
+
+	latest, ok, err := f.Latest(ctx, "a/b")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, base.Add(49*time.Hour), latest.Timestamp)
+	assert.Equal(t, status_neko.StatusUp, latest.Status)
+
+	_, ok, err = f.Latest(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestFile_Compact(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	f, err := NewFile(Config{Path: dir, Retention: &status_neko.Retention{
+		Raw:    status_neko.Duration(24 * time.Hour),
+		Hourly: status_neko.Duration(72 * time.Hour),
+	}})
+	require.NoError(t, err)
+	defer f.Close()
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, f.Append(ctx, record("a", time.Duration(i)*time.Minute, i%10 != 0, time.Duration(i+1)*time.Millisecond)))
+	}
+	require.NoError(t, f.Append(ctx, record("a", 25*time.Hour, true, time.Millisecond)))
+
+	// 第一天的原始记录被降采样, 文件被删除
+	require.NoError(t, f.Compact(ctx, base.Add(48*time.Hour)))
+	assert.NoFileExists(t, filepath.Join(dir, "records", "a", "2024-10-01.jsonl"))
+	records, err := f.Query(ctx, status_neko.Query{Monitor: "a"})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	hourly, err := f.Rollups(ctx, status_neko.Query{Monitor: "a"}, status_neko.ResolutionHour)
+	require.NoError(t, err)
+	require.Len(t, hourly, 2)
+	assert.Equal(t, status_neko.Rollup{
+		Monitor:    "a",
+		Resolution: status_neko.ResolutionHour,
+		Start:      base,
+		Count:      20,
+		Failures:   2,
+		AvgLatency: 10500 * time.Microsecond,
+		P95Latency: 19 * time.Millisecond,
+	}, hourly[0])
+	assert.Equal(t, base.Add(25*time.Hour), hourly[1].Start)
+
+	// 仍在写入的当天文件也可以被降采样
+	require.NoError(t, f.Compact(ctx, base.Add(80*time.Hour)))
+	require.NoError(t, f.Append(ctx, record("a", 80*time.Hour, true, time.Millisecond)))
+
+	hourly, err = f.Rollups(ctx, status_neko.Query{Monitor: "a", To: base.Add(48 * time.Hour)}, status_neko.ResolutionHour)
+	require.NoError(t, err)
+	require.Len(t, hourly, 1)
+	assert.Equal(t, base.Add(25*time.Hour), hourly[0].Start)
+
+	daily, err := f.Rollups(ctx, status_neko.Query{}, status_neko.ResolutionDay)
+	require.NoError(t, err)
+	require.Len(t, daily, 3)
+	assert.Equal(t, []int{20, 1, 1}, []int{daily[0].Count, daily[1].Count, daily[2].Count})
+
+	latest, ok, err := f.Latest(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, base.Add(80*time.Hour), latest.Timestamp)
+}
+
+func TestFile_CompactRepeated(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	f, err := NewFile(Config{Path: dir, Retention: &status_neko.Retention{Raw: status_neko.Duration(24 * time.Hour)}})
+	require.NoError(t, err)
+	defer f.Close()
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, f.Append(ctx, record("a", time.Duration(i)*time.Hour, true, time.Millisecond)))
+	}
+
+	// 模拟上次压缩写入汇总后, 删除原始记录前崩溃
+	records, err := f.Query(ctx, status_neko.Query{Monitor: "a"})
+	require.NoError(t, err)
+	for _, resolution := range []status_neko.Resolution{status_neko.ResolutionHour, status_neko.ResolutionDay} {
+		require.NoError(t, appendLines(f.rollupPath("a", resolution), status_neko.Downsample(records, resolution)))
+	}
+
+	require.NoError(t, f.Compact(ctx, base.Add(48*time.Hour)))
+	hourly, err := f.Rollups(ctx, status_neko.Query{Monitor: "a"}, status_neko.ResolutionHour)
+	require.NoError(t, err)
+	require.Len(t, hourly, 10)
+	for _, r := range hourly {
+		assert.Equal(t, 1, r.Count)
+	}
+	daily, err := f.Rollups(ctx, status_neko.Query{Monitor: "a"}, status_neko.ResolutionDay)
+	require.NoError(t, err)
+	require.Len(t, daily, 1)
+	assert.Equal(t, 10, daily[0].Count)
+}
+
+func TestBuildStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := status_neko.BuildStore([]byte(`{"type": "file", "path": "` + dir + `"}`))
+	require.NoError(t, err)
+	assert.Equal(t, status_neko.DefaultRetention, s.(*File).retention)
+	require.NoError(t, s.Close())
+
+	_, err = status_neko.BuildStore([]byte(`{"type": "file"}`))
+	assert.EqualError(t, err, "invalid file config: file store path is required")
+}
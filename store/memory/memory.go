@@ -0,0 +1,242 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+)
+
+var (
+	_               status_neko.Store = (*Memory)(nil)
+	storeMemoryName                   = "memory"
+
+	defaultCapacity = 10000
+)
+
+func init() {
+	status_neko.RegisterStore(storeMemoryName, status_neko.NewStoreFactory(func(c Config) (status_neko.Store, error) {
+		return NewMemory(c), nil
+	}))
+}
+
+type Config struct {
+	// Capacity 每个监控项最多保留的原始记录数, 默认 10000, 超出后最旧的记录被降采样为汇总
+	Capacity int `json:"capacity"`
+	// Retention 为零值时使用 status_neko.DefaultRetention
+	Retention *status_neko.Retention `json:"retention"`
+}
+
+// Memory keeps the recent records of every monitor in a ring buffer, the
+// records leaving it are downsampled into rollups. Nothing survives a restart.
+type Memory struct {
+	config    Config
+	retention status_neko.Retention
+
+	mu      sync.RWMutex
+	records map[string]*ring
+	rollups map[status_neko.Resolution]map[bucket]status_neko.Rollup
+}
+
+// bucket identifies a rollup of a monitor.
+type bucket struct {
+	monitor string
+	start   int64
+}
+
+func NewMemory(config Config) *Memory {
+	if config.Capacity <= 0 {
+		config.Capacity = defaultCapacity
+	}
+	retention := status_neko.DefaultRetention
+	if config.Retention != nil {
+		retention = *config.Retention
+	}
+
+	return &Memory{
+		config:    config,
+		retention: retention,
+		records:   make(map[string]*ring),
+		rollups: map[status_neko.Resolution]map[bucket]status_neko.Rollup{
+			status_neko.ResolutionHour: {},
+			status_neko.ResolutionDay:  {},
+		},
+	}
+}
+
+func (m *Memory) Append(ctx context.Context, records ...status_neko.Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var evicted []status_neko.Record
+	for _, r := range records {
+		rg, ok := m.records[r.Monitor]
+		if !ok {
+			rg = &ring{buf: make([]status_neko.Record, 0, min(m.config.Capacity, 64)), capacity: m.config.Capacity}
+			m.records[r.Monitor] = rg
+		}
+		if old, ok := rg.push(r); ok {
+			evicted = append(evicted, old)
+		}
+	}
+	m.rollup(evicted)
+	return nil
+}
+
+func (m *Memory) Query(ctx context.Context, q status_neko.Query) ([]status_neko.Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var records []status_neko.Record
+	for name, rg := range m.records {
+		if q.Monitor != "" && q.Monitor != name {
+			continue
+		}
+		rg.each(func(r status_neko.Record) {
+			if q.Match(r.Monitor, r.Timestamp) {
+				records = append(records, r)
+			}
+		})
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+	if q.Limit > 0 && len(records) > q.Limit {
+		records = records[len(records)-q.Limit:]
+	}
+	return records, nil
+}
+
+func (m *Memory) Latest(ctx context.Context, monitor string) (status_neko.Record, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rg, ok := m.records[monitor]
+	if !ok || rg.len() == 0 {
+		return status_neko.Record{}, false, nil
+	}
+	return rg.at(rg.len() - 1), true, nil
+}
+
+func (m *Memory) Rollups(ctx context.Context, q status_neko.Query, resolution status_neko.Resolution) ([]status_neko.Rollup, error) {
+	records, err := m.Query(ctx, status_neko.Query{Monitor: q.Monitor, From: q.From, To: q.To})
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	var rollups []status_neko.Rollup
+	for _, r := range m.rollups[resolution] {
+		if q.Match(r.Monitor, r.Start) {
+			rollups = append(rollups, r)
+		}
+	}
+	m.mu.RUnlock()
+
+	// 环形缓冲区中最旧的记录与已降采样的记录可能属于同一个时间段
+	return status_neko.MergeRollups(append(rollups, status_neko.Downsample(records, resolution)...)), nil
+}
+
+func (m *Memory) Compact(ctx context.Context, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.retention.Raw > 0 {
+		cutoff := status_neko.ResolutionDay.Truncate(now.Add(-time.Duration(m.retention.Raw)))
+		var expired []status_neko.Record
+		for name, rg := range m.records {
+			expired = append(expired, rg.popBefore(cutoff)...)
+			if rg.len() == 0 {
+				delete(m.records, name)
+			}
+		}
+		m.rollup(expired)
+	}
+
+	for resolution, keep := range map[status_neko.Resolution]status_neko.Duration{
+		status_neko.ResolutionHour: m.retention.Hourly,
+		status_neko.ResolutionDay:  m.retention.Daily,
+	} {
+		if keep <= 0 {
+			continue
+		}
+		cutoff := now.Add(-time.Duration(keep))
+		for k, r := range m.rollups[resolution] {
+			if r.Start.Before(cutoff) {
+				delete(m.rollups[resolution], k)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+// rollup adds records leaving the ring buffer to the rollups, the caller
+// must hold m.mu.
+func (m *Memory) rollup(records []status_neko.Record) {
+	if len(records) == 0 {
+		return
+	}
+	for resolution, rollups := range m.rollups {
+		for _, r := range status_neko.Downsample(records, resolution) {
+			k := bucket{monitor: r.Monitor, start: r.Start.UnixNano()}
+			rollups[k] = rollups[k].Add(r)
+		}
+	}
+}
+
+// ring is a fixed size FIFO of records, the oldest record is overwritten when full.
+type ring struct {
+	buf      []status_neko.Record
+	start    int
+	capacity int
+}
+
+func (r *ring) len() int {
+	return len(r.buf)
+}
+
+func (r *ring) at(i int) status_neko.Record {
+	return r.buf[(r.start+i)%len(r.buf)]
+}
+
+// push appends record and returns the record it overwrote, if any.
+func (r *ring) push(record status_neko.Record) (status_neko.Record, bool) {
+	if len(r.buf) < r.capacity {
+		r.buf = append(r.buf, record)
+		return status_neko.Record{}, false
+	}
+	evicted := r.buf[r.start]
+	r.buf[r.start] = record
+	r.start = (r.start + 1) % len(r.buf)
+	return evicted, true
+}
+
+func (r *ring) each(fn func(status_neko.Record)) {
+	for i := 0; i < r.len(); i++ {
+		fn(r.at(i))
+	}
+}
+
+// popBefore removes and returns the records older than cutoff.
+func (r *ring) popBefore(cutoff time.Time) []status_neko.Record {
+	var expired, kept []status_neko.Record
+	r.each(func(record status_neko.Record) {
+		if record.Timestamp.Before(cutoff) {
+			expired = append(expired, record)
+		} else {
+			kept = append(kept, record)
+		}
+	})
+	if len(expired) > 0 {
+		r.buf = append(make([]status_neko.Record, 0, max(len(kept), min(r.capacity, 64))), kept...)
+		r.start = 0
+	}
+	return expired
+}
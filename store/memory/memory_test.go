@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	status_neko "github.com/songzhibin97/status-neko"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var base = time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+
+func record(monitor string, at time.Duration, up bool, latency time.Duration) status_neko.Record {
+	status := status_neko.StatusUp
+	if !up {
+		status = status_neko.StatusDown
+	}
+	return status_neko.Record{Monitor: monitor, Status: status, Latency: latency, Timestamp: base.Add(at)}
+}
+
+func TestMemory_Query(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(Config{Capacity: 3})
+
+	require.NoError(t, m.Append(ctx,
+		record("a", 0, true, time.Millisecond),
+		record("b", time.Minute, false, time.Millisecond),
+		record("a", 2*time.Minute, true, time.Millisecond),
+		record("a", 3*time.Minute, false, time.Millisecond),
+	))
+	// 超出容量后覆盖最旧的记录
+	require.NoError(t, m.Append(ctx, record("a", 4*time.Minute, true, time.Millisecond)))
+
+	records, err := m.Query(ctx, status_neko.Query{Monitor: "a"})
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, base.Add(2*time.Minute), records[0].Timestamp)
+	assert.Equal(t, base.Add(4*time.Minute), records[2].Timestamp)
+
+	records, err = m.Query(ctx, status_neko.Query{From: base.Add(time.Minute), To: base.Add(3 * time.Minute)})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "b", records[0].Monitor)
+	assert.Equal(t, "a", records[1].Monitor)
+
+	records, err = m.Query(ctx, status_neko.Query{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, base.Add(3*time.Minute), records[0].Timestamp)
+
+	latest, ok, err := m.Latest(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, base.Add(4*time.Minute), latest.Timestamp)
+
+	_, ok, err = m.Latest(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestMemory_Compact(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(Config{Retention: &status_neko.Retention{
+		Raw:    status_neko.Duration(24 * time.Hour),
+		Hourly: status_neko.Duration(72 * time.Hour),
+	}})
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, m.Append(ctx, record("a", time.Duration(i)*time.Minute, i%10 != 0, time.Duration(i+1)*time.Millisecond)))
+	}
+	require.NoError(t, m.Append(ctx, record("a", 25*time.Hour, true, time.Millisecond)))
+
+	// 第一天的原始记录被降采样
+	require.NoError(t, m.Compact(ctx, base.Add(48*time.Hour)))
+	records, err := m.Query(ctx, status_neko.Query{Monitor: "a"})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	hourly, err := m.Rollups(ctx, status_neko.Query{Monitor: "a"}, status_neko.ResolutionHour)
+	require.NoError(t, err)
+	require.Len(t, hourly, 2)
+	assert.Equal(t, status_neko.Rollup{
+		Monitor:    "a",
+		Resolution: status_neko.ResolutionHour,
+		Start:      base,
+		Count:      20,
+		Failures:   2,
+		AvgLatency: 10500 * time.Microsecond,
+		P95Latency: 19 * time.Millisecond,
+	}, hourly[0])
+	// 仍在原始记录中的部分实时计算
+	assert.Equal(t, base.Add(25*time.Hour), hourly[1].Start)
+
+	daily, err := m.Rollups(ctx, status_neko.Query{Monitor: "a", To: base.Add(24 * time.Hour)}, status_neko.ResolutionDay)
+	require.NoError(t, err)
+	require.Len(t, daily, 1)
+	assert.Equal(t, 20, daily[0].Count)
+
+	// 小时汇总过期, 日汇总永久保留
+	require.NoError(t, m.Compact(ctx, base.Add(80*time.Hour)))
+	hourly, err = m.Rollups(ctx, status_neko.Query{Monitor: "a"}, status_neko.ResolutionHour)
+	require.NoError(t, err)
+	require.Len(t, hourly, 1)
+	assert.Equal(t, base.Add(25*time.Hour), hourly[0].Start)
+
+	daily, err = m.Rollups(ctx, status_neko.Query{Monitor: "a"}, status_neko.ResolutionDay)
+	require.NoError(t, err)
+	assert.Len(t, daily, 2)
+}
+
+// TestMemory_Evict: records overwritten in the ring buffer are kept in the rollups.
+func TestMemory_Evict(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(Config{Capacity: 10})
+
+	// 两个小时内每 5 分钟一条记录, 共 24 条, 每 4 条失败 1 条
+	for i := 0; i < 24; i++ {
+		require.NoError(t, m.Append(ctx, record("a", time.Duration(i)*5*time.Minute, i%4 != 0, time.Duration(i+1)*time.Millisecond)))
+	}
+	records, err := m.Query(ctx, status_neko.Query{Monitor: "a"})
+	require.NoError(t, err)
+	require.Len(t, records, 10)
+
+	hourly, err := m.Rollups(ctx, status_neko.Query{Monitor: "a"}, status_neko.ResolutionHour)
+	require.NoError(t, err)
+	require.Len(t, hourly, 2)
+	assert.Equal(t, status_neko.Rollup{
+		Monitor:    "a",
+		Resolution: status_neko.ResolutionHour,
+		Start:      base,
+		Count:      12,
+		Failures:   3,
+		AvgLatency: 6500 * time.Microsecond,
+		P95Latency: 12 * time.Millisecond,
+	}, hourly[0])
+	// 第二个小时一部分已被覆盖, 一部分仍在缓冲区中
+	assert.Equal(t, 12, hourly[1].Count)
+	assert.Equal(t, 3, hourly[1].Failures)
+	assert.Equal(t, 18500*time.Microsecond, hourly[1].AvgLatency)
+
+	daily, err := m.Rollups(ctx, status_neko.Query{Monitor: "a"}, status_neko.ResolutionDay)
+	require.NoError(t, err)
+	require.Len(t, daily, 1)
+	assert.Equal(t, 24, daily[0].Count)
+	assert.Equal(t, 6, daily[0].Failures)
+}
+
+func TestBuildStore(t *testing.T) {
+	s, err := status_neko.BuildStore([]byte(`{"type": "memory", "capacity": 5, "retention": {"raw": "1h"}}`))
+	require.NoError(t, err)
+	m := s.(*Memory)
+	assert.Equal(t, 5, m.config.Capacity)
+	assert.Equal(t, status_neko.Retention{Raw: status_neko.Duration(time.Hour)}, m.retention)
+	require.NoError(t, s.Close())
+}
@@ -0,0 +1,114 @@
+package status_neko
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRecord(t *testing.T) {
+	start := time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC)
+	r := NewRecord(Report{
+		Name:    "hello",
+		Monitor: echoMonitor{},
+		Result:  Complete(nil, errors.New("boom"), start),
+		Start:   start,
+	})
+	assert.Equal(t, Record{
+		Monitor:   "hello",
+		Provider:  "echo",
+		Status:    StatusDown,
+		Message:   "boom",
+		Latency:   r.Latency,
+		Timestamp: r.Timestamp,
+	}, r)
+	assert.False(t, r.Timestamp.IsZero())
+}
+
+func TestResolution_Truncate(t *testing.T) {
+	at := time.Date(2024, 10, 1, 8, 30, 15, 0, time.FixedZone("CST", 8*3600))
+	assert.Equal(t, time.Date(2024, 10, 1, 0, 30, 0, 0, time.UTC).Truncate(time.Hour), ResolutionHour.Truncate(at))
+	assert.Equal(t, time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC), ResolutionDay.Truncate(at))
+}
+
+func TestQuery_Match(t *testing.T) {
+	at := time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC)
+	assert.True(t, Query{}.Match("a", at))
+	assert.False(t, Query{Monitor: "b"}.Match("a", at))
+	assert.True(t, Query{From: at, To: at.Add(time.Second)}.Match("a", at))
+	assert.False(t, Query{To: at}.Match("a", at))
+	assert.False(t, Query{From: at.Add(time.Second)}.Match("a", at))
+}
+
+func TestDownsample(t *testing.T) {
+	base := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	var records []Record
+	for i := 0; i < 100; i++ {
+		status := StatusUp
+		if i%4 == 0 {
+			status = StatusDown
+		}
+		records = append(records, Record{
+			Monitor:   "a",
+			Status:    status,
+			Latency:   time.Duration(i+1) * time.Millisecond,
+			Timestamp: base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	records = append(records, Record{Monitor: "0", Status: StatusUp, Timestamp: base})
+
+	hourly := Downsample(records, ResolutionHour)
+	require.Len(t, hourly, 3)
+	assert.Equal(t, "0", hourly[0].Monitor)
+	assert.Equal(t, Rollup{
+		Monitor:    "a",
+		Resolution: ResolutionHour,
+		Start:      base,
+		Count:      60,
+		Failures:   15,
+		AvgLatency: 30500 * time.Microsecond,
+		P95Latency: 57 * time.Millisecond,
+	}, hourly[1])
+	assert.Equal(t, base.Add(time.Hour), hourly[2].Start)
+	assert.Equal(t, 40, hourly[2].Count)
+
+	daily := Downsample(records, ResolutionDay)
+	require.Len(t, daily, 2)
+	assert.Equal(t, 100, daily[1].Count)
+	assert.Equal(t, 25, daily[1].Failures)
+	assert.Equal(t, 95*time.Millisecond, daily[1].P95Latency)
+
+	assert.Empty(t, Downsample(nil, ResolutionDay))
+
+	// 维护中的检测不计入
+	maintenance := []Record{
+		{Monitor: "a", Status: StatusDown, Timestamp: base, Maintenance: true},
+		{Monitor: "a", Status: StatusUp, Timestamp: base.Add(time.Minute)},
+		{Monitor: "b", Status: StatusDown, Timestamp: base, Maintenance: true},
+	}
+	assert.Equal(t, []Rollup{{Monitor: "a", Resolution: ResolutionHour, Start: base, Count: 1}}, Downsample(maintenance, ResolutionHour))
+}
+
+func TestMergeRollups(t *testing.T) {
+	base := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	rollups := MergeRollups([]Rollup{
+		{Monitor: "a", Resolution: ResolutionDay, Start: base, Count: 3, Failures: 1, AvgLatency: 10 * time.Millisecond, P95Latency: 20 * time.Millisecond},
+		{Monitor: "b", Resolution: ResolutionDay, Start: base, Count: 1},
+		{Monitor: "a", Resolution: ResolutionDay, Start: base.Add(24 * time.Hour), Count: 1},
+		{Monitor: "a", Resolution: ResolutionDay, Start: base, Count: 1, Failures: 1, AvgLatency: 30 * time.Millisecond, P95Latency: 30 * time.Millisecond},
+		{Monitor: "a", Resolution: ResolutionDay, Start: base},
+	})
+	assert.Equal(t, []Rollup{
+		{Monitor: "a", Resolution: ResolutionDay, Start: base, Count: 4, Failures: 2, AvgLatency: 15 * time.Millisecond, P95Latency: 30 * time.Millisecond},
+		{Monitor: "a", Resolution: ResolutionDay, Start: base.Add(24 * time.Hour), Count: 1},
+		{Monitor: "b", Resolution: ResolutionDay, Start: base, Count: 1},
+	}, rollups)
+}
+
+func TestBuildStore_Error(t *testing.T) {
+	_, err := BuildStore([]byte(`{"type": "nope"}`))
+	assert.EqualError(t, err, `unknown store type "nope"`)
+}
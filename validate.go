@@ -0,0 +1,69 @@
+package status_neko
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FieldError reports an invalid field of a configuration. Field is the
+// dotted JSON path of the field, e.g. "auth_config.username".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// FieldErrors are all the invalid fields of a configuration.
+type FieldErrors []*FieldError
+
+func (e FieldErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validator is implemented by configurations that check their own fields.
+// NewFactory, NewNotifierFactory and NewStoreFactory call Validate after
+// decoding, it should return a *FieldError or FieldErrors.
+type Validator interface {
+	Validate() error
+}
+
+// Fields finds the invalid fields behind err, including the fields of JSON
+// type errors. It returns nil when err does not point to a field.
+func Fields(err error) FieldErrors {
+	var fields FieldErrors
+	if errors.As(err, &fields) {
+		return fields
+	}
+	var field *FieldError
+	if errors.As(err, &field) {
+		return FieldErrors{field}
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return FieldErrors{{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value),
+		}}
+	}
+	return nil
+}
+
+// validate calls Validate when config is a Validator.
+func validate(config interface{}) error {
+	if v, ok := config.(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
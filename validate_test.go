@@ -0,0 +1,67 @@
+package status_neko
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type requiredConfig struct {
+	Message string `json:"message"`
+	Count   int    `json:"count"`
+}
+
+func (c *requiredConfig) Validate() error {
+	if c.Message == "" {
+		return &FieldError{Field: "message", Message: "is required"}
+	}
+	return nil
+}
+
+func init() {
+	Register("required", NewFactory(func(c requiredConfig) Monitor {
+		return echoMonitor{config: echoConfig{Message: c.Message}}
+	}))
+}
+
+func TestFields(t *testing.T) {
+	_, err := Build(json.RawMessage(`{"type": "required"}`))
+	require.Error(t, err)
+	assert.Equal(t, FieldErrors{{Field: "message", Message: "is required"}}, Fields(err))
+	assert.EqualError(t, err, "invalid required config: message: is required")
+
+	_, err = Build(json.RawMessage(`{"type": "required", "message": "hi", "count": "1"}`))
+	require.Error(t, err)
+	assert.Equal(t, FieldErrors{{Field: "count", Message: "expected int, got string"}}, Fields(err))
+
+	_, err = Build(json.RawMessage(`{"type": "required", "message": "hi"}`))
+	require.NoError(t, err)
+
+	fields := FieldErrors{{Field: "a", Message: "x"}, {Field: "b", Message: "y"}}
+	assert.Equal(t, fields, Fields(fmt.Errorf("wrapped: %w", fields)))
+	assert.EqualError(t, fields, "a: x; b: y")
+	assert.Nil(t, Fields(errors.New("boom")))
+}
+
+func TestDefinition_Validate(t *testing.T) {
+	var d Definition
+	require.NoError(t, json.Unmarshal([]byte(`{"name": "a", "type": "echo", "interval": "1s"}`), &d))
+	assert.NoError(t, d.Validate())
+
+	err := Definition{Type: "nope", Interval: -1}.Validate()
+	assert.Equal(t, FieldErrors{
+		{Field: "name", Message: "is required"},
+		{Field: "type", Message: `unknown monitor type "nope"`},
+		{Field: "interval", Message: "must not be negative"},
+	}, Fields(err))
+
+	err = json.Unmarshal([]byte(`{"name": "a", "type": "echo", "timeout": "soon"}`), &d)
+	require.Error(t, err)
+	fields := Fields(err)
+	require.Len(t, fields, 1)
+	assert.Equal(t, "timeout", fields[0].Field)
+}